@@ -0,0 +1,270 @@
+package redimo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB limits for a single BatchWriteItem / BatchGetItem call.
+// See https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchWriteItem.html
+// and https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_BatchGetItem.html
+const (
+	maxBatchWriteItems = 25
+	maxBatchGetItems   = 100
+	maxBatchAttempts   = 8
+)
+
+// ErrUnprocessedItems is returned when DynamoDB keeps handing back unprocessed items from a batch
+// call even after retrying with backoff, usually because the table is being throttled.
+var ErrUnprocessedItems = errors.New("batch operation left unprocessed items after retries")
+
+func putRequest(item map[string]types.AttributeValue) types.WriteRequest {
+	return types.WriteRequest{PutRequest: &types.PutRequest{Item: item}}
+}
+
+func deleteRequest(key map[string]types.AttributeValue) types.WriteRequest {
+	return types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}}
+}
+
+func chunkWriteRequests(requests []types.WriteRequest, size int) (chunks [][]types.WriteRequest) {
+	for len(requests) > size {
+		chunks = append(chunks, requests[:size])
+		requests = requests[size:]
+	}
+
+	if len(requests) > 0 {
+		chunks = append(chunks, requests)
+	}
+
+	return
+}
+
+func batchBackoff(attempt int) time.Duration {
+	return time.Duration(1<<uint(attempt)) * 25 * time.Millisecond
+}
+
+// batchWrite sends the given requests in chunks of 25, retrying unprocessed items with an exponential
+// backoff. The requests are not applied atomically – if an error is returned some chunks may already
+// have been written. A key must not appear more than once in the same call.
+func (c Client) batchWrite(requests []types.WriteRequest) error {
+	return c.batchWriteParallel(requests, 1)
+}
+
+// batchWriteParallel works like batchWrite, but sends up to concurrency chunks at the same time. Once a
+// chunk fails the remaining chunks are skipped and the first error is returned.
+func (c Client) batchWriteParallel(requests []types.WriteRequest, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	work := make(chan []types.WriteRequest)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for chunk := range work {
+				mu.Lock()
+				failed := firstErr != nil
+				mu.Unlock()
+
+				if failed {
+					continue
+				}
+
+				if err := c.batchWriteChunk(chunk); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, chunk := range chunkWriteRequests(requests, maxBatchWriteItems) {
+		work <- chunk
+	}
+
+	close(work)
+	wg.Wait()
+
+	return firstErr
+}
+
+func (c Client) batchWriteChunk(requests []types.WriteRequest) error {
+	pending := map[string][]types.WriteRequest{c.tableName: requests}
+
+	for attempt := 0; len(pending[c.tableName]) > 0; attempt++ {
+		if attempt >= maxBatchAttempts {
+			return ErrUnprocessedItems
+		}
+
+		if attempt > 0 {
+			time.Sleep(batchBackoff(attempt))
+		}
+
+		resp, err := c.ddbClient.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return err
+		}
+
+		pending = resp.UnprocessedItems
+	}
+
+	return nil
+}
+
+// bulkWrite writes the items of a bulk load into key, like ZADDBULK, with up to concurrency chunks in flight. Blind
+// writes can't tell new members from existing ones, so with CountedCardinality the count of key is rebuilt with
+// RepairCardinality afterwards – also after a failed load, since some of the items may have been written.
+func (c Client) bulkWrite(key string, requests []types.WriteRequest, concurrency int) error {
+	err := c.batchWriteParallel(requests, concurrency)
+
+	if c.counted(key) {
+		if _, rErr := c.RepairCardinality(key); err == nil {
+			err = rErr
+		}
+	}
+
+	return err
+}
+
+// transactWrite applies the given requests as a single TransactWriteItems call, so either all of them are
+// written or none are. The caller is responsible for staying within the transaction size limit.
+func (c Client) transactWrite(requests []types.WriteRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	items := make([]types.TransactWriteItem, len(requests))
+
+	for i, request := range requests {
+		if request.PutRequest != nil {
+			items[i].Put = &types.Put{
+				Item:      request.PutRequest.Item,
+				TableName: aws.String(c.tableName),
+			}
+		}
+
+		if request.DeleteRequest != nil {
+			items[i].Delete = &types.Delete{
+				Key:       request.DeleteRequest.Key,
+				TableName: aws.String(c.tableName),
+			}
+		}
+	}
+
+	_, err := c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+
+	return err
+}
+
+// batchGet fetches the items with the given keys in chunks of 100, retrying unprocessed keys with an exponential
+// backoff. Only the attributes in the projection are fetched. Items are returned in no particular order and
+// missing items are simply absent, so callers should match the results by key.
+func (c Client) batchGet(keys []keyDef, projection ...string) (items []map[string]types.AttributeValue, err error) {
+	seen := make(map[keyDef]struct{})
+	avKeys := make([]map[string]types.AttributeValue, 0, len(keys))
+
+	for _, key := range keys {
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			avKeys = append(avKeys, key.toAV(c))
+		}
+	}
+
+	for len(avKeys) > 0 {
+		chunk := avKeys
+		if len(chunk) > maxBatchGetItems {
+			chunk = chunk[:maxBatchGetItems]
+		}
+
+		avKeys = avKeys[len(chunk):]
+
+		chunkItems, err := c.batchGetChunk(chunk, projection)
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, chunkItems...)
+	}
+
+	return
+}
+
+// projectionWithNames builds a projection expression that refers to the attributes through expression
+// attribute names, so that attributes named after reserved words (like ttl) can be projected.
+func projectionWithNames(attributes []string) (expression *string, names map[string]string) {
+	names = make(map[string]string)
+	references := make([]string, len(attributes))
+
+	for i, attribute := range attributes {
+		names["#"+attribute] = attribute
+		references[i] = "#" + attribute
+	}
+
+	return aws.String(strings.Join(references, ", ")), names
+}
+
+func (c Client) batchGetChunk(keys []map[string]types.AttributeValue, projection []string) (items []map[string]types.AttributeValue, err error) {
+	var (
+		projectionExpression *string
+		projectionNames      map[string]string
+	)
+
+	if len(projection) > 0 {
+		projectionExpression, projectionNames = projectionWithNames(projection)
+	}
+
+	pending := map[string]types.KeysAndAttributes{
+		c.tableName: {
+			ConsistentRead:           aws.Bool(c.consistentReads),
+			ExpressionAttributeNames: projectionNames,
+			Keys:                     keys,
+			ProjectionExpression:     projectionExpression,
+		},
+	}
+
+	for attempt := 0; len(pending[c.tableName].Keys) > 0; attempt++ {
+		if attempt >= maxBatchAttempts {
+			return items, ErrUnprocessedItems
+		}
+
+		if attempt > 0 {
+			time.Sleep(batchBackoff(attempt))
+		}
+
+		resp, err := c.ddbClient.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, resp.Responses[c.tableName]...)
+		pending = resp.UnprocessedKeys
+	}
+
+	return
+}
@@ -0,0 +1,21 @@
+package redimo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkWriteRequests(t *testing.T) {
+	requests := make([]types.WriteRequest, 60)
+
+	chunks := chunkWriteRequests(requests, maxBatchWriteItems)
+	assert.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 25)
+	assert.Len(t, chunks[1], 25)
+	assert.Len(t, chunks[2], 10)
+
+	assert.Len(t, chunkWriteRequests(requests[:25], maxBatchWriteItems), 1)
+	assert.Empty(t, chunkWriteRequests(nil, maxBatchWriteItems))
+}
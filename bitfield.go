@@ -0,0 +1,243 @@
+package redimo
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrInvalidBitField is returned by BITFIELD for an integer type of no bits, signed integers of more than 64 bits,
+// unsigned integers of more than 63 bits, or offsets that are negative or beyond the 2^32 bits of a bitmap.
+var ErrInvalidBitField = errors.New("invalid bit field")
+
+// BitFieldType is the type of an integer in a BITFIELD operation, like i8 or u4 in Redis.
+type BitFieldType struct {
+	Signed bool
+	Bits   uint
+}
+
+// Signed returns the type of signed integers with the given number of bits, up to 64.
+func Signed(bits uint) BitFieldType {
+	return BitFieldType{Signed: true, Bits: bits}
+}
+
+// Unsigned returns the type of unsigned integers with the given number of bits, up to 63.
+func Unsigned(bits uint) BitFieldType {
+	return BitFieldType{Signed: false, Bits: bits}
+}
+
+func (t BitFieldType) valid() bool {
+	return t.Bits > 0 && (t.Signed && t.Bits <= 64 || !t.Signed && t.Bits <= 63)
+}
+
+func (t BitFieldType) min() *big.Int {
+	if !t.Signed {
+		return big.NewInt(0)
+	}
+
+	return new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), t.Bits-1))
+}
+
+func (t BitFieldType) max() *big.Int {
+	bits := t.Bits
+	if t.Signed {
+		bits--
+	}
+
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits), big.NewInt(1))
+}
+
+// BitFieldOverflow controls what SET and INCRBY do in a BITFIELD when the value doesn't fit into the integer type.
+type BitFieldOverflow string
+
+const (
+	// OverflowWrap wraps around, so incrementing the largest value gives the smallest. This is the default.
+	OverflowWrap BitFieldOverflow = "WRAP"
+	// OverflowSat saturates, so values stay at the largest or smallest value of the type.
+	OverflowSat BitFieldOverflow = "SAT"
+)
+
+type bitFieldOp struct {
+	op       string
+	typ      BitFieldType
+	offset   int64
+	value    int64
+	overflow BitFieldOverflow
+}
+
+// BitField is a list of operations for BITFIELD. Build it by chaining GET, SET, INCRBY and OVERFLOW on BitField{},
+// like BitField{}.OVERFLOW(OverflowSat).INCRBY(Unsigned(8), 0, 1).
+type BitField struct {
+	ops      []bitFieldOp
+	overflow BitFieldOverflow
+}
+
+func (bf BitField) add(op bitFieldOp) BitField {
+	op.overflow = bf.overflow
+	bf.ops = append(append([]bitFieldOp(nil), bf.ops...), op)
+
+	return bf
+}
+
+// GET returns the integer of the given type at the given bit offset.
+func (bf BitField) GET(typ BitFieldType, offset int64) BitField {
+	return bf.add(bitFieldOp{op: "GET", typ: typ, offset: offset})
+}
+
+// SET sets the integer of the given type at the given bit offset and returns its previous value.
+func (bf BitField) SET(typ BitFieldType, offset int64, value int64) BitField {
+	return bf.add(bitFieldOp{op: "SET", typ: typ, offset: offset, value: value})
+}
+
+// INCRBY increments the integer of the given type at the given bit offset and returns the new value.
+func (bf BitField) INCRBY(typ BitFieldType, offset int64, increment int64) BitField {
+	return bf.add(bitFieldOp{op: "INCRBY", typ: typ, offset: offset, value: increment})
+}
+
+// OVERFLOW sets the overflow behaviour of the SET and INCRBY operations that follow it.
+func (bf BitField) OVERFLOW(overflow BitFieldOverflow) BitField {
+	bf.overflow = overflow
+
+	return bf
+}
+
+func (bf BitField) readOnly() bool {
+	for _, op := range bf.ops {
+		if op.op != "GET" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BITFIELD runs the operations in bf on integers of arbitrary width packed into the bitmap at key, and returns the
+// result of each operation in order. The bitmap is the same one SETBIT and GETBIT work on, so BITFIELD and the bit
+// commands see the same bits, like in Redis. Bits are numbered from the most significant bit of the first byte, and
+// the bitmap grows as needed. To address the n-th integer of a type like #n in Redis, use n times its number of bits
+// as the offset.
+//
+// The operations are applied to the 1 KB chunks of the bitmap that they touch. If that is a single chunk, which it is
+// for a group of small counters, all operations are applied with a single conditional write, and otherwise with a
+// transaction over all the chunks, which returns ErrTooManyKeys if there are more of them than fit (see
+// TransactionActions). Either way the write is retried according to the RetryPolicy of the client if the chunks
+// change concurrently. If there are only GET operations, the chunks are only read.
+//
+// Cost is O(1) / 1 RCU + 1 WCU per attempt for a single chunk, twice that per chunk for transactions.
+//
+// Works similar to https://redis.io/commands/bitfield
+func (c Client) BITFIELD(key string, bf BitField) (results []int64, err error) {
+	for _, op := range bf.ops {
+		if !op.typ.valid() || op.offset < 0 || op.offset+int64(op.typ.Bits) > bitmapMaxBits {
+			return nil, ErrInvalidBitField
+		}
+	}
+
+	chunks := bf.chunks()
+
+	if bf.readOnly() {
+		bm, err := c.bitmapChunks(key, chunks)
+		if err != nil {
+			return nil, err
+		}
+
+		return bf.apply(bm), nil
+	}
+
+	err = c.updateBitmapChunks(key, chunks, func(bm bitmap) {
+		results = bf.apply(bm)
+	})
+
+	return
+}
+
+// chunks returns the numbers of the bitmap chunks the operations touch, in order.
+func (bf BitField) chunks() []int64 {
+	touched := make(bitmap)
+
+	for _, op := range bf.ops {
+		first := op.offset / 8 / bitmapChunkBytes
+		last := (op.offset + int64(op.typ.Bits) - 1) / 8 / bitmapChunkBytes
+
+		for chunk := first; chunk <= last; chunk++ {
+			touched[chunk] = nil
+		}
+	}
+
+	return touched.chunks()
+}
+
+// apply runs the operations on the chunks in bm, changing them in place, and returns their results.
+func (bf BitField) apply(bm bitmap) (results []int64) {
+	results = make([]int64, 0, len(bf.ops))
+
+	for _, op := range bf.ops {
+		current := readBits(bm, op.offset, op.typ)
+
+		switch op.op {
+		case "GET":
+			results = append(results, current)
+		case "SET":
+			writeBits(bm, op.offset, op.typ, op.fit(big.NewInt(op.value)))
+			results = append(results, current)
+		case "INCRBY":
+			value := op.fit(new(big.Int).Add(big.NewInt(current), big.NewInt(op.value)))
+			writeBits(bm, op.offset, op.typ, value)
+			results = append(results, value)
+		}
+	}
+
+	return results
+}
+
+// fit makes value fit into the type of the operation according to its overflow behaviour.
+func (op bitFieldOp) fit(value *big.Int) int64 {
+	min, max := op.typ.min(), op.typ.max()
+
+	if op.overflow == OverflowSat {
+		if value.Cmp(min) < 0 {
+			return min.Int64()
+		}
+
+		if value.Cmp(max) > 0 {
+			return max.Int64()
+		}
+
+		return value.Int64()
+	}
+
+	span := new(big.Int).Lsh(big.NewInt(1), op.typ.Bits)
+	wrapped := new(big.Int).Mod(value, span)
+
+	if wrapped.Cmp(max) > 0 {
+		wrapped.Sub(wrapped, span)
+	}
+
+	return wrapped.Int64()
+}
+
+// readBits reads an integer of the given type at the given bit offset. Bits beyond the end of the bitmap are 0.
+func readBits(bm bitmap, offset int64, typ BitFieldType) int64 {
+	var raw uint64
+
+	for i := int64(0); i < int64(typ.Bits); i++ {
+		raw <<= 1
+
+		if bm.bit(offset + i) {
+			raw |= 1
+		}
+	}
+
+	if typ.Signed && typ.Bits < 64 && raw&(1<<(typ.Bits-1)) != 0 {
+		raw |= ^uint64(0) << typ.Bits
+	}
+
+	return int64(raw)
+}
+
+// writeBits writes the low bits of value as an integer of the given type at the given bit offset, growing the
+// chunks of the bitmap as needed.
+func writeBits(bm bitmap, offset int64, typ BitFieldType, value int64) {
+	for i := int64(0); i < int64(typ.Bits); i++ {
+		bm.setBit(offset+i, uint64(value)&(1<<(typ.Bits-1-uint(i))) != 0)
+	}
+}
@@ -0,0 +1,81 @@
+package redimo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitFieldOps(t *testing.T) {
+	bm := bitmap{}
+	results := BitField{}.SET(Unsigned(8), 0, 255).GET(Signed(8), 0).GET(Unsigned(4), 4).apply(bm)
+	assert.Equal(t, []int64{0, -1, 15}, results)
+	assert.Equal(t, bitmap{0: {0xff}}, bm)
+
+	results = BitField{}.INCRBY(Unsigned(2), 100, 1).INCRBY(Unsigned(2), 100, 3).apply(bitmap{})
+	assert.Equal(t, []int64{1, 0}, results)
+
+	results = BitField{}.OVERFLOW(OverflowSat).INCRBY(Signed(4), 0, 10).INCRBY(Signed(4), 0, -20).apply(bitmap{})
+	assert.Equal(t, []int64{7, -8}, results)
+
+	results = BitField{}.SET(Signed(8), 3, 200).GET(Signed(8), 3).apply(bitmap{})
+	assert.Equal(t, []int64{0, -56}, results)
+
+	results = BitField{}.SET(Signed(64), 0, -2).INCRBY(Signed(64), 0, 1).apply(bitmap{})
+	assert.Equal(t, []int64{0, -1}, results)
+
+	results = BitField{}.SET(Unsigned(63), 0, 1<<62).GET(Unsigned(63), 0).apply(bitmap{})
+	assert.Equal(t, []int64{0, 1 << 62}, results)
+
+	bm = bitmap{}
+	boundary := int64(bitmapChunkBytes*8 - 4)
+	results = BitField{}.SET(Unsigned(8), boundary, 0xff).GET(Unsigned(8), boundary).apply(bm)
+	assert.Equal(t, []int64{0, 0xff}, results)
+	assert.Equal(t, byte(0x0f), bm[0][bitmapChunkBytes-1])
+	assert.Equal(t, []byte{0xf0}, bm[1])
+
+	assert.Equal(t, []int64{0, 1}, BitField{}.GET(Unsigned(8), boundary).GET(Unsigned(4), 0).chunks())
+}
+
+func TestBitField(t *testing.T) {
+	c := newClient(t)
+
+	results, err := c.BITFIELD("counters", BitField{}.INCRBY(Unsigned(8), 0, 5).INCRBY(Unsigned(8), 8, 300))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{5, 44}, results)
+
+	results, err = c.BITFIELD("counters", BitField{}.OVERFLOW(OverflowSat).INCRBY(Unsigned(8), 0, 300).GET(Unsigned(8), 8))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{255, 44}, results)
+
+	count, err := c.BITCOUNT("counters")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8+3), count)
+
+	_, err = c.SETBIT("counters", 15, true)
+	assert.NoError(t, err)
+
+	results, err = c.BITFIELD("counters", BitField{}.GET(Unsigned(8), 8))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{45}, results)
+
+	boundary := int64(bitmapChunkBytes*8 - 4)
+
+	results, err = c.BITFIELD("counters", BitField{}.SET(Unsigned(8), boundary, 0xff).GET(Unsigned(8), 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 255}, results)
+
+	bit, err := c.GETBIT("counters", boundary+7)
+	assert.NoError(t, err)
+	assert.True(t, bit)
+
+	results, err = c.BITFIELD("nothing", BitField{}.GET(Signed(16), 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0}, results)
+
+	_, err = c.BITFIELD("counters", BitField{}.GET(Unsigned(64), 0))
+	assert.Equal(t, ErrInvalidBitField, err)
+
+	_, err = c.BITFIELD("counters", BitField{}.GET(Unsigned(8), 1<<32-4))
+	assert.Equal(t, ErrInvalidBitField, err)
+}
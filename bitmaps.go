@@ -0,0 +1,613 @@
+package redimo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// bitmapChunkBytes is the size of the chunks a bitmap is split into. Each chunk is a separate item, so setting a bit
+// only reads and writes the 8192 bits around it, and a bitmap can grow far beyond the item size limit.
+const bitmapChunkBytes = 1024
+
+// bitmapMaxBits is the number of bits a bitmap can hold, the same as in Redis. Offsets from there on are rejected
+// with ErrOffsetOutOfRange.
+const bitmapMaxBits = 1 << 32
+
+// ErrInvalidBitOp is returned by BITOP for an unknown operation, or for NOT with other than one source key.
+var ErrInvalidBitOp = errors.New("invalid bit operation")
+
+// BitOp is a bitwise operation for BITOP.
+type BitOp string
+
+const (
+	BitAnd BitOp = "AND"
+	BitOr  BitOp = "OR"
+	BitXor BitOp = "XOR"
+	BitNot BitOp = "NOT"
+)
+
+// bitmapChunkKey returns the sort key of a chunk, padded so that the chunks of a bitmap sort in order.
+func bitmapChunkKey(chunk int64) string {
+	return fmt.Sprintf("%019d", chunk)
+}
+
+// bitmap holds the chunks of a bitmap by chunk number. Missing chunks are all zeros.
+type bitmap map[int64][]byte
+
+// length returns the length of the bitmap in bytes, up to the end of the last byte stored.
+func (bm bitmap) length() (length int64) {
+	for chunk, data := range bm {
+		if end := chunk*bitmapChunkBytes + int64(len(data)); end > length {
+			length = end
+		}
+	}
+
+	return
+}
+
+// chunks returns the numbers of the chunks that are stored, in order.
+func (bm bitmap) chunks() []int64 {
+	chunks := make([]int64, 0, len(bm))
+	for chunk := range bm {
+		chunks = append(chunks, chunk)
+	}
+
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i] < chunks[j] })
+
+	return chunks
+}
+
+func (bm bitmap) byteAt(offset int64) byte {
+	data := bm[offset/bitmapChunkBytes]
+	if i := offset % bitmapChunkBytes; i < int64(len(data)) {
+		return data[i]
+	}
+
+	return 0
+}
+
+// bit returns the bit at offset.
+func (bm bitmap) bit(offset int64) bool {
+	return bm.byteAt(offset/8)&byte(0x80>>uint(offset%8)) != 0
+}
+
+// setBit sets or clears the bit at offset, growing its chunk as needed.
+func (bm bitmap) setBit(offset int64, value bool) {
+	chunk, index, mask := offset/8/bitmapChunkBytes, offset/8%bitmapChunkBytes, byte(0x80>>uint(offset%8))
+
+	data := bm[chunk]
+	if index >= int64(len(data)) {
+		data = append(data, make([]byte, index+1-int64(len(data)))...)
+	}
+
+	if value {
+		data[index] |= mask
+	} else {
+		data[index] &^= mask
+	}
+
+	bm[chunk] = data
+}
+
+// SETBIT sets or clears the bit at offset in the bitmap at key and returns the previous value of the bit. Bits are
+// numbered from the most significant bit of the first byte, like in Redis, and the bitmap grows as needed. Offsets
+// have to be below 2^32, like in Redis, and ErrOffsetOutOfRange is returned otherwise.
+//
+// Bitmaps are stored in chunks of 1 KB, each in its own item, so a bitmap can hold far more bits than fit into a
+// single item. Setting a bit reads its chunk and writes it back on the condition that it hasn't changed, retrying
+// according to the RetryPolicy of the client if other clients change the same chunk concurrently.
+//
+// Cost is O(1) / 1 RCU + 1 WCU per attempt.
+//
+// Works similar to https://redis.io/commands/setbit
+func (c Client) SETBIT(key string, offset int64, value bool) (previous bool, err error) {
+	if offset < 0 || offset >= bitmapMaxBits {
+		return false, ErrOffsetOutOfRange
+	}
+
+	chunk, index, mask := offset/8/bitmapChunkBytes, offset/8%bitmapChunkBytes, byte(0x80>>uint(offset%8))
+
+	err = c.updateBitmapChunk(key, chunk, func(data []byte) []byte {
+		previous = index < int64(len(data)) && data[index]&mask != 0
+		if previous == value {
+			return data
+		}
+
+		if index >= int64(len(data)) {
+			data = append(data, make([]byte, index+1-int64(len(data)))...)
+		}
+
+		data[index] ^= mask
+
+		return data
+	})
+
+	return
+}
+
+// setBits sets the bits at the given offsets in the bitmap at key, with one conditional write for each chunk that
+// changes, and returns whether any of the bits was 0 before. The writes to different chunks are not atomic.
+func (c Client) setBits(key string, offsets []int64) (changed bool, err error) {
+	chunks := make(map[int64][]int64)
+	for _, offset := range offsets {
+		chunk := offset / 8 / bitmapChunkBytes
+		chunks[chunk] = append(chunks[chunk], offset)
+	}
+
+	for chunk, chunkOffsets := range chunks {
+		var chunkChanged bool
+
+		err = c.updateBitmapChunk(key, chunk, func(data []byte) []byte {
+			chunkChanged = false
+
+			for _, offset := range chunkOffsets {
+				index, mask := offset/8%bitmapChunkBytes, byte(0x80>>uint(offset%8))
+				if index >= int64(len(data)) {
+					data = append(data, make([]byte, index+1-int64(len(data)))...)
+				}
+
+				if data[index]&mask == 0 {
+					data[index] |= mask
+					chunkChanged = true
+				}
+			}
+
+			return data
+		})
+		if err != nil {
+			return
+		}
+
+		changed = changed || chunkChanged
+	}
+
+	return
+}
+
+// updateBitmapChunk reads a chunk of the bitmap at key, passes a copy of it to fn, and writes back the result if it
+// is different, on the condition that the chunk hasn't changed in between. It retries according to the RetryPolicy
+// of the client if the condition fails, so fn may be called more than once.
+func (c Client) updateBitmapChunk(key string, chunk int64, fn func(data []byte) []byte) error {
+	return c.retryPolicy.retry(func() (done bool, err error) {
+		current, found, err := c.bitmapChunk(key, chunk)
+		if err != nil {
+			return
+		}
+
+		updated := fn(append([]byte(nil), current...))
+		if found && bytes.Equal(updated, current) || !found && len(updated) == 0 {
+			return true, nil
+		}
+
+		builder := newExpresionBuilder()
+		builder.updateSET(vk, BytesValue{updated})
+
+		if found {
+			builder.addConditionEquality(vk, BytesValue{current})
+		} else {
+			builder.addConditionNotExists(c.partitionKey)
+		}
+
+		_, err = c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+			ConditionExpression:       builder.conditionExpression(),
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			Key:                       keyDef{pk: key, sk: bitmapChunkKey(chunk)}.toAV(c),
+			TableName:                 aws.String(c.tableName),
+			UpdateExpression:          builder.updateExpression(),
+		})
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		return err == nil, err
+	})
+}
+
+// updateBitmapChunks reads the given chunks of the bitmap at key, passes them to fn, which changes them in place,
+// and writes back the chunks that changed, on the condition that none of the chunks changed in between. A single
+// chunk is written like in updateBitmapChunk, and more chunks with a transaction, which returns ErrTooManyKeys if
+// they don't fit. Conflicts are retried according to the RetryPolicy of the client, so fn may be called more than
+// once.
+func (c Client) updateBitmapChunks(key string, chunks []int64, fn func(bm bitmap)) error {
+	if len(chunks) == 1 {
+		return c.updateBitmapChunk(key, chunks[0], func(data []byte) []byte {
+			bm := bitmap{chunks[0]: data}
+			fn(bm)
+
+			return bm[chunks[0]]
+		})
+	}
+
+	if len(chunks) > c.transactionActions {
+		return ErrTooManyKeys
+	}
+
+	return c.retryPolicy.retry(func() (done bool, err error) {
+		current, err := c.bitmapChunks(key, chunks)
+		if err != nil {
+			return
+		}
+
+		updated := make(bitmap, len(current))
+		for chunk, data := range current {
+			updated[chunk] = append([]byte(nil), data...)
+		}
+
+		fn(updated)
+
+		actions := make([]types.TransactWriteItem, 0, len(chunks))
+		changed := false
+
+		for _, chunk := range chunks {
+			data, found := current[chunk]
+
+			builder := newExpresionBuilder()
+			if found {
+				builder.addConditionEquality(vk, BytesValue{data})
+			} else {
+				builder.addConditionNotExists(c.partitionKey)
+			}
+
+			if found && bytes.Equal(updated[chunk], data) || !found && len(updated[chunk]) == 0 {
+				actions = append(actions, types.TransactWriteItem{
+					ConditionCheck: &types.ConditionCheck{
+						ConditionExpression:       builder.conditionExpression(),
+						ExpressionAttributeNames:  builder.expressionAttributeNames(),
+						ExpressionAttributeValues: builder.expressionAttributeValues(),
+						Key:                       keyDef{pk: key, sk: bitmapChunkKey(chunk)}.toAV(c),
+						TableName:                 aws.String(c.tableName),
+					},
+				})
+
+				continue
+			}
+
+			changed = true
+
+			item := keyDef{pk: key, sk: bitmapChunkKey(chunk)}.toAV(c)
+			item[vk] = BytesValue{updated[chunk]}.ToAV()
+
+			actions = append(actions, types.TransactWriteItem{
+				Put: &types.Put{
+					ConditionExpression:       builder.conditionExpression(),
+					ExpressionAttributeNames:  builder.expressionAttributeNames(),
+					ExpressionAttributeValues: builder.expressionAttributeValues(),
+					Item:                      item,
+					TableName:                 aws.String(c.tableName),
+				},
+			})
+		}
+
+		if !changed {
+			return true, nil
+		}
+
+		_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: actions,
+		})
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		return err == nil, err
+	})
+}
+
+// GETBIT returns the bit at offset in the bitmap at key. Bits beyond the end of the bitmap are 0, and offsets that
+// are out of range for SETBIT return ErrOffsetOutOfRange.
+//
+// Cost is O(1) / 1 RCU.
+//
+// Works similar to https://redis.io/commands/getbit
+func (c Client) GETBIT(key string, offset int64) (value bool, err error) {
+	if offset < 0 || offset >= bitmapMaxBits {
+		return false, ErrOffsetOutOfRange
+	}
+
+	data, _, err := c.bitmapChunk(key, offset/8/bitmapChunkBytes)
+	if index := offset / 8 % bitmapChunkBytes; index < int64(len(data)) {
+		value = data[index]&byte(0x80>>uint(offset%8)) != 0
+	}
+
+	return
+}
+
+// BITCOUNT returns the number of bits set to 1 in the bitmap at key.
+//
+// Cost is O(N) / 1 RCU per 4 KB of the bitmap.
+//
+// Works similar to https://redis.io/commands/bitcount
+func (c Client) BITCOUNT(key string) (count int64, err error) {
+	return c.BITCOUNTRANGE(key, 0, -1)
+}
+
+// BITCOUNTRANGE works like BITCOUNT, but only counts the bits in the bytes from start to end, both inclusive.
+// Negative offsets count from the end of the bitmap, so -1 is the last byte.
+//
+// Works similar to https://redis.io/commands/bitcount
+func (c Client) BITCOUNTRANGE(key string, start, end int64) (count int64, err error) {
+	bm, err := c.bitmap(key)
+	if err != nil {
+		return
+	}
+
+	start, end = byteRange(start, end, bm.length())
+
+	for chunk, data := range bm {
+		for i, b := range data {
+			if offset := chunk*bitmapChunkBytes + int64(i); offset >= start && offset <= end {
+				count += int64(bits.OnesCount8(b))
+			}
+		}
+	}
+
+	return
+}
+
+// byteRange resolves a Redis style inclusive range with negative offsets over the given length. The range is empty
+// if start is after end.
+func byteRange(start, end, length int64) (int64, int64) {
+	if start < 0 {
+		start += length
+	}
+
+	if end < 0 {
+		end += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if end >= length {
+		end = length - 1
+	}
+
+	return start, end
+}
+
+// BITPOS returns the position of the first bit set to value in the bitmap at key, or -1 if there is none. As in
+// Redis, when looking for a 0 in a bitmap that has all its bits set, the first bit after the end of the bitmap is
+// returned, and looking for a 0 in a bitmap that doesn't exist returns 0. Only the chunks that are stored are
+// searched, and the chunks that are missing in between are all zeros.
+//
+// Cost is O(N) / 1 RCU per 4 KB of the bitmap.
+//
+// Works similar to https://redis.io/commands/bitpos
+func (c Client) BITPOS(key string, value bool) (position int64, err error) {
+	bm, err := c.bitmap(key)
+	if err != nil {
+		return -1, err
+	}
+
+	next := int64(0)
+
+	for _, chunk := range bm.chunks() {
+		if !value && chunk > next {
+			return next * bitmapChunkBytes * 8, nil
+		}
+
+		data := bm[chunk]
+
+		for i, b := range data {
+			if !value {
+				b = ^b
+			}
+
+			if b != 0 {
+				return (chunk*bitmapChunkBytes+int64(i))*8 + int64(bits.LeadingZeros8(b)), nil
+			}
+		}
+
+		if !value && len(data) < bitmapChunkBytes {
+			return (chunk*bitmapChunkBytes + int64(len(data))) * 8, nil
+		}
+
+		next = chunk + 1
+	}
+
+	if value {
+		return -1, nil
+	}
+
+	return next * bitmapChunkBytes * 8, nil
+}
+
+// BITOP performs a bitwise operation between the bitmaps at the source keys and stores the result in the bitmap at
+// the destination key, replacing it, and returns the length of the result in bytes. Shorter bitmaps are treated as
+// if they were padded with zeros to the length of the longest one. NOT takes exactly one source key.
+//
+// The result is computed one chunk at a time, and only for the chunks that are stored in any of the sources, since
+// the others are all zeros. NOT is the exception: the chunks missing from its source turn into ones, so it writes
+// every chunk up to the length of the source. The result is written with batched writes and is not atomic.
+//
+// Cost is O(N) / 1 RCU per 4 KB of the source bitmaps plus 1 WCU per KB written.
+//
+// Works similar to https://redis.io/commands/bitop
+func (c Client) BITOP(op BitOp, destinationKey string, sourceKeys ...string) (length int64, err error) {
+	switch op {
+	case BitAnd, BitOr, BitXor:
+		if len(sourceKeys) == 0 {
+			return 0, ErrInvalidBitOp
+		}
+	case BitNot:
+		if len(sourceKeys) != 1 {
+			return 0, ErrInvalidBitOp
+		}
+	default:
+		return 0, ErrInvalidBitOp
+	}
+
+	chunks := make(map[int64]struct{})
+
+	sources := make([]bitmap, len(sourceKeys))
+	for i, sourceKey := range sourceKeys {
+		if sources[i], err = c.bitmap(sourceKey); err != nil {
+			return
+		}
+
+		if l := sources[i].length(); l > length {
+			length = l
+		}
+
+		for chunk := range sources[i] {
+			chunks[chunk] = struct{}{}
+		}
+	}
+
+	if length == 0 {
+		return 0, c.putBitmap(destinationKey, nil, 0)
+	}
+
+	// The last chunk is always written, so that the result keeps its length.
+	chunks[(length-1)/bitmapChunkBytes] = struct{}{}
+
+	if op == BitNot {
+		for chunk := int64(0); chunk*bitmapChunkBytes < length; chunk++ {
+			chunks[chunk] = struct{}{}
+		}
+	}
+
+	result := make(bitmap, len(chunks))
+
+	for chunk := range chunks {
+		size := length - chunk*bitmapChunkBytes
+		if size > bitmapChunkBytes {
+			size = bitmapChunkBytes
+		}
+
+		data := make([]byte, size)
+
+		for i := range data {
+			offset := chunk*bitmapChunkBytes + int64(i)
+			b := sources[0].byteAt(offset)
+
+			for _, source := range sources[1:] {
+				switch op {
+				case BitAnd:
+					b &= source.byteAt(offset)
+				case BitOr:
+					b |= source.byteAt(offset)
+				case BitXor:
+					b ^= source.byteAt(offset)
+				}
+			}
+
+			if op == BitNot {
+				b = ^b
+			}
+
+			data[i] = b
+		}
+
+		result[chunk] = data
+	}
+
+	return length, c.putBitmap(destinationKey, result, length)
+}
+
+// putBitmap replaces the bitmap at key with the chunks of bm, skipping the chunks that are all zeros except for the
+// last one, which holds the length of the bitmap.
+func (c Client) putBitmap(key string, bm bitmap, length int64) (err error) {
+	if _, err = c.DEL(key); err != nil {
+		return
+	}
+
+	var requests []types.WriteRequest
+
+	for _, chunk := range bm.chunks() {
+		data := bm[chunk]
+		if isZero(data) && chunk != (length-1)/bitmapChunkBytes {
+			continue
+		}
+
+		item := keyDef{pk: key, sk: bitmapChunkKey(chunk)}.toAV(c)
+		item[vk] = BytesValue{data}.ToAV()
+		requests = append(requests, putRequest(item))
+	}
+
+	return c.batchWrite(requests)
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bitmapChunk reads a single chunk of the bitmap at key.
+func (c Client) bitmapChunk(key string, chunk int64) (data []byte, found bool, err error) {
+	resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		Key:            keyDef{pk: key, sk: bitmapChunkKey(chunk)}.toAV(c),
+		TableName:      aws.String(c.tableName),
+	})
+	if err != nil || len(resp.Item) == 0 {
+		return
+	}
+
+	return ReturnValue{resp.Item[vk]}.Bytes(), true, nil
+}
+
+// bitmapChunks reads the given chunks of the bitmap at key. Chunks that don't exist are missing from the result.
+func (c Client) bitmapChunks(key string, chunks []int64) (bm bitmap, err error) {
+	c.consistentReads = true
+
+	keys := make([]keyDef, len(chunks))
+	for i, chunk := range chunks {
+		keys[i] = keyDef{pk: key, sk: bitmapChunkKey(chunk)}
+	}
+
+	items, err := c.batchGet(keys)
+	if err != nil {
+		return
+	}
+
+	bm = make(bitmap, len(items))
+
+	for _, item := range items {
+		chunk, err := strconv.ParseInt(parseKey(item, c).sk, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		bm[chunk] = ReturnValue{item[vk]}.Bytes()
+	}
+
+	return
+}
+
+// bitmap reads all the chunks of the bitmap at key.
+func (c Client) bitmap(key string) (bm bitmap, err error) {
+	items, err := c.keyItems(key)
+	if err != nil {
+		return
+	}
+
+	bm = make(bitmap, len(items))
+
+	for _, item := range items {
+		chunk, err := strconv.ParseInt(parseKey(item, c).sk, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		bm[chunk] = ReturnValue{item[vk]}.Bytes()
+	}
+
+	return
+}
@@ -0,0 +1,152 @@
+package redimo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitmaps(t *testing.T) {
+	c := newClient(t)
+
+	previous, err := c.SETBIT("dau", 7, true)
+	assert.NoError(t, err)
+	assert.False(t, previous)
+
+	previous, err = c.SETBIT("dau", 7, true)
+	assert.NoError(t, err)
+	assert.True(t, previous)
+
+	_, err = c.SETBIT("dau", 100000, true)
+	assert.NoError(t, err)
+
+	bit, err := c.GETBIT("dau", 7)
+	assert.NoError(t, err)
+	assert.True(t, bit)
+
+	bit, err = c.GETBIT("dau", 6)
+	assert.NoError(t, err)
+	assert.False(t, bit)
+
+	bit, err = c.GETBIT("dau", 1<<31)
+	assert.NoError(t, err)
+	assert.False(t, bit)
+
+	_, err = c.GETBIT("dau", 1<<32)
+	assert.Equal(t, ErrOffsetOutOfRange, err)
+
+	count, err := c.BITCOUNT("dau")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = c.BITCOUNTRANGE("dau", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = c.BITCOUNTRANGE("dau", -1, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	position, err := c.BITPOS("dau", true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), position)
+
+	position, err = c.BITPOS("dau", false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), position)
+
+	position, err = c.BITPOS("nothing", true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), position)
+
+	previous, err = c.SETBIT("dau", 7, false)
+	assert.NoError(t, err)
+	assert.True(t, previous)
+
+	position, err = c.BITPOS("dau", true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100000), position)
+
+	_, err = c.SETBIT("dau", -1, true)
+	assert.Equal(t, ErrOffsetOutOfRange, err)
+
+	_, err = c.SETBIT("dau", 1<<32, true)
+	assert.Equal(t, ErrOffsetOutOfRange, err)
+}
+
+func TestSparseBitmaps(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SETBIT("sparse", 1<<32-1, true)
+	assert.NoError(t, err)
+
+	position, err := c.BITPOS("sparse", true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1<<32-1), position)
+
+	position, err = c.BITPOS("sparse", false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), position)
+
+	_, err = c.SETBIT("small", 3, true)
+	assert.NoError(t, err)
+
+	length, err := c.BITOP(BitOr, "result", "sparse", "small")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1<<32/8), length)
+
+	count, err := c.BITCOUNT("result")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	position, err = c.BITPOS("result", false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), position)
+}
+
+func TestBitOp(t *testing.T) {
+	c := newClient(t)
+
+	for _, offset := range []int64{0, 1, 2, 20000} {
+		_, err := c.SETBIT("monday", offset, true)
+		assert.NoError(t, err)
+	}
+
+	for _, offset := range []int64{1, 2, 3} {
+		_, err := c.SETBIT("tuesday", offset, true)
+		assert.NoError(t, err)
+	}
+
+	length, err := c.BITOP(BitAnd, "both", "monday", "tuesday")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20000/8+1), length)
+
+	count, err := c.BITCOUNT("both")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	_, err = c.BITOP(BitOr, "either", "monday", "tuesday")
+	assert.NoError(t, err)
+
+	count, err = c.BITCOUNT("either")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+
+	_, err = c.BITOP(BitXor, "one", "monday", "tuesday")
+	assert.NoError(t, err)
+
+	count, err = c.BITCOUNT("one")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	length, err = c.BITOP(BitNot, "not", "tuesday")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), length)
+
+	position, err := c.BITPOS("not", false)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), position)
+
+	_, err = c.BITOP(BitNot, "not", "monday", "tuesday")
+	assert.Equal(t, ErrInvalidBitOp, err)
+}
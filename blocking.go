@@ -0,0 +1,85 @@
+package redimo
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// PollPolicy controls how the blocking operations (like BZPOPMIN) wait for data. DynamoDB has no way to push
+// changes to clients, so blocking operations poll instead: after an empty attempt they wait Interval before
+// trying again, multiplying the wait by Backoff after every empty attempt, up to MaxInterval.
+//
+// The zero value is usable and is equivalent to DefaultPollPolicy. A Backoff of 1 polls at a fixed interval.
+type PollPolicy struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Backoff     float64
+}
+
+// DefaultPollPolicy starts polling every 100ms and backs off to one poll every 5s.
+var DefaultPollPolicy = PollPolicy{
+	Interval:    100 * time.Millisecond,
+	MaxInterval: 5 * time.Second,
+	Backoff:     2,
+}
+
+func (p PollPolicy) normalized() PollPolicy {
+	if p.Interval <= 0 {
+		p.Interval = DefaultPollPolicy.Interval
+	}
+
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = DefaultPollPolicy.MaxInterval
+	}
+
+	if p.MaxInterval < p.Interval {
+		p.MaxInterval = p.Interval
+	}
+
+	if p.Backoff < 1 {
+		p.Backoff = DefaultPollPolicy.Backoff
+	}
+
+	return p
+}
+
+func (p PollPolicy) next(wait time.Duration) time.Duration {
+	wait = time.Duration(float64(wait) * p.Backoff)
+	if wait > p.MaxInterval {
+		wait = p.MaxInterval
+	}
+
+	return wait
+}
+
+// poll calls attempt until it reports that it's done, returns an error, or the context ends. A context
+// that hits its deadline is treated like a Redis blocking timeout and returns ok = false with no error, while
+// a cancelled context returns the context error.
+func (p PollPolicy) poll(ctx context.Context, attempt func() (done bool, err error)) (ok bool, err error) {
+	p = p.normalized()
+	wait := p.Interval
+
+	for {
+		done, err := attempt()
+		if err != nil || done {
+			return done, err
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return false, nil
+			}
+
+			return false, ctx.Err()
+		case <-timer.C:
+		}
+
+		wait = p.next(wait)
+	}
+}
@@ -0,0 +1,59 @@
+package redimo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPollPolicyBackoff(t *testing.T) {
+	p := PollPolicy{}.normalized()
+	assert.Equal(t, DefaultPollPolicy, p)
+
+	p = PollPolicy{Interval: time.Second, MaxInterval: 3 * time.Second, Backoff: 2}.normalized()
+	assert.Equal(t, 2*time.Second, p.next(time.Second))
+	assert.Equal(t, 3*time.Second, p.next(2*time.Second))
+
+	p = PollPolicy{Interval: time.Second, Backoff: 1}.normalized()
+	assert.Equal(t, time.Second, p.next(time.Second))
+}
+
+func TestPollPolicyPoll(t *testing.T) {
+	policy := PollPolicy{Interval: time.Millisecond, MaxInterval: 2 * time.Millisecond, Backoff: 2}
+
+	attempts := 0
+	ok, err := policy.poll(context.Background(), func() (bool, error) {
+		attempts++
+		return attempts == 3, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, attempts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	ok, err = policy.poll(ctx, func() (bool, error) {
+		return false, nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	ok, err = policy.poll(ctx, func() (bool, error) {
+		return false, nil
+	})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, ok)
+
+	failure := errors.New("failure")
+	_, err = policy.poll(context.Background(), func() (bool, error) {
+		return false, failure
+	})
+	assert.Equal(t, failure, err)
+}
@@ -0,0 +1,146 @@
+package redimo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// bloomField is the field of the _redimo/<key> metadata hash that holds the number of bits and hash functions of
+// the Bloom filter at key.
+const bloomField = "bloom"
+
+// The error rate and capacity of Bloom filters that are created by BFADD without BFRESERVE, the same as in Redis.
+const (
+	DefaultBloomErrorRate = 0.01
+	DefaultBloomCapacity  = 100
+)
+
+// ErrInvalidFilter is returned when creating a filter with an error rate that isn't between 0 and 1, or a capacity
+// that isn't positive.
+var ErrInvalidFilter = errors.New("invalid filter parameters")
+
+type bloomFilter struct {
+	bits   int64
+	hashes int64
+}
+
+// newBloomFilter sizes a Bloom filter for the given capacity and error rate.
+func newBloomFilter(errorRate float64, capacity int64) bloomFilter {
+	bits := math.Ceil(-float64(capacity) * math.Log(errorRate) / (math.Ln2 * math.Ln2))
+	hashes := math.Round(bits / float64(capacity) * math.Ln2)
+
+	return bloomFilter{bits: int64(bits), hashes: int64(math.Max(hashes, 1))}
+}
+
+// offsets returns the bits for member, using double hashing over the two halves of its 128 bit FNV-1a hash.
+func (bf bloomFilter) offsets(member string) []int64 {
+	h := fnv.New128a()
+	_, _ = h.Write([]byte(member))
+	sum := h.Sum(nil)
+	h1, h2 := binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:])
+
+	offsets := make([]int64, bf.hashes)
+	for i := range offsets {
+		offsets[i] = int64((h1 + uint64(i)*h2) % uint64(bf.bits))
+	}
+
+	return offsets
+}
+
+// BFRESERVE creates an empty Bloom filter at key that holds capacity members with the given rate of false
+// positives, like 0.001 for one in a thousand. Returns false if there already is a filter at key. Adding more
+// members than the capacity makes false positives more likely, but still works.
+//
+// The bits of the filter are stored like a bitmap at key, in chunks of 1 KB each in their own item (see SETBIT),
+// and its size is stored in the _redimo/<key> metadata hash, so a filter for millions of members doesn't run into
+// the item size limit. A capacity of a million with an error rate of 1% takes about 1.2 MB.
+//
+// Works similar to https://redis.io/commands/bf.reserve
+func (c Client) BFRESERVE(key string, errorRate float64, capacity int64) (ok bool, err error) {
+	if errorRate <= 0 || errorRate >= 1 || capacity <= 0 {
+		return false, ErrInvalidFilter
+	}
+
+	bf := newBloomFilter(errorRate, capacity)
+
+	return c.HSETNX(metadataKey(key), bloomField, StringValue{fmt.Sprintf("%d %d", bf.bits, bf.hashes)})
+}
+
+// BFADD adds member to the Bloom filter at key, creating the filter with DefaultBloomErrorRate and
+// DefaultBloomCapacity if it doesn't exist. Returns true if the member was added, and false if it may have been
+// added before.
+//
+// The bits of the member are set with one conditional write for each chunk they fall into, so adding is not atomic,
+// but concurrent adds never lose each other's bits.
+//
+// Cost is O(k) / 1 RCU + 1 WCU for each of the k hash functions at worst, and usually less since several bits fall
+// into the same chunk.
+//
+// Works similar to https://redis.io/commands/bf.add
+func (c Client) BFADD(key string, member string) (added bool, err error) {
+	bf, found, err := c.bloomFilter(key)
+	if err == nil && !found {
+		_, err = c.BFRESERVE(key, DefaultBloomErrorRate, DefaultBloomCapacity)
+		if err == nil {
+			bf, _, err = c.bloomFilter(key)
+		}
+	}
+
+	if err != nil {
+		return
+	}
+
+	return c.setBits(key, bf.offsets(member))
+}
+
+// BFEXISTS checks whether member may have been added to the Bloom filter at key. False means the member was
+// definitely never added, true means it probably was. Returns false if there is no filter at key.
+//
+// Cost is O(k) / 1 RCU for each chunk the k bits of the member fall into.
+//
+// Works similar to https://redis.io/commands/bf.exists
+func (c Client) BFEXISTS(key string, member string) (exists bool, err error) {
+	bf, found, err := c.bloomFilter(key)
+	if err != nil || !found {
+		return
+	}
+
+	chunks := make(map[int64][]byte)
+
+	for _, offset := range bf.offsets(member) {
+		chunk := offset / 8 / bitmapChunkBytes
+
+		data, read := chunks[chunk]
+		if !read {
+			if data, _, err = c.bitmapChunk(key, chunk); err != nil {
+				return
+			}
+
+			chunks[chunk] = data
+		}
+
+		index := offset / 8 % bitmapChunkBytes
+		if index >= int64(len(data)) || data[index]&byte(0x80>>uint(offset%8)) == 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// bloomFilter reads the size of the Bloom filter at key.
+func (c Client) bloomFilter(key string) (bf bloomFilter, found bool, err error) {
+	c.consistentReads = true
+
+	val, err := c.HGET(metadataKey(key), bloomField)
+	if err != nil || val.Empty() {
+		return
+	}
+
+	_, err = fmt.Sscanf(val.String(), "%d %d", &bf.bits, &bf.hashes)
+
+	return bf, err == nil, err
+}
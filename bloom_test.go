@@ -0,0 +1,78 @@
+package redimo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomSizing(t *testing.T) {
+	bf := newBloomFilter(0.01, 1000000)
+	assert.Equal(t, int64(9585059), bf.bits)
+	assert.Equal(t, int64(7), bf.hashes)
+
+	offsets := bf.offsets("https://example.com")
+	assert.Len(t, offsets, 7)
+	assert.Equal(t, offsets, bf.offsets("https://example.com"))
+
+	for _, offset := range offsets {
+		assert.True(t, offset >= 0 && offset < bf.bits)
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	c := newClient(t)
+
+	ok, err := c.BFRESERVE("urls", 0.01, 1000)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.BFRESERVE("urls", 0.01, 1000)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = c.BFRESERVE("other", 1.5, 1000)
+	assert.Equal(t, ErrInvalidFilter, err)
+
+	for i := 0; i < 100; i++ {
+		added, err := c.BFADD("urls", fmt.Sprintf("https://example.com/%d", i))
+		assert.NoError(t, err)
+		assert.True(t, added)
+	}
+
+	added, err := c.BFADD("urls", "https://example.com/42")
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	for i := 0; i < 100; i++ {
+		exists, err := c.BFEXISTS("urls", fmt.Sprintf("https://example.com/%d", i))
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+
+	falsePositives := 0
+
+	for i := 100; i < 200; i++ {
+		exists, err := c.BFEXISTS("urls", fmt.Sprintf("https://example.com/%d", i))
+		assert.NoError(t, err)
+
+		if exists {
+			falsePositives++
+		}
+	}
+
+	assert.True(t, falsePositives < 5)
+
+	added, err = c.BFADD("defaults", "member")
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	exists, err := c.BFEXISTS("defaults", "member")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = c.BFEXISTS("nothing", "member")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
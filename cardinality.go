@@ -0,0 +1,78 @@
+package redimo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cardinalityField is the field of the _redimo/<key> metadata hash that holds the maintained member or field count,
+// or list length, of key when counted cardinality is enabled.
+const cardinalityField = "cardinality"
+
+func metadataKey(key string) string {
+	return fmt.Sprintf("_redimo/%v", key)
+}
+
+// counted reports whether writes to key maintain its count. Metadata hashes are never counted themselves, since
+// counting them would write to yet another metadata hash.
+func (c Client) counted(key string) bool {
+	return c.countedCardinality && !strings.HasPrefix(key, metadataKey(""))
+}
+
+// adjustCardinality adds delta to the maintained member count of key. It does nothing unless counted
+// cardinality is enabled.
+func (c Client) adjustCardinality(key string, delta int) error {
+	if !c.counted(key) || delta == 0 {
+		return nil
+	}
+
+	_, err := c.HINCRBY(metadataKey(key), cardinalityField, int64(delta))
+
+	return err
+}
+
+// setCardinality overwrites the maintained member count of key. It does nothing unless counted cardinality
+// is enabled.
+func (c Client) setCardinality(key string, count int32) error {
+	if !c.counted(key) {
+		return nil
+	}
+
+	_, err := c.HSET(metadataKey(key), map[string]Value{cardinalityField: IntValue{int64(count)}})
+
+	return err
+}
+
+// cardinality returns the number of members or fields at key, reading the maintained count if key is counted and
+// counting the members with a query otherwise, like for the shards of a sorted set, which are never counted.
+func (c Client) cardinality(key string) (count int32, err error) {
+	if !c.counted(key) {
+		return c.countItems(key, false)
+	}
+
+	val, err := c.HGET(metadataKey(key), cardinalityField)
+	if err == nil {
+		count = int32(val.Int())
+	}
+
+	return
+}
+
+// RepairCardinality counts the members of the set or sorted set, the fields of the hash or the elements of the list at
+// key with a query and overwrites the maintained count with the result, which is also returned. Use it to backfill the
+// counts of data written before CountedCardinality was enabled, or written by clients that didn't have it enabled, and
+// to drop hash fields that have expired from the count. Writes to key that happen while the members are being counted
+// may be lost from the count, so repair keys while they are not being modified.
+//
+// Cost is O(N) / 1 RCU per 4 KB of members, like HLEN.
+func (c Client) RepairCardinality(key string) (count int32, err error) {
+	count, err = c.countItems(key, false)
+	if err != nil {
+		return
+	}
+
+	c.countedCardinality = true
+	err = c.setCardinality(key, count)
+
+	return
+}
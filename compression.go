@@ -0,0 +1,190 @@
+package redimo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// codecKey is the attribute that marks a compressed value with the name of its codec and the type the value had
+// before it was compressed, like "gzip/S".
+const codecKey = "codec"
+
+// ErrUnknownCodec is returned when reading a value that was compressed with a codec the client doesn't know. Values
+// compressed with GzipCodec can always be read, other codecs have to be set with Compression.
+var ErrUnknownCodec = errors.New("value was compressed with an unknown codec")
+
+// Codec compresses the string and hash values written by a client, see Compression. Implementations must be safe
+// for concurrent use.
+type Codec interface {
+	// Name identifies the codec in the items it compressed, so it must not change once values have been written.
+	Name() string
+	Encode(data []byte) ([]byte, error)
+	Decode(data []byte) ([]byte, error)
+}
+
+// GzipCodec compresses values with gzip from the standard library.
+var GzipCodec Codec = gzipCodec{}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string {
+	return "gzip"
+}
+
+func (gzipCodec) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decode(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// encodeValue returns the attribute value to store for value, compressed with the codec of the client if it is a
+// string or bytes value of at least the threshold size that gets smaller by compressing it. If the value was
+// compressed, marker holds the value of the codec attribute, otherwise it is nil.
+func (c Client) encodeValue(value Value) (av types.AttributeValue, marker types.AttributeValue, err error) {
+	av = value.ToAV()
+	if c.codec == nil {
+		return
+	}
+
+	var (
+		data []byte
+		kind string
+	)
+
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		data, kind = []byte(v.Value), "S"
+	case *types.AttributeValueMemberB:
+		data, kind = v.Value, "B"
+	default:
+		return
+	}
+
+	if len(data) < c.compressThreshold {
+		return
+	}
+
+	compressed, err := c.codec.Encode(data)
+	if err != nil || len(compressed) >= len(data) {
+		return av, nil, err
+	}
+
+	return &types.AttributeValueMemberB{Value: compressed}, StringValue{c.codec.Name() + "/" + kind}.ToAV(), nil
+}
+
+// decodeValue returns the value stored in item, decompressing it if it is marked as compressed.
+func (c Client) decodeValue(item map[string]types.AttributeValue) (val ReturnValue, err error) {
+	marker, ok := item[codecKey].(*types.AttributeValueMemberS)
+	if !ok {
+		return ReturnValue{item[vk]}, nil
+	}
+
+	parts := strings.SplitN(marker.Value, "/", 2)
+
+	var codec Codec
+
+	switch {
+	case c.codec != nil && c.codec.Name() == parts[0]:
+		codec = c.codec
+	case parts[0] == GzipCodec.Name():
+		codec = GzipCodec
+	default:
+		return val, ErrUnknownCodec
+	}
+
+	compressed, ok := item[vk].(*types.AttributeValueMemberB)
+	if !ok {
+		return val, ErrUnknownCodec
+	}
+
+	data, err := codec.Decode(compressed.Value)
+	if err != nil {
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "B" {
+		return ReturnValue{BytesValue{data}.ToAV()}, nil
+	}
+
+	return ReturnValue{StringValue{string(data)}.ToAV()}, nil
+}
+
+// updateValue makes an update set the value attribute to value, compressing it if the client has a codec. The codec
+// marker is set along with a compressed value and removed otherwise, so overwriting a compressed value with one
+// that isn't compressed leaves no stale marker behind.
+func (c Client) updateValue(b *expressionBuilder, value Value) error {
+	av, marker, err := c.encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	b.updateSetAV(vk, av)
+
+	if marker != nil {
+		b.updateSetAV(codecKey, marker)
+	} else {
+		b.clauses["REMOVE"] = append(b.clauses["REMOVE"], "#"+codecKey)
+		b.keys[codecKey] = struct{}{}
+	}
+
+	return nil
+}
+
+// valueItem returns the item to put for a value at the given key and sort key, compressing it like updateValue.
+func (c Client) valueItem(key string, sk string, value Value) (item map[string]types.AttributeValue, err error) {
+	av, marker, err := c.encodeValue(value)
+	if err != nil {
+		return
+	}
+
+	item = keyDef{pk: key, sk: sk}.toAV(c)
+	item[vk] = av
+
+	if marker != nil {
+		item[codecKey] = marker
+	}
+
+	return
+}
+
+// valueProjection returns a projection of the given attributes plus the value and its codec marker, along with the
+// attribute names it refers to.
+func valueProjection(attributes ...string) (projection *string, names map[string]string) {
+	names = make(map[string]string)
+	refs := make([]string, 0, len(attributes)+2)
+
+	for _, attribute := range append(attributes, vk, codecKey) {
+		names["#"+attribute] = attribute
+		refs = append(refs, "#"+attribute)
+	}
+
+	projection = aws.String(strings.Join(refs, ", "))
+
+	return
+}
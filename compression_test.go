@@ -0,0 +1,118 @@
+package redimo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// suffixCodec "compresses" values by dropping a known suffix.
+type suffixCodec struct{}
+
+func (suffixCodec) Name() string {
+	return "suffix"
+}
+
+func (suffixCodec) Encode(data []byte) ([]byte, error) {
+	return bytes.TrimSuffix(data, []byte("!!!!")), nil
+}
+
+func (suffixCodec) Decode(data []byte) ([]byte, error) {
+	return append(data, "!!!!"...), nil
+}
+
+func TestCodecValues(t *testing.T) {
+	c := Client{}.Compression(GzipCodec, 100)
+	long := strings.Repeat("compressible ", 100)
+
+	av, marker, err := c.encodeValue(StringValue{long})
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip/S", ReturnValue{marker}.String())
+	assert.True(t, len(ReturnValue{av}.Bytes()) < len(long))
+
+	val, err := c.decodeValue(map[string]types.AttributeValue{vk: av, codecKey: marker})
+	assert.NoError(t, err)
+	assert.Equal(t, long, val.String())
+
+	av, marker, err = c.encodeValue(BytesValue{[]byte(long)})
+	assert.NoError(t, err)
+
+	val, err = Client{}.decodeValue(map[string]types.AttributeValue{vk: av, codecKey: marker})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(long), val.Bytes())
+
+	av, marker, err = c.encodeValue(StringValue{"short"})
+	assert.NoError(t, err)
+	assert.Nil(t, marker)
+	assert.Equal(t, "short", ReturnValue{av}.String())
+
+	_, marker, err = c.encodeValue(IntValue{42})
+	assert.NoError(t, err)
+	assert.Nil(t, marker)
+
+	custom := Client{}.Compression(suffixCodec{}, 1)
+	av, marker, err = custom.encodeValue(StringValue{"abc!!!!"})
+	assert.NoError(t, err)
+	assert.Equal(t, "suffix/S", ReturnValue{marker}.String())
+
+	val, err = custom.decodeValue(map[string]types.AttributeValue{vk: av, codecKey: marker})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc!!!!", val.String())
+
+	_, err = c.decodeValue(map[string]types.AttributeValue{vk: av, codecKey: marker})
+	assert.Equal(t, ErrUnknownCodec, err)
+}
+
+func TestCompression(t *testing.T) {
+	plain := newClient(t)
+	c := plain.Compression(GzipCodec, 100)
+	long := strings.Repeat("compressible ", 100)
+
+	_, err := c.SET("k", long)
+	assert.NoError(t, err)
+
+	val, err := plain.GET("k")
+	assert.NoError(t, err)
+	assert.Equal(t, long, val.String())
+
+	usage, _, err := c.MEMORYUSAGE("k")
+	assert.NoError(t, err)
+	assert.True(t, usage < int64(len(long)))
+
+	length, err := c.APPEND("k", "!")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(long)+1), length)
+
+	_, err = plain.SET("k", "short")
+	assert.NoError(t, err)
+
+	val, err = c.GET("k")
+	assert.NoError(t, err)
+	assert.Equal(t, "short", val.String())
+
+	_, err = c.HSET("h", map[string]Value{"f1": StringValue{long}, "f2": StringValue{"v2"}})
+	assert.NoError(t, err)
+
+	val, err = plain.HGET("h", "f1")
+	assert.NoError(t, err)
+	assert.Equal(t, long, val.String())
+
+	values, err := plain.HMGET("h", "f1", "f2")
+	assert.NoError(t, err)
+	assert.Equal(t, long, values["f1"].String())
+	assert.Equal(t, "v2", values["f2"].String())
+
+	values, err = c.HGETALL("h")
+	assert.NoError(t, err)
+	assert.Equal(t, long, values["f1"].String())
+
+	err = c.MSET(map[string]string{"m1": long})
+	assert.NoError(t, err)
+
+	values, err = plain.MGET("m1")
+	assert.NoError(t, err)
+	assert.Equal(t, long, values["m1"].String())
+}
@@ -0,0 +1,317 @@
+package redimo
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math/rand"
+)
+
+// cuckooField is the field of the _redimo/<key> metadata hash that holds the number of buckets of the cuckoo filter
+// at key.
+const cuckooField = "cuckoo"
+
+// DefaultCuckooCapacity is the capacity of cuckoo filters that are created by CFADD without CFRESERVE, the same as
+// in Redis.
+const DefaultCuckooCapacity = 1024
+
+// Each bucket of a cuckoo filter holds four 16 bit fingerprints, so 128 buckets fit into a bitmap chunk.
+const (
+	cuckooSlots       = 4
+	cuckooBucketBytes = cuckooSlots * 2
+	cuckooMaxKicks    = 500
+)
+
+// ErrFilterFull is returned by CFADD when there's no room left for the item in the cuckoo filter.
+var ErrFilterFull = errors.New("filter is full")
+
+type cuckooFilter struct {
+	buckets uint64
+}
+
+// newCuckooFilter sizes a cuckoo filter for the given capacity. The number of buckets is a power of two, so that
+// the alternate bucket of a fingerprint can be found with an XOR.
+func newCuckooFilter(capacity int64) cuckooFilter {
+	buckets := uint64(1)
+	for buckets*cuckooSlots < uint64(capacity) {
+		buckets <<= 1
+	}
+
+	return cuckooFilter{buckets: buckets}
+}
+
+// locate returns the fingerprint of item and its two buckets.
+func (cf cuckooFilter) locate(item string) (fingerprint uint16, first uint64, second uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(item))
+	sum := h.Sum64()
+
+	fingerprint = uint16(sum >> 48)
+	if fingerprint == 0 {
+		fingerprint = 1
+	}
+
+	first = sum & (cf.buckets - 1)
+
+	return fingerprint, first, cf.alternate(first, fingerprint)
+}
+
+// alternate returns the other bucket a fingerprint in bucket can move to.
+func (cf cuckooFilter) alternate(bucket uint64, fingerprint uint16) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte{byte(fingerprint >> 8), byte(fingerprint)})
+
+	return (bucket ^ h.Sum64()) & (cf.buckets - 1)
+}
+
+// CFRESERVE creates an empty cuckoo filter at key that holds capacity items. Returns false if there already is a
+// filter at key.
+//
+// Cuckoo filters answer the same question as Bloom filters, but also support deleting items, see CFDEL. Each item is
+// stored as a 16 bit fingerprint, which makes false positives about one in 8000. Like Bloom filters, the
+// fingerprints are stored like a bitmap at key, in chunks of 1 KB each in their own item (see SETBIT), and the size
+// is stored in the _redimo/<key> metadata hash.
+//
+// Works similar to https://redis.io/commands/cf.reserve
+func (c Client) CFRESERVE(key string, capacity int64) (ok bool, err error) {
+	if capacity <= 0 {
+		return false, ErrInvalidFilter
+	}
+
+	return c.HSETNX(metadataKey(key), cuckooField, IntValue{int64(newCuckooFilter(capacity).buckets)})
+}
+
+// CFADD adds item to the cuckoo filter at key, creating the filter with DefaultCuckooCapacity if it doesn't exist.
+// Items can be added more than once, and then have to be deleted as many times. Use CFADDNX to only add items that
+// don't exist.
+//
+// If both buckets of the item are full, fingerprints are moved to their alternate buckets to make room, one
+// conditional write at a time, and ErrFilterFull is returned if no room could be made. Concurrent adds are safe, but
+// an item that is being moved can be missing from CFEXISTS for a moment.
+//
+// Cost is O(1) / 1 RCU + 1 WCU usually, and more when the filter is close to full.
+//
+// Works similar to https://redis.io/commands/cf.add
+func (c Client) CFADD(key string, item string) (err error) {
+	cf, err := c.cuckooFilter(key, true)
+	if err != nil {
+		return
+	}
+
+	fingerprint, first, second := cf.locate(item)
+
+	for _, bucket := range []uint64{first, second} {
+		inserted, err := c.cuckooInsert(key, bucket, fingerprint)
+		if err != nil || inserted {
+			return err
+		}
+	}
+
+	return c.cuckooKick(key, cf, fingerprint, []uint64{first, second}[rand.Intn(2)])
+}
+
+// CFADDNX adds item to the cuckoo filter at key like CFADD, unless it may exist already. Returns whether the item
+// was added.
+//
+// Works similar to https://redis.io/commands/cf.addnx
+func (c Client) CFADDNX(key string, item string) (added bool, err error) {
+	exists, err := c.CFEXISTS(key, item)
+	if err != nil || exists {
+		return
+	}
+
+	return true, c.CFADD(key, item)
+}
+
+// CFEXISTS checks whether item may have been added to the cuckoo filter at key. False means it definitely isn't in
+// the filter, true means it probably is. Returns false if there is no filter at key.
+//
+// Cost is O(1) / 1 or 2 RCU.
+//
+// Works similar to https://redis.io/commands/cf.exists
+func (c Client) CFEXISTS(key string, item string) (exists bool, err error) {
+	cf, err := c.cuckooFilter(key, false)
+	if err != nil || cf.buckets == 0 {
+		return
+	}
+
+	fingerprint, first, second := cf.locate(item)
+
+	for _, bucket := range []uint64{first, second} {
+		slots, err := c.cuckooBucket(key, bucket)
+		if err != nil {
+			return false, err
+		}
+
+		for _, slot := range slots {
+			if slot == fingerprint {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// CFDEL deletes one copy of item from the cuckoo filter at key, and returns false if it wasn't found. Only delete
+// items that were added: deleting an item that wasn't added can delete another item with the same fingerprint.
+//
+// Cost is O(1) / up to 2 RCU + 1 WCU.
+//
+// Works similar to https://redis.io/commands/cf.del
+func (c Client) CFDEL(key string, item string) (deleted bool, err error) {
+	cf, err := c.cuckooFilter(key, false)
+	if err != nil || cf.buckets == 0 {
+		return
+	}
+
+	fingerprint, first, second := cf.locate(item)
+
+	for _, bucket := range []uint64{first, second} {
+		err = c.updateCuckooBucket(key, bucket, func(slots []uint16) bool {
+			deleted = replaceSlot(slots, fingerprint, 0)
+
+			return deleted
+		})
+		if err != nil || deleted {
+			return
+		}
+	}
+
+	return
+}
+
+// cuckooInsert puts fingerprint into an empty slot of bucket, and returns false if the bucket is full.
+func (c Client) cuckooInsert(key string, bucket uint64, fingerprint uint16) (inserted bool, err error) {
+	err = c.updateCuckooBucket(key, bucket, func(slots []uint16) bool {
+		inserted = replaceSlot(slots, 0, fingerprint)
+
+		return inserted
+	})
+
+	return
+}
+
+// cuckooKick makes room for fingerprint by swapping it with a random fingerprint of bucket, and moving that one to
+// its alternate bucket in turn. If no room is found, the swaps are undone so that no fingerprint is lost.
+func (c Client) cuckooKick(key string, cf cuckooFilter, fingerprint uint16, bucket uint64) error {
+	type swap struct {
+		bucket  uint64
+		evicted uint16
+		placed  uint16
+	}
+
+	var swaps []swap
+
+	for kicks := 0; kicks < cuckooMaxKicks; kicks++ {
+		var (
+			inserted bool
+			evicted  uint16
+		)
+
+		err := c.updateCuckooBucket(key, bucket, func(slots []uint16) bool {
+			if inserted = replaceSlot(slots, 0, fingerprint); inserted {
+				return true
+			}
+
+			slot := rand.Intn(cuckooSlots)
+			evicted, slots[slot] = slots[slot], fingerprint
+
+			return true
+		})
+		if err != nil || inserted {
+			return err
+		}
+
+		swaps = append(swaps, swap{bucket: bucket, evicted: evicted, placed: fingerprint})
+		fingerprint, bucket = evicted, cf.alternate(bucket, evicted)
+	}
+
+	for i := len(swaps) - 1; i >= 0; i-- {
+		s := swaps[i]
+
+		err := c.updateCuckooBucket(key, s.bucket, func(slots []uint16) bool {
+			return replaceSlot(slots, s.placed, s.evicted)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return ErrFilterFull
+}
+
+// replaceSlot replaces the first slot holding old with replacement, and returns false if there is none.
+func replaceSlot(slots []uint16, old uint16, replacement uint16) bool {
+	for i := range slots {
+		if slots[i] == old {
+			slots[i] = replacement
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// cuckooBucket reads the fingerprints in bucket, where 0 marks an empty slot.
+func (c Client) cuckooBucket(key string, bucket uint64) (slots []uint16, err error) {
+	offset := int64(bucket) * cuckooBucketBytes
+
+	data, _, err := c.bitmapChunk(key, offset/bitmapChunkBytes)
+
+	return decodeCuckooBucket(data, offset%bitmapChunkBytes), err
+}
+
+// updateCuckooBucket passes the fingerprints in bucket to fn, and writes them back if fn returns true. It is
+// retried like updateBitmapChunk, so fn may be called more than once.
+func (c Client) updateCuckooBucket(key string, bucket uint64, fn func(slots []uint16) bool) error {
+	offset := int64(bucket) * cuckooBucketBytes
+	index := offset % bitmapChunkBytes
+
+	return c.updateBitmapChunk(key, offset/bitmapChunkBytes, func(data []byte) []byte {
+		slots := decodeCuckooBucket(data, index)
+		if !fn(slots) {
+			return data
+		}
+
+		if int64(len(data)) < index+cuckooBucketBytes {
+			data = append(data, make([]byte, index+cuckooBucketBytes-int64(len(data)))...)
+		}
+
+		for i, slot := range slots {
+			binary.BigEndian.PutUint16(data[index+int64(2*i):], slot)
+		}
+
+		return data
+	})
+}
+
+func decodeCuckooBucket(data []byte, index int64) []uint16 {
+	slots := make([]uint16, cuckooSlots)
+
+	for i := range slots {
+		if at := index + int64(2*i); at+2 <= int64(len(data)) {
+			slots[i] = binary.BigEndian.Uint16(data[at:])
+		}
+	}
+
+	return slots
+}
+
+// cuckooFilter reads the size of the cuckoo filter at key, creating it with DefaultCuckooCapacity if create is set.
+// The size is 0 if there is no filter.
+func (c Client) cuckooFilter(key string, create bool) (cf cuckooFilter, err error) {
+	c.consistentReads = true
+
+	val, err := c.HGET(metadataKey(key), cuckooField)
+	if err != nil || !val.Empty() || !create {
+		return cuckooFilter{buckets: uint64(val.Int())}, err
+	}
+
+	if _, err = c.CFRESERVE(key, DefaultCuckooCapacity); err != nil {
+		return
+	}
+
+	return c.cuckooFilter(key, false)
+}
@@ -0,0 +1,100 @@
+package redimo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCuckooLocate(t *testing.T) {
+	cf := newCuckooFilter(1000)
+	assert.Equal(t, uint64(256), cf.buckets)
+
+	for i := 0; i < 100; i++ {
+		fingerprint, first, second := cf.locate(fmt.Sprintf("item%d", i))
+		assert.NotZero(t, fingerprint)
+		assert.True(t, first < cf.buckets && second < cf.buckets)
+		assert.Equal(t, first, cf.alternate(second, fingerprint))
+	}
+
+	slots := []uint16{1, 0, 2, 0}
+	assert.True(t, replaceSlot(slots, 0, 3))
+	assert.Equal(t, []uint16{1, 3, 2, 0}, slots)
+	assert.False(t, replaceSlot(slots, 4, 5))
+}
+
+func TestCuckooFilter(t *testing.T) {
+	c := newClient(t)
+
+	ok, err := c.CFRESERVE("seen", 1000)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.CFRESERVE("seen", 1000)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, c.CFADD("seen", fmt.Sprintf("https://example.com/%d", i)))
+	}
+
+	exists, err := c.CFEXISTS("seen", "https://example.com/7")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = c.CFEXISTS("seen", "https://example.com/700")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	deleted, err := c.CFDEL("seen", "https://example.com/7")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	exists, err = c.CFEXISTS("seen", "https://example.com/7")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	deleted, err = c.CFDEL("seen", "https://example.com/7")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	added, err := c.CFADDNX("seen", "https://example.com/8")
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	added, err = c.CFADDNX("defaults", "member")
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	exists, err = c.CFEXISTS("nothing", "member")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCuckooFilterFull(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.CFRESERVE("tiny", 8)
+	assert.NoError(t, err)
+
+	added := 0
+
+	for i := 0; i < 20; i++ {
+		err := c.CFADD("tiny", fmt.Sprintf("item%d", i))
+		if err == ErrFilterFull {
+			break
+		}
+
+		assert.NoError(t, err)
+		added++
+	}
+
+	assert.True(t, added >= 4 && added <= 8)
+
+	for i := 0; i < added; i++ {
+		exists, err := c.CFEXISTS("tiny", fmt.Sprintf("item%d", i))
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
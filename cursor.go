@@ -0,0 +1,129 @@
+package redimo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ScanStart is the cursor used to begin a scan with operations like ZSCAN. When a scan is complete, the
+// returned cursor is ScanStart again, just like the "0" cursor in Redis.
+const ScanStart = "0"
+
+// ErrInvalidCursor is returned when a cursor passed to a scan operation was not generated by Redimo, or was generated
+// by a scan of another key.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type cursorAttribute struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+	B []byte  `json:"B,omitempty"`
+}
+
+// encodeCursor turns a DynamoDB LastEvaluatedKey into an opaque string that can be stored and passed back
+// later, even from another process. An empty key (no more results) is encoded as ScanStart.
+func encodeCursor(lastEvaluatedKey map[string]types.AttributeValue) string {
+	if len(lastEvaluatedKey) == 0 {
+		return ScanStart
+	}
+
+	attributes := make(map[string]cursorAttribute)
+
+	for name, av := range lastEvaluatedKey {
+		switch av := av.(type) {
+		case *types.AttributeValueMemberS:
+			attributes[name] = cursorAttribute{S: &av.Value}
+		case *types.AttributeValueMemberN:
+			attributes[name] = cursorAttribute{N: &av.Value}
+		case *types.AttributeValueMemberB:
+			attributes[name] = cursorAttribute{B: av.Value}
+		}
+	}
+
+	encoded, _ := json.Marshal(attributes)
+
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// decodeCursor reverses encodeCursor. ScanStart and the empty string both decode to a nil key, which starts
+// a query from the beginning.
+func decodeCursor(cursor string) (exclusiveStartKey map[string]types.AttributeValue, err error) {
+	if cursor == ScanStart || cursor == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var attributes map[string]cursorAttribute
+	if err := json.Unmarshal(decoded, &attributes); err != nil || len(attributes) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	exclusiveStartKey = make(map[string]types.AttributeValue)
+
+	for name, attribute := range attributes {
+		switch {
+		case attribute.S != nil:
+			exclusiveStartKey[name] = &types.AttributeValueMemberS{Value: *attribute.S}
+		case attribute.N != nil:
+			exclusiveStartKey[name] = &types.AttributeValueMemberN{Value: *attribute.N}
+		case attribute.B != nil:
+			exclusiveStartKey[name] = &types.AttributeValueMemberB{Value: attribute.B}
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return exclusiveStartKey, nil
+}
+
+// decodeKeyCursor works like decodeCursor for scans over the items of a single key, and returns ErrInvalidCursor if
+// the cursor was returned by a scan of another key.
+func (c Client) decodeKeyCursor(key string, cursor string) (exclusiveStartKey map[string]types.AttributeValue, err error) {
+	exclusiveStartKey, err = decodeCursor(cursor)
+	if err != nil || exclusiveStartKey == nil {
+		return
+	}
+
+	if pk, ok := exclusiveStartKey[c.partitionKey].(*types.AttributeValueMemberS); !ok || pk.Value != key {
+		return nil, ErrInvalidCursor
+	}
+
+	return
+}
+
+// encodeCellCursor extends encodeCursor for scans that query a list of cells one after another, like
+// GEOSEARCHSCAN, by adding the index of the cell the scan stopped in.
+func encodeCellCursor(cell int, lastEvaluatedKey map[string]types.AttributeValue) string {
+	return fmt.Sprintf("%d.%v", cell, encodeCursor(lastEvaluatedKey))
+}
+
+// decodeCellCursor reverses encodeCellCursor. ScanStart and the empty string both decode to the start of the
+// first cell.
+func decodeCellCursor(cursor string) (cell int, exclusiveStartKey map[string]types.AttributeValue, err error) {
+	if cursor == ScanStart || cursor == "" {
+		return 0, nil, nil
+	}
+
+	parts := strings.SplitN(cursor, ".", 2)
+	if len(parts) != 2 {
+		return 0, nil, ErrInvalidCursor
+	}
+
+	cell, err = strconv.Atoi(parts[0])
+	if err != nil || cell < 0 {
+		return 0, nil, ErrInvalidCursor
+	}
+
+	exclusiveStartKey, err = decodeCursor(parts[1])
+
+	return cell, exclusiveStartKey, err
+}
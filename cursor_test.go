@@ -0,0 +1,67 @@
+package redimo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursors(t *testing.T) {
+	lastEvaluatedKey := map[string]types.AttributeValue{
+		"pk":  &types.AttributeValueMemberS{Value: "key"},
+		"sk":  &types.AttributeValueMemberS{Value: "member/with?odd=chars"},
+		"skN": &types.AttributeValueMemberN{Value: "3.14"},
+		"b":   &types.AttributeValueMemberB{Value: []byte{0, 1, 2}},
+	}
+
+	cursor := encodeCursor(lastEvaluatedKey)
+	assert.NotEqual(t, ScanStart, cursor)
+
+	decoded, err := decodeCursor(cursor)
+	assert.NoError(t, err)
+	assert.Equal(t, lastEvaluatedKey, decoded)
+
+	assert.Equal(t, ScanStart, encodeCursor(nil))
+
+	decoded, err = decodeCursor(ScanStart)
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	decoded, err = decodeCursor("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = decodeCursor("not a cursor")
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	_, err = decodeCursor("e30")
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestCellCursors(t *testing.T) {
+	lastEvaluatedKey := map[string]types.AttributeValue{
+		"pk":  &types.AttributeValueMemberS{Value: "key"},
+		"skN": &types.AttributeValueMemberN{Value: "1376383545825912065"},
+	}
+
+	cell, decoded, err := decodeCellCursor(encodeCellCursor(3, lastEvaluatedKey))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cell)
+	assert.Equal(t, lastEvaluatedKey, decoded)
+
+	cell, decoded, err = decodeCellCursor(encodeCellCursor(2, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cell)
+	assert.Nil(t, decoded)
+
+	cell, decoded, err = decodeCellCursor(ScanStart)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cell)
+	assert.Nil(t, decoded)
+
+	for _, invalid := range []string{"x.0", "-1.0", "1", "1.not a cursor"} {
+		_, _, err = decodeCellCursor(invalid)
+		assert.Equal(t, ErrInvalidCursor, err)
+	}
+}
@@ -2,8 +2,11 @@ package redimo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -15,6 +18,17 @@ import (
 
 const earthRadiusMeters = 6372797.560856
 
+// ErrMemberNotFound is returned by the searches around a member, like GEORADIUSBYMEMBER, when the member doesn't
+// exist.
+var ErrMemberNotFound = errors.New("member not found")
+
+// Locations are indexed by the ID of their s2 cell in the score attribute, which only keeps the center of the cell.
+// The exact coordinates are stored in these attributes of the member as well.
+const (
+	geoLatKey = "lat"
+	geoLonKey = "lon"
+)
+
 type GLocation struct {
 	Lat float64
 	Lon float64
@@ -28,10 +42,25 @@ func (l GLocation) Geohash() string {
 	return geohash.Encode(l.Lat, l.Lon)
 }
 
+// attributes are the exact coordinates of the location, which are stored next to its cell ID.
+func (l GLocation) attributes() map[string]Value {
+	return map[string]Value{geoLatKey: FloatValue{l.Lat}, geoLonKey: FloatValue{l.Lon}}
+}
+
 func (l GLocation) toAV() types.AttributeValue {
 	return &types.AttributeValueMemberN{Value: l.s2CellID()}
 }
 
+// gCell stores a location in the score attribute as the ID of the s2 cell that contains it, so that locations can
+// be searched with range queries on the score index.
+type gCell struct {
+	location GLocation
+}
+
+func (gc gCell) ToAV() types.AttributeValue {
+	return gc.location.toAV()
+}
+
 func (l *GLocation) setCellIDString(cellIDStr string) {
 	cellID, _ := strconv.ParseUint(cellIDStr, 10, 64)
 	s2Cell := s2.CellID(cellID)
@@ -44,6 +73,20 @@ func (l GLocation) DistanceTo(other GLocation, unit GUnit) (distance float64) {
 	return Meters.To(unit, l.s2LatLng().Distance(other.s2LatLng()).Radians()*earthRadiusMeters)
 }
 
+// BearingTo returns the initial bearing of the shortest path from this location to the other one, in degrees
+// clockwise from north, between 0 and 360. Following the path, the bearing changes on the way unless the path
+// runs along a meridian or the equator.
+func (l GLocation) BearingTo(other GLocation) (bearing float64) {
+	from, to := l.s2LatLng(), other.s2LatLng()
+	deltaLng := (to.Lng - from.Lng).Radians()
+
+	y := math.Sin(deltaLng) * math.Cos(to.Lat.Radians())
+	x := math.Cos(from.Lat.Radians())*math.Sin(to.Lat.Radians()) -
+		math.Sin(from.Lat.Radians())*math.Cos(to.Lat.Radians())*math.Cos(deltaLng)
+
+	return math.Mod(s1.Angle(math.Atan2(y, x)).Degrees()+360, 360)
+}
+
 func (l GLocation) s2LatLng() s2.LatLng {
 	return s2.LatLngFromDegrees(l.Lat, l.Lon)
 }
@@ -60,12 +103,56 @@ func (from GUnit) To(to GUnit, d float64) float64 {
 }
 
 const (
-	Meters     GUnit = 1.0
-	Kilometers GUnit = 1000.0
-	Miles      GUnit = 1609.34
-	Feet       GUnit = 0.3048
+	Meters        GUnit = 1.0
+	Kilometers    GUnit = 1000.0
+	Miles         GUnit = 1609.34
+	Feet          GUnit = 0.3048
+	NauticalMiles GUnit = 1852.0
+	Yards         GUnit = 0.9144
+)
+
+// ErrInvalidUnit is returned by RegisterGUnit when the unit isn't a positive number of meters.
+var ErrInvalidUnit = errors.New("unit must be a positive number of meters")
+
+var (
+	gUnitsMutex sync.RWMutex
+	gUnits      = map[string]GUnit{
+		"m":   Meters,
+		"km":  Kilometers,
+		"mi":  Miles,
+		"ft":  Feet,
+		"nmi": NauticalMiles,
+		"yd":  Yards,
+	}
 )
 
+// RegisterGUnit makes a unit available under the given name for GUnitByName, replacing any unit registered under
+// that name before. A GUnit is just the number of meters in one unit, so GUnit(metersPerUnit) can be passed to
+// the geo commands directly as well – the registry is for units that are picked by name, like in a request
+// parameter. The Redis names m, km, mi and ft, along with nmi and yd, are registered from the start.
+func RegisterGUnit(name string, metersPerUnit float64) (unit GUnit, err error) {
+	if !(metersPerUnit > 0) || math.IsInf(metersPerUnit, +1) {
+		return unit, ErrInvalidUnit
+	}
+
+	gUnitsMutex.Lock()
+	defer gUnitsMutex.Unlock()
+
+	gUnits[name] = GUnit(metersPerUnit)
+
+	return GUnit(metersPerUnit), nil
+}
+
+// GUnitByName returns the unit registered under the given name. If there is no such unit, found is false.
+func GUnitByName(name string) (unit GUnit, found bool) {
+	gUnitsMutex.RLock()
+	defer gUnitsMutex.RUnlock()
+
+	unit, found = gUnits[name]
+
+	return
+}
+
 // GEOADD adds the given members into the key. Members are represented by a map of name to GLocation, which is just a wrapper
 // for latitude and longitude. If a member already exists, its location will be updated. The method only returns the members
 // that were added as part of the operation and did not already exist.
@@ -76,25 +163,19 @@ const (
 func (c Client) GEOADD(key string, members map[string]GLocation) (newlyAddedMembers map[string]GLocation, err error) {
 	newlyAddedMembers = make(map[string]GLocation)
 
-	for member, location := range members {
-		builder := newExpresionBuilder()
-		builder.updateSetAV(c.sortKeyNum, location.toAV())
-
-		resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-			ConditionExpression:       builder.conditionExpression(),
-			ExpressionAttributeNames:  builder.expressionAttributeNames(),
-			ExpressionAttributeValues: builder.expressionAttributeValues(),
-			Key:                       keyDef{pk: key, sk: member}.toAV(c),
-			ReturnValues:              types.ReturnValueAllOld,
-			TableName:                 aws.String(c.tableName),
-			UpdateExpression:          builder.updateExpression(),
-		})
+	defer func() {
+		if cErr := c.adjustCardinality(key, len(newlyAddedMembers)); err == nil {
+			err = cErr
+		}
+	}()
 
+	for member, location := range members {
+		status, err := c.zAddMember(key, member, gCell{location}, location.attributes(), Flags{})
 		if err != nil {
 			return newlyAddedMembers, err
 		}
 
-		if len(resp.Attributes) < 1 {
+		if status == MemberAdded {
 			newlyAddedMembers[member] = location
 		}
 	}
@@ -102,6 +183,70 @@ func (c Client) GEOADD(key string, members map[string]GLocation) (newlyAddedMemb
 	return newlyAddedMembers, nil
 }
 
+// GEOADDCOUNTS works like GEOADD, but takes the same flags as ZADD and returns the number of members that were added
+// and the number of existing members whose location was overwritten. With IfNotExists only new members are added,
+// and with IfAlreadyExists only existing members are moved. Adding both counts gives the result of the CH option in
+// Redis.
+//
+// Every member is a single update that returns the previous item, so the counts don't cost any extra reads.
+//
+// Works similar to https://redis.io/commands/geoadd
+func (c Client) GEOADDCOUNTS(key string, members map[string]GLocation, flags Flags) (added int32, updated int32, err error) {
+	defer func() {
+		if cErr := c.adjustCardinality(key, int(added)); err == nil {
+			err = cErr
+		}
+	}()
+
+	for member, location := range members {
+		status, err := c.zAddMember(key, member, gCell{location}, location.attributes(), flags)
+		if err != nil {
+			return added, updated, err
+		}
+
+		switch status {
+		case MemberAdded:
+			added++
+		case MemberUpdated:
+			updated++
+		}
+	}
+
+	return
+}
+
+// GEOADDBULK is a bulk loading alternative to GEOADD for large numbers of members, and works like ZADDBULK: members
+// are written unconditionally with batched writes, up to concurrency at a time.
+//
+// Cost is O(N) / 1 WCU per member, plus the cost of RepairCardinality with CountedCardinality.
+func (c Client) GEOADDBULK(key string, members map[string]GLocation, concurrency int) (err error) {
+	requests := make([]types.WriteRequest, 0, len(members))
+
+	for member, location := range members {
+		item := keyDef{pk: key, sk: member}.toAV(c)
+		item[c.sortKeyNum] = location.toAV()
+
+		for name, value := range location.attributes() {
+			item[name] = value.ToAV()
+		}
+
+		requests = append(requests, putRequest(item))
+	}
+
+	return c.bulkWrite(key, requests, concurrency)
+}
+
+// GEODEL removes the given members and their locations from the key, and returns the number of members that were
+// removed. Members that don't exist are ignored. Geo members are stored as sorted set members with the location as
+// their score, so this is the same as ZREM – removing a member removes the whole item.
+//
+// Cost is O(1) / 1 WCU for each member.
+func (c Client) GEODEL(key string, members ...string) (removedCount int32, err error) {
+	removedMembers, err := c.ZREM(key, members...)
+
+	return int32(len(removedMembers)), err
+}
+
 // GEODIST returns the scalar distance between the two members, converted to the given unit. If either of
 // the members or the key is missing, ok will be false. Each GUnit also has convenience methods to convert
 // distances into other units.
@@ -118,6 +263,21 @@ func (c Client) GEODIST(key string, member1, member2 string, unit GUnit) (distan
 	return locations[member1].DistanceTo(locations[member2], unit), true, nil
 }
 
+// GEOAZIMUTH works like GEODIST, but also returns the initial bearing from member1 to member2 in degrees clockwise
+// from north, from the same read of the two locations. See GLocation.BearingTo for the details.
+//
+// Cost is O(1) / 1 RCU for each of the two members.
+func (c Client) GEOAZIMUTH(key string, member1, member2 string, unit GUnit) (distance float64, bearing float64, ok bool, err error) {
+	locations, err := c.GEOPOS(key, member1, member2)
+	if err != nil || len(locations) < 2 {
+		return
+	}
+
+	from, to := locations[member1], locations[member2]
+
+	return from.DistanceTo(to, unit), from.BearingTo(to), true, nil
+}
+
 // GEOHASH returns the Geohash strings (see https://en.wikipedia.org/wiki/Geohash) of the given members. If any members
 // were not found, they will not be present in the returned map.
 //
@@ -138,7 +298,9 @@ func (c Client) GEOHASH(key string, members ...string) (geohashes map[string]str
 }
 
 // GEOPOS returns the stored locations for each of the given members, as a map of member to location.
-// If a member cannot be found, it will not be present in the returned map.
+// If a member cannot be found, it will not be present in the returned map. The exact coordinates passed
+// to GEOADD are returned – only locations written by older versions come back as the center of the s2
+// cell they are indexed by, which is within a centimeter of the original location.
 //
 // Cost is O(1) / 1 RCU for each member.
 //
@@ -158,93 +320,103 @@ func (c Client) GEOPOS(key string, members ...string) (locations map[string]GLoc
 		}
 
 		if len(resp.Item) > 0 {
-			locations[member] = fromCellIDString(resp.Item[c.sortKeyNum].(*types.AttributeValueMemberN).Value)
+			locations[member] = c.geoExactLocation(resp.Item)
 		}
 	}
 
 	return
 }
 
-// GEORADIUS returns the members (limited to the given count) that are located within the given radius
-// of the given center. Note that the positions are returned in no particular order – if there are more
-// members inside the given radius than the given count, the method *does not* guarantee that the returned
-// locations are the closest.
+// GEORADIUS returns the members that are located within the given radius of the given center, limited to the
+// count members closest to the center. Nothing is returned if count is 0 or less; use GEOSEARCH without a Count to
+// get every member inside the radius. Every member is returned once, even if it lies in more than one of the cells
+// searched.
 //
-// The GLocation type has convenience methods to calculate the distance between points, this can be used
-// to sort the locations as required.
+// To find the closest members, every member inside the area searched has to be read. GEORADIUSANY stops as soon
+// as count members are found instead, which is cheaper if any members will do.
 //
-// Cost is O(N) where N is the number of locations inside the square / bounding box that contains the circle
-// we're searching inside.
+// Cost is O(N) where N is the number of locations inside the cells covering the circle we're searching inside.
 //
 // Works similar to https://redis.io/commands/georadius
 func (c Client) GEORADIUS(key string, center GLocation, radius float64, radiusUnit GUnit, count int32) (positions map[string]GLocation, err error) {
+	return c.geoRadius(key, center, radius, radiusUnit, GQueryOptions{Count: count})
+}
+
+// GEORADIUSANY works like GEORADIUS, but returns the first count members found inside the radius instead of the
+// closest ones, like the ANY option in Redis. The search stops as soon as count members are found, so the cost
+// depends on count rather than on the number of members in the area.
+//
+// Cost is O(count) in the best case, and O(N) like GEORADIUS if fewer than count members are inside the radius.
+func (c Client) GEORADIUSANY(key string, center GLocation, radius float64, radiusUnit GUnit, count int32) (positions map[string]GLocation, err error) {
+	return c.geoRadius(key, center, radius, radiusUnit, GQueryOptions{Count: count, Any: true})
+}
+
+func (c Client) geoRadius(key string, center GLocation, radius float64, radiusUnit GUnit, options GQueryOptions) (positions map[string]GLocation, err error) {
 	positions = make(map[string]GLocation)
-	radiusCap := s2.CapFromCenterAngle(s2.PointFromLatLng(center.s2LatLng()), s1.Angle(radiusUnit.To(Meters, radius)/earthRadiusMeters))
-
-	for _, cellID := range radiusCap.CellUnionBound() {
-		builder := newExpresionBuilder()
-		builder.addConditionEquality(c.partitionKey, StringValue{key})
-		builder.condition(fmt.Sprintf("#%v BETWEEN :start AND :stop", c.sortKeyNum), c.sortKeyNum)
-		builder.values["start"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", cellID.RangeMin())}
-		builder.values["stop"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", cellID.RangeMax())}
-
-		var cursor map[string]types.AttributeValue
-
-		hasMoreResults := true
-
-		for hasMoreResults && count > 0 {
-			resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
-				ConsistentRead:            aws.Bool(c.consistentReads),
-				ExclusiveStartKey:         cursor,
-				ExpressionAttributeNames:  builder.expressionAttributeNames(),
-				ExpressionAttributeValues: builder.expressionAttributeValues(),
-				IndexName:                 aws.String(c.indexName),
-				KeyConditionExpression:    builder.conditionExpression(),
-				Limit:                     aws.Int32(count),
-				TableName:                 aws.String(c.tableName),
-			})
-			if err != nil {
-				return positions, err
-			}
-
-			if len(resp.LastEvaluatedKey) > 0 {
-				cursor = resp.LastEvaluatedKey
-			} else {
-				hasMoreResults = false
-			}
-
-			for _, item := range resp.Items {
-				location := fromCellIDString(item[c.sortKeyNum].(*types.AttributeValueMemberN).Value)
-				member := item[c.sortKey].(*types.AttributeValueMemberS).Value
-
-				if center.DistanceTo(location, radiusUnit) <= radius {
-					positions[member] = location
-					count--
-				}
-			}
-		}
+
+	if options.Count <= 0 {
+		return
+	}
+
+	results, err := c.GEOSEARCH(key, center, GRadius{Radius: radius, Unit: radiusUnit}, options)
+	for _, result := range results {
+		positions[result.Member] = result.Location
 	}
 
 	return
 }
 
-// GEORADIUSBYMEMBER returns the members (limited to the given count) that are located within the given radius
-// of the given member. Note that the positions are returned in no particular order – if there are more
-// members inside the given radius than the given count, the method *does not* guarantee that the returned
-// locations are the closest.
-//
-// The GLocation type has convenience methods to calculate the distance between points, this can be used
-// to sort the locations as required.
+// GEORADIUSBYMEMBER works like GEORADIUS, but searches around the location of the given member, which is
+// included in the results.
 //
-// Cost is O(N) where N is the number of locations inside the square / bounding box that contains the circle
-// we're searching inside.
+// If the member doesn't exist, ErrMemberNotFound is returned.
 //
 // Works similar to https://redis.io/commands/georadiusbymember
 func (c Client) GEORADIUSBYMEMBER(key string, member string, radius float64, radiusUnit GUnit, count int32) (positions map[string]GLocation, err error) {
-	locations, err := c.GEOPOS(key, member)
+	center, err := c.geoMemberLocation(key, member)
 	if err == nil {
-		positions, err = c.GEORADIUS(key, locations[member], radius, radiusUnit, count)
+		positions, err = c.GEORADIUS(key, center, radius, radiusUnit, count)
 	}
 
 	return
 }
+
+// GEORADIUSBYMEMBERANY works like GEORADIUSANY around the location of the given member.
+//
+// If the member doesn't exist, ErrMemberNotFound is returned.
+func (c Client) GEORADIUSBYMEMBERANY(key string, member string, radius float64, radiusUnit GUnit, count int32) (positions map[string]GLocation, err error) {
+	center, err := c.geoMemberLocation(key, member)
+	if err == nil {
+		positions, err = c.GEORADIUSANY(key, center, radius, radiusUnit, count)
+	}
+
+	return
+}
+
+func (c Client) geoMemberLocation(key string, member string) (location GLocation, err error) {
+	locations, err := c.GEOPOS(key, member)
+	if err != nil {
+		return
+	}
+
+	location, found := locations[member]
+	if !found {
+		return location, ErrMemberNotFound
+	}
+
+	return location, nil
+}
+
+// geoExactLocation reads the exact coordinates of a member if they were stored, and the center of its cell otherwise.
+// The coordinates are only used if they are inside the stored cell, so that a score written over the location with
+// ZADD isn't hidden by stale coordinates.
+func (c Client) geoExactLocation(item map[string]types.AttributeValue) GLocation {
+	cellID := item[c.sortKeyNum].(*types.AttributeValueMemberN).Value
+	exact := GLocation{Lat: ReturnValue{item[geoLatKey]}.Float(), Lon: ReturnValue{item[geoLonKey]}.Float()}
+
+	if item[geoLatKey] != nil && item[geoLonKey] != nil && exact.s2CellID() == cellID {
+		return exact
+	}
+
+	return fromCellIDString(cellID)
+}
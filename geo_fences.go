@@ -0,0 +1,281 @@
+package redimo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/golang/geo/s2"
+)
+
+// ErrInvalidFence is returned when a fence has neither a positive radius nor a polygon with at least three vertices.
+var ErrInvalidFence = errors.New("fence needs a positive radius or at least three vertices")
+
+// The cells covering a fence are stored at these levels only – from about 300 km down to about 150 m wide – so
+// that the fences containing a point can be found by looking up the point's cell at each of them.
+const (
+	geoFenceMinLevel = 6
+	geoFenceMaxLevel = 18
+	geoFenceLevelMod = 3
+)
+
+// GFence is a named area registered on a geo key with GEOFENCESET. A fence is either a circle with the given radius
+// around Center, or, if Polygon has vertices, the polygon they outline. The edges of a polygon are the shortest
+// lines between the vertices, and the polygon is always the smaller of the two areas they enclose.
+type GFence struct {
+	Center  GLocation
+	Radius  float64
+	Unit    GUnit
+	Polygon []GLocation
+}
+
+func (f GFence) valid() bool {
+	if len(f.Polygon) > 0 {
+		return len(f.Polygon) >= 3
+	}
+
+	return f.Radius > 0 && f.Unit > 0
+}
+
+func (f GFence) region() s2.Region {
+	if len(f.Polygon) > 0 {
+		return geoLoop(f.Polygon)
+	}
+
+	return geoRadiusCap(f.Center, f.Radius, f.Unit)
+}
+
+// Contains returns true if the location is inside the fence.
+func (f GFence) Contains(location GLocation) bool {
+	if len(f.Polygon) > 0 {
+		return geoLoop(f.Polygon).ContainsPoint(s2.PointFromLatLng(location.s2LatLng()))
+	}
+
+	return f.Center.DistanceTo(location, f.Unit) <= f.Radius
+}
+
+func (f GFence) center() GLocation {
+	if len(f.Polygon) > 0 {
+		ll := s2.LatLngFromPoint(geoLoop(f.Polygon).CapBound().Center())
+		return GLocation{Lat: ll.Lat.Degrees(), Lon: ll.Lng.Degrees()}
+	}
+
+	return f.Center
+}
+
+// geoLoop turns the vertices of a polygon into an s2 loop around the smaller of the two areas they enclose.
+func geoLoop(vertices []GLocation) *s2.Loop {
+	points := make([]s2.Point, len(vertices))
+	for i, vertex := range vertices {
+		points[i] = s2.PointFromLatLng(vertex.s2LatLng())
+	}
+
+	loop := s2.LoopFromPoints(points)
+	loop.Normalize()
+
+	return loop
+}
+
+// geoFenceCells returns the cells stored in the fence index for the given fence.
+func geoFenceCells(fence GFence) s2.CellUnion {
+	coverer := s2.RegionCoverer{
+		MinLevel: geoFenceMinLevel,
+		MaxLevel: geoFenceMaxLevel,
+		LevelMod: geoFenceLevelMod,
+		MaxCells: geoCoveringCells,
+	}
+
+	return coverer.Covering(fence.region())
+}
+
+// geoFencesKey is the hash holding the definitions of the fences registered on key, and geoFenceIndexKey holds an
+// item for every cell covering each of the fences, with the cell ID in the score attribute.
+func geoFencesKey(key string) string {
+	return fmt.Sprintf("_redimo/%v/fences", key)
+}
+
+func geoFenceIndexKey(key string) string {
+	return fmt.Sprintf("_redimo/%v/fencecells", key)
+}
+
+// geoFenceDerivedKeys returns the keys holding the fences of the geo key at key, which COPY copies along with the key.
+func geoFenceDerivedKeys(key string) []string {
+	return []string{geoFencesKey(key), geoFenceIndexKey(key)}
+}
+
+func (c Client) geoFenceIndexItems(key string, name string, fence GFence) (items []map[string]types.AttributeValue) {
+	for _, cellID := range geoFenceCells(fence) {
+		item := keyDef{pk: geoFenceIndexKey(key), sk: fmt.Sprintf("%d/%v", cellID, name)}.toAV(c)
+		item[c.sortKeyNum] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", cellID)}
+		items = append(items, item)
+	}
+
+	return
+}
+
+// GEOFENCESET registers a fence with the given name on the geo key, replacing any existing fence with that name.
+// The definition of the fence is stored along with the cells covering it, which lets GEOFENCES find the fences that
+// contain a point without reading every fence.
+//
+// Replacing a fence is not atomic: while it is being replaced, GEOFENCES may miss the fence.
+//
+// Cost is O(1) / 1 WCU for the definition, plus up to 2 WCUs for each of the (up to 8) cells covering the old and
+// the new fence.
+func (c Client) GEOFENCESET(key string, name string, fence GFence) (err error) {
+	if !fence.valid() {
+		return ErrInvalidFence
+	}
+
+	if _, err = c.GEOFENCEDEL(key, name); err != nil {
+		return
+	}
+
+	definition, err := json.Marshal(fence)
+	if err != nil {
+		return
+	}
+
+	if _, err = c.HSET(geoFencesKey(key), name, string(definition)); err != nil {
+		return
+	}
+
+	var requests []types.WriteRequest
+	for _, item := range c.geoFenceIndexItems(key, name, fence) {
+		requests = append(requests, putRequest(item))
+	}
+
+	return c.batchWrite(requests)
+}
+
+// GEOFENCEGET returns the fence with the given name. If there is no such fence, found is false.
+//
+// Cost is O(1) / 1 RCU.
+func (c Client) GEOFENCEGET(key string, name string) (fence GFence, found bool, err error) {
+	val, err := c.HGET(geoFencesKey(key), name)
+	if err != nil || val.Empty() {
+		return
+	}
+
+	err = json.Unmarshal([]byte(val.String()), &fence)
+
+	return fence, err == nil, err
+}
+
+// GEOFENCEDEL removes the fence with the given name. If there is no such fence, removed is false.
+//
+// Cost is O(1) / 1 WCU for the definition and for each of the cells covering the fence.
+func (c Client) GEOFENCEDEL(key string, name string) (removed bool, err error) {
+	fence, found, err := c.GEOFENCEGET(key, name)
+	if err != nil || !found {
+		return
+	}
+
+	var requests []types.WriteRequest
+	for _, item := range c.geoFenceIndexItems(key, name, fence) {
+		requests = append(requests, deleteRequest(keyDef{pk: geoFenceIndexKey(key), sk: parseKey(item, c).sk}.toAV(c)))
+	}
+
+	if err = c.batchWrite(requests); err != nil {
+		return
+	}
+
+	deleted, err := c.HDEL(geoFencesKey(key), name)
+
+	return len(deleted) > 0, err
+}
+
+// GEOFENCES returns the names of the fences registered on the geo key that contain the given location. The cell
+// index is looked up at a handful of cell levels around the location, and only the fences found there are read and
+// checked exactly, so the cost doesn't grow with the number of fences that are far away.
+//
+// Cost is O(L) queries for the cell levels, plus 1 RCU for each fence that is close to the location.
+func (c Client) GEOFENCES(key string, location GLocation) (names []string, err error) {
+	leaf := s2.CellIDFromLatLng(location.s2LatLng())
+	candidates := make(map[string]struct{})
+
+	for level := geoFenceMinLevel; level <= geoFenceMaxLevel; level += geoFenceLevelMod {
+		cellNames, err := c.geoFencesInCell(key, leaf.Parent(level))
+		if err != nil {
+			return names, err
+		}
+
+		for _, name := range cellNames {
+			candidates[name] = struct{}{}
+		}
+	}
+
+	for name := range candidates {
+		fence, found, err := c.GEOFENCEGET(key, name)
+		if err != nil {
+			return names, err
+		}
+
+		if found && fence.Contains(location) {
+			names = append(names, name)
+		}
+	}
+
+	return
+}
+
+func (c Client) geoFencesInCell(key string, cellID s2.CellID) (names []string, err error) {
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{geoFenceIndexKey(key)})
+	builder.addConditionEquality(c.sortKeyNum, ReturnValue{&types.AttributeValueMemberN{Value: fmt.Sprintf("%d", cellID)}})
+
+	var cursor map[string]types.AttributeValue
+
+	for hasMoreResults := true; hasMoreResults; {
+		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+			ConsistentRead:            aws.Bool(c.consistentReads),
+			ExclusiveStartKey:         cursor,
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			IndexName:                 aws.String(c.indexName),
+			KeyConditionExpression:    builder.conditionExpression(),
+			TableName:                 aws.String(c.tableName),
+		})
+		if err != nil {
+			return names, err
+		}
+
+		for _, item := range resp.Items {
+			parts := strings.SplitN(parseKey(item, c).sk, "/", 2)
+			if len(parts) == 2 {
+				names = append(names, parts[1])
+			}
+		}
+
+		cursor = resp.LastEvaluatedKey
+		hasMoreResults = len(cursor) > 0
+	}
+
+	return
+}
+
+// GEOFENCEMEMBERS returns the members of the geo key that are inside the fence with the given name. Distances in
+// the results are measured from the center of the fence – the center of its bounding circle for a polygon – in the
+// unit of the fence, or in meters for a polygon. See GQueryOptions for the options. If there is no such fence, found
+// is false.
+//
+// Cost is O(N) where N is the number of locations inside the cells covering the fence.
+func (c Client) GEOFENCEMEMBERS(key string, name string, options GQueryOptions) (results []GResult, found bool, err error) {
+	fence, found, err := c.GEOFENCEGET(key, name)
+	if err != nil || !found {
+		return
+	}
+
+	unit := fence.Unit
+	if len(fence.Polygon) > 0 || unit <= 0 {
+		unit = Meters
+	}
+
+	results, err = c.geoSearch(context.TODO(), key, fence.center(), fence.region(), unit, fence.Contains, options)
+
+	return results, true, err
+}
@@ -0,0 +1,86 @@
+package redimo
+
+import (
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/stretchr/testify/assert"
+)
+
+var tamilNaduCoast = []GLocation{{13.5, 79.9}, {13.5, 80.6}, {9.0, 80.6}, {9.5, 77.5}}
+
+func TestGeoFenceShapes(t *testing.T) {
+	clockwise := GFence{Polygon: tamilNaduCoast}
+	counterClockwise := GFence{Polygon: []GLocation{tamilNaduCoast[3], tamilNaduCoast[2], tamilNaduCoast[1], tamilNaduCoast[0]}}
+
+	for _, fence := range []GFence{clockwise, counterClockwise} {
+		assert.True(t, fence.Contains(GLocation{11.5, 79.5}))
+		assert.False(t, fence.Contains(GLocation{12.97, 77.56}))
+		assert.False(t, fence.Contains(GLocation{51.5, -0.12}))
+	}
+
+	circle := GFence{Center: GLocation{13.09, 80.28}, Radius: 130, Unit: Kilometers}
+	assert.True(t, circle.Contains(GLocation{12.9204, 79.15}))
+	assert.False(t, circle.Contains(GLocation{11.935, 79.83}))
+
+	assert.False(t, GFence{Polygon: tamilNaduCoast[:2]}.valid())
+	assert.False(t, GFence{Center: GLocation{13.09, 80.28}}.valid())
+
+	leaf := s2.CellIDFromLatLng(GLocation{11.5, 79.5}.s2LatLng())
+	cells := geoFenceCells(clockwise)
+	assert.True(t, cells.ContainsCellID(leaf))
+
+	for _, cellID := range cells {
+		assert.Equal(t, 0, (cellID.Level()-geoFenceMinLevel)%geoFenceLevelMod)
+		assert.True(t, cellID.Level() <= geoFenceMaxLevel)
+	}
+}
+
+func TestGeoFences(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	assert.Equal(t, ErrInvalidFence, c.GEOFENCESET("india", "broken", GFence{Polygon: tamilNaduCoast[:2]}))
+
+	err := c.GEOFENCESET("india", "south", GFence{Polygon: tamilNaduCoast})
+	assert.NoError(t, err)
+
+	err = c.GEOFENCESET("india", "chennai-metro", GFence{Center: GLocation{13.09, 80.28}, Radius: 50, Unit: Kilometers})
+	assert.NoError(t, err)
+
+	names, err := c.GEOFENCES("india", GLocation{11.5, 79.5})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"south"}, names)
+
+	names, err = c.GEOFENCES("india", GLocation{13.0, 80.2})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"south", "chennai-metro"}, names)
+
+	names, err = c.GEOFENCES("india", GLocation{51.5, -0.12})
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	results, found, err := c.GEOFENCEMEMBERS("india", "south", GQueryOptions{})
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.ElementsMatch(t, []string{"chennai", "madurai", "pondy"}, resultMembers(results))
+
+	err = c.GEOFENCESET("india", "chennai-metro", GFence{Center: GLocation{12.97, 77.56}, Radius: 50, Unit: Kilometers})
+	assert.NoError(t, err)
+
+	names, err = c.GEOFENCES("india", GLocation{13.0, 80.2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"south"}, names)
+
+	removed, err := c.GEOFENCEDEL("india", "south")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+
+	_, found, err = c.GEOFENCEMEMBERS("india", "south", GQueryOptions{})
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	names, err = c.GEOFENCES("india", GLocation{11.5, 79.5})
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
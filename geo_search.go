@@ -0,0 +1,500 @@
+package redimo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// geoCoveringCells is the number of cells used to cover a search area. More cells follow the shape of the area
+// more closely, so fewer members outside of it are read, but every cell is a separate query.
+const geoCoveringCells = 8
+
+// geoMaxLevel is the level of the smallest s2 cells, which locations are stored as.
+const geoMaxLevel = 30
+
+// GEONEAREST starts searching within geoNearestStartMeters of the center, and multiplies the radius by
+// geoNearestGrowth until enough members are found.
+const (
+	geoNearestStartMeters = 1000.0
+	geoNearestGrowth      = 4
+)
+
+// ErrInvalidPolygon is returned by GEOSEARCHPOLYGON when the polygon has less than three vertices.
+var ErrInvalidPolygon = errors.New("polygon needs at least three vertices")
+
+// GSort is the order of the results of a geo search, by distance from the center.
+type GSort string
+
+const (
+	GUnsorted   GSort = ""
+	GAscending  GSort = "ASC"
+	GDescending GSort = "DESC"
+)
+
+// GQueryOptions are the Redis options of the geo search commands.
+//
+// WithDist and WithHash fill in the Distance (in the unit of the search) and Geohash of every result. Sort orders
+// the results by their distance from the center. Count limits the number of results – the count closest results
+// are returned, so every member in the area is read and sorted first. With Any, the search stops as soon as count
+// results are found instead, which is much cheaper for large areas but doesn't return the closest members.
+// ExcludeMember leaves the member that a search like GEOSEARCHBYMEMBER is centered on out of the results.
+type GQueryOptions struct {
+	WithDist      bool
+	WithHash      bool
+	Sort          GSort
+	Count         int32
+	Any           bool
+	ExcludeMember bool
+
+	// from is the member the search is centered on, if any.
+	from string
+}
+
+// GResult is a single member found by a geo search, along with the extra information asked for in GQueryOptions.
+// Searches only read the score index, so Location is the center of the s2 cell the member is indexed by, which is
+// within a centimeter of the location passed to GEOADD – use GEOPOS for the exact coordinates.
+type GResult struct {
+	Member   string
+	Location GLocation
+	Distance float64
+	Geohash  string
+}
+
+// GShape is the area searched by GEOSEARCH around its center: a GRadius or a GBox.
+type GShape interface {
+	region(center GLocation) s2.Region
+	contains(center GLocation, location GLocation) bool
+	unit() GUnit
+}
+
+// GRadius is a circle with the given radius, like BYRADIUS in GEOSEARCH.
+type GRadius struct {
+	Radius float64
+	Unit   GUnit
+}
+
+func (r GRadius) region(center GLocation) s2.Region {
+	return geoRadiusCap(center, r.Radius, r.Unit)
+}
+
+func (r GRadius) contains(center GLocation, location GLocation) bool {
+	return center.DistanceTo(location, r.Unit) <= r.Radius
+}
+
+func (r GRadius) unit() GUnit {
+	return r.Unit
+}
+
+// GBox is a rectangle aligned with the meridians and parallels, like BYBOX in GEOSEARCH. Width is measured east to
+// west and Height north to south, both through the center of the box.
+type GBox struct {
+	Width  float64
+	Height float64
+	Unit   GUnit
+}
+
+func (b GBox) region(center GLocation) s2.Region {
+	halfHeight := b.Unit.To(Meters, b.Height/2) / earthRadiusMeters
+	halfWidth := b.Unit.To(Meters, b.Width/2) / earthRadiusMeters
+	maxLat := math.Min(math.Abs(center.s2LatLng().Lat.Radians())+halfHeight, math.Pi/2)
+
+	// A point at the edge of the box is halfWidth away from the meridian of the center along a great circle, which
+	// spans the most longitude on the parallel closest to a pole.
+	halfLng := math.Pi
+	if ratio := math.Sin(halfWidth/2) / math.Cos(maxLat); ratio < 1 {
+		halfLng = 2 * math.Asin(ratio)
+	}
+
+	return s2.RectFromCenterSize(center.s2LatLng(), s2.LatLng{Lat: s1.Angle(2 * halfHeight), Lng: s1.Angle(2 * halfLng)})
+}
+
+func (b GBox) contains(center GLocation, location GLocation) bool {
+	onMeridian := GLocation{Lat: location.Lat, Lon: center.Lon}
+
+	return center.DistanceTo(onMeridian, b.Unit) <= b.Height/2 && location.DistanceTo(onMeridian, b.Unit) <= b.Width/2
+}
+
+func (b GBox) unit() GUnit {
+	return b.Unit
+}
+
+// GEOSEARCH returns the members inside the given shape around center – a GRadius, like GEORADIUSWITHOPTIONS, or a
+// GBox. The members in the cells covering the shape are read from the score index and only those actually inside the
+// shape are returned. Distances in the results are in the unit of the shape. See GQueryOptions for the options.
+//
+// Cost is O(N) where N is the number of locations inside the cells covering the shape.
+//
+// Works similar to https://redis.io/commands/geosearch
+func (c Client) GEOSEARCH(key string, center GLocation, shape GShape, options GQueryOptions) (results []GResult, err error) {
+	return c.geoSearch(context.TODO(), key, center, shape.region(center), shape.unit(), func(location GLocation) bool {
+		return shape.contains(center, location)
+	}, options)
+}
+
+// GEOSEARCHPOLYGON returns the members inside the polygon outlined by the given vertices, like a city boundary. The
+// edges of the polygon are the shortest lines between consecutive vertices (the last vertex connects back to the
+// first one), and the polygon is always the smaller of the two areas they enclose, so the vertices can be listed in
+// either direction. The members in the cells covering the polygon are read from the score index and only those
+// actually inside it are returned.
+//
+// Distances in the results are in meters from the center of the smallest circle around the polygon. See
+// GQueryOptions for the options. ErrInvalidPolygon is returned if there are less than three vertices.
+//
+// Cost is O(N) where N is the number of locations inside the cells covering the polygon.
+func (c Client) GEOSEARCHPOLYGON(key string, vertices []GLocation, options GQueryOptions) (results []GResult, err error) {
+	if len(vertices) < 3 {
+		return nil, ErrInvalidPolygon
+	}
+
+	fence := GFence{Polygon: vertices}
+
+	return c.geoSearch(context.TODO(), key, fence.center(), fence.region(), Meters, fence.Contains, options)
+}
+
+// GEOSEARCHBYMEMBER works like GEOSEARCH around the location of the given member, like FROMMEMBER in GEOSEARCH.
+// The member itself is part of the results unless ExcludeMember is set in the options. If the member doesn't exist,
+// ErrMemberNotFound is returned.
+//
+// Works similar to https://redis.io/commands/geosearch
+func (c Client) GEOSEARCHBYMEMBER(key string, member string, shape GShape, options GQueryOptions) (results []GResult, err error) {
+	center, err := c.geoMemberLocation(key, member)
+	if err == nil {
+		options.from = member
+		results, err = c.GEOSEARCH(key, center, shape, options)
+	}
+
+	return
+}
+
+// GEOSEARCHSCAN pages through the members inside the given shape around center, like ZSCAN does for a sorted set.
+// Every call reads up to count members from the score index and returns the ones inside the shape, along with a
+// cursor for the next call. Start with the ScanStart cursor; when the returned cursor is ScanStart again, the scan is
+// complete. The results of each page are in no particular order, and a page may hold fewer than count results (even
+// none) while the cursor is not yet ScanStart.
+//
+// The cursor is an opaque string holding the cell of the covering the scan stopped in and the DynamoDB pagination
+// key inside that cell, so it can be stored and used to resume the scan later, even from another process, as long
+// as the same center and shape are passed again.
+func (c Client) GEOSEARCHSCAN(key string, center GLocation, shape GShape, cursor string, count int32) (results []GResult, nextCursor string, err error) {
+	cell, startKey, err := decodeCellCursor(cursor)
+	if err != nil {
+		return
+	}
+
+	covering := geoCovering(shape.region(center))
+	read := int32(0)
+
+	for ; cell < len(covering); cell++ {
+		limit := int32(0)
+		if count > 0 {
+			limit = count - read
+		}
+
+		resp, err := c.geoQueryCell(context.TODO(), key, covering[cell], startKey, limit)
+		if err != nil {
+			return results, cursor, err
+		}
+
+		for _, item := range resp.Items {
+			member, location := c.geoItem(item)
+			if shape.contains(center, location) {
+				results = append(results, GResult{Member: member, Location: location})
+			}
+		}
+
+		read += int32(len(resp.Items))
+		startKey = resp.LastEvaluatedKey
+
+		if len(startKey) > 0 {
+			return results, encodeCellCursor(cell, startKey), nil
+		}
+
+		if count > 0 && read >= count {
+			break
+		}
+	}
+
+	if cell+1 >= len(covering) {
+		return results, ScanStart, nil
+	}
+
+	return results, encodeCellCursor(cell+1, nil), nil
+}
+
+// GEOSEARCHPAGES walks the members inside the given shape around center, calling fn with the members found by each
+// query of at most pageSize members, as the cells covering the shape are read one after another. Only one page is
+// held in memory, so arbitrarily large areas can be walked. The walk stops early when fn returns false, and when ctx
+// is done, in which case the context error is returned. The members are in no particular order.
+//
+// Pages are read lazily, so members that are added, moved or removed during the walk may or may not be seen.
+func (c Client) GEOSEARCHPAGES(ctx context.Context, key string, center GLocation, shape GShape, pageSize int32, fn func(page []GResult) bool) error {
+	for _, cellID := range geoCovering(shape.region(center)) {
+		var startKey map[string]types.AttributeValue
+
+		for hasMoreResults := true; hasMoreResults; {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			resp, err := c.geoQueryCell(ctx, key, cellID, startKey, pageSize)
+			if err != nil {
+				return err
+			}
+
+			page := make([]GResult, 0, len(resp.Items))
+
+			for _, item := range resp.Items {
+				member, location := c.geoItem(item)
+				if shape.contains(center, location) {
+					page = append(page, GResult{Member: member, Location: location})
+				}
+			}
+
+			if len(page) > 0 && !fn(page) {
+				return nil
+			}
+
+			startKey = resp.LastEvaluatedKey
+			hasMoreResults = len(startKey) > 0
+		}
+	}
+
+	return nil
+}
+
+// GEORADIUSPAGES works like GEOSEARCHPAGES for the members within the given radius of center.
+func (c Client) GEORADIUSPAGES(ctx context.Context, key string, center GLocation, radius float64, radiusUnit GUnit, pageSize int32, fn func(page []GResult) bool) error {
+	return c.GEOSEARCHPAGES(ctx, key, center, GRadius{Radius: radius, Unit: radiusUnit}, pageSize, fn)
+}
+
+// GEORADIUSSCAN works like GEOSEARCHSCAN for the members within the given radius of center.
+func (c Client) GEORADIUSSCAN(key string, center GLocation, radius float64, radiusUnit GUnit, cursor string, count int32) (results []GResult, nextCursor string, err error) {
+	return c.GEOSEARCHSCAN(key, center, GRadius{Radius: radius, Unit: radiusUnit}, cursor, count)
+}
+
+// GEONEAREST returns the k members closest to center, closest first, with their distance from center in the given
+// unit. It searches a small radius around center first and widens the search until k members are found inside the
+// radius, so the members returned are always the k closest, without having to guess a radius that holds them.
+//
+// Every widening searches the whole circle again, so the cost depends on how far away the k-th closest member is:
+// about O(N) where N is the number of locations inside the cells covering the final circle, read a few times over.
+func (c Client) GEONEAREST(key string, center GLocation, k int32, unit GUnit) (results []GResult, err error) {
+	if k <= 0 {
+		return
+	}
+
+	for radius := geoNearestStartMeters; ; radius *= geoNearestGrowth {
+		results, err = c.GEOSEARCH(key, center, GRadius{Radius: radius, Unit: Meters}, GQueryOptions{Sort: GAscending, WithDist: true})
+		if err != nil {
+			return results, err
+		}
+
+		if int32(len(results)) >= k || radius >= math.Pi*earthRadiusMeters {
+			break
+		}
+	}
+
+	if int32(len(results)) > k {
+		results = results[:k]
+	}
+
+	for i := range results {
+		results[i].Distance = Meters.To(unit, results[i].Distance)
+	}
+
+	return results, nil
+}
+
+// GCellCount is the number of members located in an s2 cell, as returned by GEOAGG.
+type GCellCount struct {
+	CellID s2.CellID
+	Center GLocation
+	Count  int32
+}
+
+// GEOAGG counts the members inside the given shape around center per s2 cell at the given level (0 to 30, where a
+// level 10 cell is about 10 km wide and each level halves the width), for rendering density maps without returning
+// every member. Only the cells holding at least one member are returned, ordered by cell ID, which keeps neighboring
+// cells close to each other.
+//
+// Cost is O(N) where N is the number of locations inside the cells covering the shape – the keys of the members
+// are read from the score index and counted, but not returned.
+func (c Client) GEOAGG(key string, level int, center GLocation, shape GShape) (counts []GCellCount, err error) {
+	if level < 0 {
+		level = 0
+	}
+
+	if level > geoMaxLevel {
+		level = geoMaxLevel
+	}
+
+	cellCounts := make(map[s2.CellID]int32)
+
+	err = c.geoScan(context.TODO(), key, shape.region(center), func(member string, location GLocation) bool {
+		if shape.contains(center, location) {
+			cellCounts[s2.CellIDFromLatLng(location.s2LatLng()).Parent(level)]++
+		}
+
+		return true
+	})
+
+	for cellID, count := range cellCounts {
+		ll := cellID.LatLng()
+		counts = append(counts, GCellCount{
+			CellID: cellID,
+			Center: GLocation{Lat: ll.Lat.Degrees(), Lon: ll.Lng.Degrees()},
+			Count:  count,
+		})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].CellID < counts[j].CellID
+	})
+
+	return
+}
+
+// GEORADIUSWITHOPTIONS works like GEORADIUS, but returns a list of results that can hold the distance and the
+// geohash of every member, sorted by distance if asked to. See GQueryOptions for the options.
+//
+// Works similar to https://redis.io/commands/georadius
+func (c Client) GEORADIUSWITHOPTIONS(key string, center GLocation, radius float64, radiusUnit GUnit, options GQueryOptions) (results []GResult, err error) {
+	return c.GEOSEARCH(key, center, GRadius{Radius: radius, Unit: radiusUnit}, options)
+}
+
+// GEORADIUSBYMEMBERWITHOPTIONS works like GEORADIUSWITHOPTIONS around the location of the given member, and like
+// GEOSEARCHBYMEMBER treats ExcludeMember and members that don't exist.
+//
+// Works similar to https://redis.io/commands/georadiusbymember
+func (c Client) GEORADIUSBYMEMBERWITHOPTIONS(key string, member string, radius float64, radiusUnit GUnit, options GQueryOptions) (results []GResult, err error) {
+	return c.GEOSEARCHBYMEMBER(key, member, GRadius{Radius: radius, Unit: radiusUnit}, options)
+}
+
+func geoRadiusCap(center GLocation, radius float64, radiusUnit GUnit) s2.Cap {
+	return s2.CapFromCenterAngle(s2.PointFromLatLng(center.s2LatLng()), s1.Angle(radiusUnit.To(Meters, radius)/earthRadiusMeters))
+}
+
+// geoSearch reads the members inside region, keeps the ones accepted by inside and applies the options. Distances
+// are measured from center in the given unit.
+func (c Client) geoSearch(ctx context.Context, key string, center GLocation, region s2.Region, unit GUnit,
+	inside func(location GLocation) bool, options GQueryOptions) (results []GResult, err error) {
+	err = c.geoScan(ctx, key, region, func(member string, location GLocation) bool {
+		if inside(location) && !(options.ExcludeMember && options.from != "" && member == options.from) {
+			results = append(results, GResult{Member: member, Location: location})
+		}
+
+		return !options.Any || options.Count <= 0 || int32(len(results)) < options.Count
+	})
+	if err != nil {
+		return results, err
+	}
+
+	order := options.Sort
+	if order == GUnsorted && options.Count > 0 && !options.Any {
+		order = GAscending
+	}
+
+	distances := make(map[string]float64, len(results))
+	for _, result := range results {
+		distances[result.Member] = center.DistanceTo(result.Location, unit)
+	}
+
+	if order != GUnsorted {
+		sort.SliceStable(results, func(i, j int) bool {
+			if order == GDescending {
+				return distances[results[i].Member] > distances[results[j].Member]
+			}
+
+			return distances[results[i].Member] < distances[results[j].Member]
+		})
+	}
+
+	if options.Count > 0 && int32(len(results)) > options.Count {
+		results = results[:options.Count]
+	}
+
+	for i := range results {
+		if options.WithDist {
+			results[i].Distance = distances[results[i].Member]
+		}
+
+		if options.WithHash {
+			results[i].Geohash = results[i].Location.Geohash()
+		}
+	}
+
+	return results, nil
+}
+
+// geoScan queries the score index for the members in the cells covering region and calls fn with each of them until
+// fn returns false. The cells don't overlap, so every member is passed at most once, but members close to the region
+// that are not inside it are passed as well.
+func (c Client) geoScan(ctx context.Context, key string, region s2.Region, fn func(member string, location GLocation) bool) error {
+	for _, cellID := range geoCovering(region) {
+		var cursor map[string]types.AttributeValue
+
+		for hasMoreResults := true; hasMoreResults; {
+			resp, err := c.geoQueryCell(ctx, key, cellID, cursor, 0)
+			if err != nil {
+				return err
+			}
+
+			for _, item := range resp.Items {
+				if !fn(c.geoItem(item)) {
+					return nil
+				}
+			}
+
+			cursor = resp.LastEvaluatedKey
+			hasMoreResults = len(cursor) > 0
+		}
+	}
+
+	return nil
+}
+
+// geoCovering returns the cells that cover region. The covering only depends on the region, so the same region
+// always gets the same cells, in the same order.
+func geoCovering(region s2.Region) s2.CellUnion {
+	coverer := s2.RegionCoverer{MaxLevel: geoMaxLevel, MaxCells: geoCoveringCells}
+	return coverer.CellUnion(region)
+}
+
+// geoQueryCell reads a page of the members located in the given cell, starting after startKey and reading up to
+// limit members if limit is positive.
+func (c Client) geoQueryCell(ctx context.Context, key string, cellID s2.CellID, startKey map[string]types.AttributeValue, limit int32) (*dynamodb.QueryOutput, error) {
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{key})
+	builder.condition(fmt.Sprintf("#%v BETWEEN :start AND :stop", c.sortKeyNum), c.sortKeyNum)
+	builder.values["start"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", cellID.RangeMin())}
+	builder.values["stop"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", cellID.RangeMax())}
+
+	var queryLimit *int32
+	if limit > 0 {
+		queryLimit = aws.Int32(limit)
+	}
+
+	return c.ddbClient.Query(ctx, &dynamodb.QueryInput{
+		ConsistentRead:            aws.Bool(c.consistentReads),
+		ExclusiveStartKey:         startKey,
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		IndexName:                 aws.String(c.indexName),
+		KeyConditionExpression:    builder.conditionExpression(),
+		Limit:                     queryLimit,
+		TableName:                 aws.String(c.tableName),
+	})
+}
+
+func (c Client) geoItem(item map[string]types.AttributeValue) (member string, location GLocation) {
+	return parseKey(item, c).sk, fromCellIDString(item[c.sortKeyNum].(*types.AttributeValueMemberN).Value)
+}
@@ -0,0 +1,236 @@
+package redimo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/geo/s2"
+	"github.com/stretchr/testify/assert"
+)
+
+func addIndianCities(t *testing.T, c Client) {
+	_, err := c.GEOADD("india", map[string]GLocation{
+		"chennai":    {13.09, 80.28},
+		"vellore":    {12.9204, 79.15},
+		"pondy":      {11.935, 79.83},
+		"bangalore":  {12.97, 77.56},
+		"coimbatore": {11, 76.95},
+		"madurai":    {9.939093, 78.121719},
+	})
+	assert.NoError(t, err)
+}
+
+func resultMembers(results []GResult) (members []string) {
+	for _, result := range results {
+		members = append(members, result.Member)
+	}
+
+	return
+}
+
+func TestGeoRadiusOptions(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	chennai := GLocation{13.09, 80.28}
+
+	results, err := c.GEORADIUSWITHOPTIONS("india", chennai, 400, Kilometers, GQueryOptions{Sort: GAscending, WithDist: true, WithHash: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chennai", "vellore", "pondy", "bangalore"}, resultMembers(results))
+	assert.InDelta(t, 0, results[0].Distance, 1)
+	assert.InDelta(t, 124, results[1].Distance, 1)
+	assert.Equal(t, results[1].Location.Geohash(), results[1].Geohash)
+
+	results, err = c.GEORADIUSWITHOPTIONS("india", chennai, 400, Kilometers, GQueryOptions{Sort: GDescending})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bangalore", "pondy", "vellore", "chennai"}, resultMembers(results))
+	assert.Zero(t, results[0].Distance)
+	assert.Empty(t, results[0].Geohash)
+
+	results, err = c.GEORADIUSWITHOPTIONS("india", chennai, 400, Kilometers, GQueryOptions{Count: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chennai", "vellore"}, resultMembers(results))
+
+	results, err = c.GEORADIUSWITHOPTIONS("india", chennai, 400, Kilometers, GQueryOptions{Count: 2, Any: true})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	results, err = c.GEORADIUSBYMEMBERWITHOPTIONS("india", "madurai", 100, Kilometers, GQueryOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"madurai"}, resultMembers(results))
+}
+
+func TestGeoSearchBox(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	chennai := GLocation{13.09, 80.28}
+
+	results, err := c.GEOSEARCH("india", chennai, GBox{Width: 300, Height: 100, Unit: Kilometers}, GQueryOptions{Sort: GAscending})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chennai", "vellore"}, resultMembers(results))
+
+	results, err = c.GEOSEARCH("india", chennai, GBox{Width: 700, Height: 100, Unit: Kilometers}, GQueryOptions{Sort: GAscending})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chennai", "vellore", "bangalore"}, resultMembers(results))
+
+	results, err = c.GEOSEARCHBYMEMBER("india", "chennai", GRadius{Radius: 130, Unit: Kilometers}, GQueryOptions{Sort: GDescending})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vellore", "chennai"}, resultMembers(results))
+}
+
+func TestGeoBoxContains(t *testing.T) {
+	box := GBox{Width: 200, Height: 100, Unit: Kilometers}
+	center := GLocation{60, 10}
+
+	assert.True(t, box.contains(center, GLocation{60.4, 11.5}))
+	assert.False(t, box.contains(center, GLocation{60.5, 10}))
+	assert.False(t, box.contains(center, GLocation{60, 12}))
+
+	region := box.region(center)
+	assert.True(t, region.ContainsPoint(s2.PointFromLatLng(GLocation{60.4, 11.5}.s2LatLng())))
+}
+
+func TestGeoSearchScan(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	var members []string
+
+	cursor := ScanStart
+
+	for calls := 0; calls < 100; calls++ {
+		results, nextCursor, err := c.GEORADIUSSCAN("india", GLocation{13.09, 80.28}, 400, Kilometers, cursor, 1)
+		assert.NoError(t, err)
+
+		members = append(members, resultMembers(results)...)
+		cursor = nextCursor
+
+		if cursor == ScanStart {
+			break
+		}
+	}
+
+	assert.Equal(t, ScanStart, cursor)
+	assert.ElementsMatch(t, []string{"chennai", "vellore", "pondy", "bangalore"}, members)
+
+	_, _, err := c.GEORADIUSSCAN("india", GLocation{13.09, 80.28}, 400, Kilometers, "bogus", 1)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestGeoNearest(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	results, err := c.GEONEAREST("india", GLocation{13.09, 80.28}, 3, Kilometers)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chennai", "vellore", "pondy"}, resultMembers(results))
+	assert.InDelta(t, 137.4, results[2].Distance, 1)
+
+	results, err = c.GEONEAREST("india", GLocation{51.5, -0.12}, 10, Kilometers)
+	assert.NoError(t, err)
+	assert.Len(t, results, 6)
+	assert.Equal(t, "bangalore", results[0].Member)
+	assert.Equal(t, "madurai", results[5].Member)
+
+	results, err = c.GEONEAREST("empty", GLocation{13.09, 80.28}, 3, Kilometers)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestGeoSearchPolygon(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	results, err := c.GEOSEARCHPOLYGON("india", []GLocation{{13.5, 79.9}, {13.5, 80.6}, {9.0, 80.6}, {9.5, 77.5}}, GQueryOptions{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"chennai", "madurai", "pondy"}, resultMembers(results))
+
+	results, err = c.GEOSEARCHPOLYGON("india", []GLocation{{9.5, 77.5}, {9.0, 80.6}, {13.5, 80.6}, {13.5, 79.9}}, GQueryOptions{Count: 1, Any: true})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	_, err = c.GEOSEARCHPOLYGON("india", []GLocation{{13.5, 79.9}, {13.5, 80.6}}, GQueryOptions{})
+	assert.Equal(t, ErrInvalidPolygon, err)
+}
+
+func TestGeoAgg(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	counts, err := c.GEOAGG("india", 0, GLocation{12, 78}, GRadius{Radius: 1000, Unit: Kilometers})
+	assert.NoError(t, err)
+	assert.Len(t, counts, 1)
+	assert.Equal(t, int32(6), counts[0].Count)
+	assert.Equal(t, 0, counts[0].CellID.Level())
+
+	counts, err = c.GEOAGG("india", 8, GLocation{13.09, 80.28}, GRadius{Radius: 400, Unit: Kilometers})
+	assert.NoError(t, err)
+
+	total := int32(0)
+	for i, count := range counts {
+		total += count.Count
+		assert.Equal(t, 8, count.CellID.Level())
+
+		if i > 0 {
+			assert.True(t, counts[i-1].CellID < count.CellID)
+		}
+	}
+
+	assert.Equal(t, int32(4), total)
+}
+
+func TestGeoSearchByMember(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	results, err := c.GEORADIUSBYMEMBERWITHOPTIONS("india", "chennai", 130, Kilometers, GQueryOptions{Sort: GAscending})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chennai", "vellore"}, resultMembers(results))
+
+	results, err = c.GEORADIUSBYMEMBERWITHOPTIONS("india", "chennai", 130, Kilometers, GQueryOptions{ExcludeMember: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vellore"}, resultMembers(results))
+
+	results, err = c.GEOSEARCHBYMEMBER("india", "chennai", GRadius{Radius: 150, Unit: Kilometers}, GQueryOptions{Count: 1, ExcludeMember: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vellore"}, resultMembers(results))
+
+	_, err = c.GEOSEARCHBYMEMBER("india", "mumbai", GRadius{Radius: 150, Unit: Kilometers}, GQueryOptions{})
+	assert.Equal(t, ErrMemberNotFound, err)
+
+	_, err = c.GEORADIUSBYMEMBER("india", "mumbai", 150, Kilometers, 10)
+	assert.Equal(t, ErrMemberNotFound, err)
+}
+
+func TestGeoSearchPages(t *testing.T) {
+	c := newClient(t)
+	addIndianCities(t, c)
+
+	var members []string
+
+	err := c.GEORADIUSPAGES(context.Background(), "india", GLocation{13.09, 80.28}, 400, Kilometers, 1, func(page []GResult) bool {
+		assert.Len(t, page, 1)
+		members = append(members, resultMembers(page)...)
+
+		return true
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"chennai", "vellore", "pondy", "bangalore"}, members)
+
+	pages := 0
+	err = c.GEOSEARCHPAGES(context.Background(), "india", GLocation{13.09, 80.28}, GBox{Width: 1000, Height: 1000, Unit: Kilometers}, 1, func(page []GResult) bool {
+		pages++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = c.GEOSEARCHPAGES(ctx, "india", GLocation{13.09, 80.28}, GBox{Width: 1000, Height: 1000, Unit: Kilometers}, 1, func(page []GResult) bool {
+		return true
+	})
+	assert.Equal(t, context.Canceled, err)
+}
@@ -1,6 +1,7 @@
 package redimo
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -17,6 +18,41 @@ func TestPointsAndDistances(t *testing.T) {
 	assert.Equal(t, "1376383545825912065", l.toAV().(*types.AttributeValueMemberN).Value)
 
 	assert.InDelta(t, 32.8084, Meters.To(Feet, 10), 0.01)
+	assert.InDelta(t, 1, Kilometers.To(NauticalMiles, 1.852), 0.0001)
+	assert.InDelta(t, 1760, Miles.To(Yards, 1), 0.01)
+}
+
+func TestBearings(t *testing.T) {
+	origin := GLocation{0, 0}
+
+	assert.InDelta(t, 0, origin.BearingTo(GLocation{1, 0}), 0.0001)
+	assert.InDelta(t, 90, origin.BearingTo(GLocation{0, 1}), 0.0001)
+	assert.InDelta(t, 180, origin.BearingTo(GLocation{-1, 0}), 0.0001)
+	assert.InDelta(t, 270, origin.BearingTo(GLocation{0, -1}), 0.0001)
+
+	palermo := GLocation{38.115556, 13.361389}
+	catania := GLocation{37.502669, 15.087269}
+	assert.InDelta(t, 113.67, palermo.BearingTo(catania), 0.01)
+}
+
+func TestGeoUnitRegistry(t *testing.T) {
+	unit, found := GUnitByName("nmi")
+	assert.True(t, found)
+	assert.Equal(t, NauticalMiles, unit)
+
+	_, found = GUnitByName("furlong")
+	assert.False(t, found)
+
+	furlongs, err := RegisterGUnit("furlong", 201.168)
+	assert.NoError(t, err)
+	assert.InDelta(t, 8, Miles.To(furlongs, 1), 0.001)
+
+	unit, found = GUnitByName("furlong")
+	assert.True(t, found)
+	assert.Equal(t, furlongs, unit)
+
+	_, err = RegisterGUnit("nothing", 0)
+	assert.Equal(t, ErrInvalidUnit, err)
 }
 
 func TestGeoBasics(t *testing.T) {
@@ -62,6 +98,16 @@ func TestGeoBasics(t *testing.T) {
 	assert.True(t, ok)
 	assert.InDelta(t, 103.3182, distance, 0.01)
 
+	distance, bearing, ok, err := c.GEOAZIMUTH("Sicily", "Palermo", "Catania", Kilometers)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.InDelta(t, 166.2742, distance, 0.01)
+	assert.InDelta(t, 113.67, bearing, 0.01)
+
+	_, _, ok, err = c.GEOAZIMUTH("Sicily", "Palermo", "Syracuse", Kilometers)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
 	positions, err := c.GEOPOS("Sicily", "Palermo", "Catania")
 	assert.NoError(t, err)
 	assert.InDelta(t, startingMap["Palermo"].Lat, positions["Palermo"].Lat, 0.1)
@@ -90,4 +136,136 @@ func TestGeoRadius(t *testing.T) {
 	locations2, err := c.GEORADIUSBYMEMBER("india", "chennai", 180, Kilometers, 10)
 	assert.NoError(t, err)
 	assert.Equal(t, locations, locations2)
+
+	locations, err = c.GEORADIUS("india", GLocation{13.09, 80.28}, 180, Kilometers, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(locations))
+	assert.Contains(t, locations, "chennai")
+	assert.Contains(t, locations, "vellore")
+
+	locations, err = c.GEORADIUS("india", GLocation{13.09, 80.28}, 1000, Kilometers, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(locations))
+
+	locations, err = c.GEORADIUS("india", GLocation{13.09, 80.28}, 1000, Kilometers, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, locations)
+
+	locations, err = c.GEORADIUSANY("india", GLocation{13.09, 80.28}, 1000, Kilometers, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(locations))
+
+	locations, err = c.GEORADIUSBYMEMBERANY("india", "chennai", 180, Kilometers, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(locations))
+
+	_, err = c.GEORADIUSBYMEMBERANY("india", "nowhere", 180, Kilometers, 1)
+	assert.Equal(t, ErrMemberNotFound, err)
+}
+
+func TestGeoAddCounts(t *testing.T) {
+	c := newClient(t)
+
+	added, updated, err := c.GEOADDCOUNTS("Sicily", map[string]GLocation{
+		"Palermo": {38.115556, 13.361389},
+	}, Flags{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), added)
+	assert.Equal(t, int32(0), updated)
+
+	added, updated, err = c.GEOADDCOUNTS("Sicily", map[string]GLocation{
+		"Palermo": {38.2, 13.4},
+		"Catania": {37.502669, 15.087269},
+	}, Flags{IfNotExists})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), added)
+	assert.Equal(t, int32(0), updated)
+
+	added, updated, err = c.GEOADDCOUNTS("Sicily", map[string]GLocation{
+		"Palermo":  {38.2, 13.4},
+		"Syracuse": {37.075474, 15.286586},
+	}, Flags{IfAlreadyExists})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), added)
+	assert.Equal(t, int32(1), updated)
+
+	positions, err := c.GEOPOS("Sicily", "Palermo", "Syracuse")
+	assert.NoError(t, err)
+	assert.Len(t, positions, 1)
+	assert.InDelta(t, 38.2, positions["Palermo"].Lat, 0.001)
+}
+
+func TestGeoDel(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.GEOADD("Sicily", map[string]GLocation{
+		"Palermo": {38.115556, 13.361389},
+		"Catania": {37.502669, 15.087269},
+	})
+	assert.NoError(t, err)
+
+	removed, err := c.GEODEL("Sicily", "Palermo", "Syracuse")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), removed)
+
+	positions, err := c.GEOPOS("Sicily", "Palermo", "Catania")
+	assert.NoError(t, err)
+	assert.Len(t, positions, 1)
+	assert.Contains(t, positions, "Catania")
+
+	count, err := c.ZCARD("Sicily")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+}
+
+func TestGeoAddBulk(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	members := make(map[string]GLocation)
+	for i := 0; i < 60; i++ {
+		members[fmt.Sprintf("poi%02d", i)] = GLocation{Lat: 13 + float64(i)/100, Lon: 80}
+	}
+
+	err := c.GEOADDBULK("pois", members, 3)
+	assert.NoError(t, err)
+
+	count, err := c.ZCARD("pois")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(60), count)
+
+	positions, err := c.GEOPOS("pois", "poi42")
+	assert.NoError(t, err)
+	assert.InDelta(t, 13.42, positions["poi42"].Lat, 0.001)
+}
+
+func TestGeoExactPositions(t *testing.T) {
+	c := newClient(t)
+	palermo := GLocation{38.115556, 13.361389}
+
+	_, err := c.GEOADD("Sicily", map[string]GLocation{"Palermo": palermo})
+	assert.NoError(t, err)
+
+	positions, err := c.GEOPOS("Sicily", "Palermo")
+	assert.NoError(t, err)
+	assert.Equal(t, palermo, positions["Palermo"])
+
+}
+
+func TestGeoExactLocation(t *testing.T) {
+	c := Client{sortKeyNum: "skN"}
+	palermo := GLocation{38.115556, 13.361389}
+	catania := GLocation{37.502669, 15.087269}
+
+	item := map[string]types.AttributeValue{c.sortKeyNum: palermo.toAV()}
+	assert.InDelta(t, palermo.Lat, c.geoExactLocation(item).Lat, 0.000001)
+	assert.NotEqual(t, palermo, c.geoExactLocation(item))
+
+	for name, value := range palermo.attributes() {
+		item[name] = value.ToAV()
+	}
+
+	assert.Equal(t, palermo, c.geoExactLocation(item))
+
+	item[c.sortKeyNum] = catania.toAV()
+	assert.InDelta(t, catania.Lon, c.geoExactLocation(item).Lon, 0.000001)
 }
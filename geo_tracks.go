@@ -0,0 +1,130 @@
+package redimo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// GMove is an entry in the movement log of a geo member: the location the member was at, and the time it was moved
+// away from there with GEOMOVE.
+type GMove struct {
+	Location GLocation
+	Time     time.Time
+}
+
+// geoTrackKey holds the movement log of a member, with an item per move sorted by the time of the move.
+func geoTrackKey(key string, member string) string {
+	return fmt.Sprintf("_redimo/%v/track/%v", key, member)
+}
+
+// geoTrackSortKey pads the time of a move so that the log sorts in time order.
+func geoTrackSortKey(t time.Time) string {
+	return fmt.Sprintf("%020d", t.UnixNano())
+}
+
+// GEOMOVE sets the location of a member of the geo key, adding the member if it doesn't exist yet. If the member
+// existed, its previous location is returned and existed is true.
+//
+// With record, the previous location is appended to the movement log of the member along with the time of the move,
+// and can be read back with GEOTRACK. The log entry is written after the member has been moved, so if an error is
+// returned the member may have moved without the entry being recorded. The log is kept apart from the geo key, so
+// GEODEL does not remove it.
+//
+// Cost is O(1) / 1 WCU, plus 1 WCU for the log entry.
+func (c Client) GEOMOVE(key string, member string, location GLocation, record bool) (previous GLocation, existed bool, err error) {
+	builder := newExpresionBuilder()
+	builder.updateSetAV(c.sortKeyNum, gCell{location}.ToAV())
+	builder.updateTTL(Flags{})
+
+	for name, value := range location.attributes() {
+		builder.updateSET(name, value)
+	}
+
+	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       keyDef{pk: key, sk: member}.toAV(c),
+		ReturnValues:              types.ReturnValueAllOld,
+		TableName:                 aws.String(c.tableName),
+		UpdateExpression:          builder.updateExpression(),
+	})
+	if err != nil {
+		return
+	}
+
+	if _, ok := resp.Attributes[c.sortKeyNum].(*types.AttributeValueMemberN); !ok {
+		err = c.adjustCardinality(key, 1)
+		return
+	}
+
+	previous, existed = c.geoExactLocation(resp.Attributes), true
+
+	if record {
+		item := keyDef{pk: geoTrackKey(key, member), sk: geoTrackSortKey(time.Now())}.toAV(c)
+		for name, value := range previous.attributes() {
+			item[name] = value.ToAV()
+		}
+
+		_, err = c.ddbClient.PutItem(context.TODO(), &dynamodb.PutItemInput{
+			Item:      item,
+			TableName: aws.String(c.tableName),
+		})
+	}
+
+	return
+}
+
+// GEOTRACK returns the movement log of a member recorded by GEOMOVE, most recent move first. At most count moves
+// are returned, or all of them if count is AllResults.
+//
+// Cost is O(N) / 1 RCU per 4 KB of moves read.
+func (c Client) GEOTRACK(key string, member string, count int32) (moves []GMove, err error) {
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{geoTrackKey(key, member)})
+
+	var cursor map[string]types.AttributeValue
+
+	for hasMoreResults := true; hasMoreResults; {
+		var limit *int32
+		if count > 0 {
+			limit = aws.Int32(count - int32(len(moves)))
+		}
+
+		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+			ConsistentRead:            aws.Bool(c.consistentReads),
+			ExclusiveStartKey:         cursor,
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			KeyConditionExpression:    builder.conditionExpression(),
+			Limit:                     limit,
+			ScanIndexForward:          aws.Bool(false),
+			TableName:                 aws.String(c.tableName),
+		})
+		if err != nil {
+			return moves, err
+		}
+
+		for _, item := range resp.Items {
+			nanos, err := strconv.ParseInt(parseKey(item, c).sk, 10, 64)
+			if err != nil {
+				return moves, err
+			}
+
+			moves = append(moves, GMove{
+				Location: GLocation{Lat: ReturnValue{item[geoLatKey]}.Float(), Lon: ReturnValue{item[geoLonKey]}.Float()},
+				Time:     time.Unix(0, nanos),
+			})
+		}
+
+		cursor = resp.LastEvaluatedKey
+		hasMoreResults = len(cursor) > 0 && (count <= 0 || int32(len(moves)) < count)
+	}
+
+	return
+}
@@ -0,0 +1,53 @@
+package redimo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoMove(t *testing.T) {
+	c := newClient(t)
+
+	chennai := GLocation{13.09, 80.28}
+	vellore := GLocation{12.9165, 79.1325}
+	bangalore := GLocation{12.9716, 77.5946}
+
+	_, existed, err := c.GEOMOVE("truck", "t1", chennai, true)
+	assert.NoError(t, err)
+	assert.False(t, existed)
+
+	previous, existed, err := c.GEOMOVE("truck", "t1", vellore, true)
+	assert.NoError(t, err)
+	assert.True(t, existed)
+	assert.InDelta(t, chennai.Lat, previous.Lat, 0.000001)
+	assert.InDelta(t, chennai.Lon, previous.Lon, 0.000001)
+
+	previous, existed, err = c.GEOMOVE("truck", "t1", bangalore, true)
+	assert.NoError(t, err)
+	assert.True(t, existed)
+	assert.InDelta(t, vellore.Lat, previous.Lat, 0.000001)
+
+	_, _, err = c.GEOMOVE("truck", "t1", chennai, false)
+	assert.NoError(t, err)
+
+	locations, err := c.GEOPOS("truck", "t1")
+	assert.NoError(t, err)
+	assert.InDelta(t, chennai.Lat, locations["t1"].Lat, 0.000001)
+
+	moves, err := c.GEOTRACK("truck", "t1", AllResults)
+	assert.NoError(t, err)
+	assert.Len(t, moves, 2)
+	assert.InDelta(t, vellore.Lat, moves[0].Location.Lat, 0.000001)
+	assert.InDelta(t, chennai.Lat, moves[1].Location.Lat, 0.000001)
+	assert.False(t, moves[0].Time.Before(moves[1].Time))
+
+	moves, err = c.GEOTRACK("truck", "t1", 1)
+	assert.NoError(t, err)
+	assert.Len(t, moves, 1)
+	assert.InDelta(t, vellore.Lon, moves[0].Location.Lon, 0.000001)
+
+	moves, err = c.GEOTRACK("truck", "t2", AllResults)
+	assert.NoError(t, err)
+	assert.Empty(t, moves)
+}
@@ -0,0 +1,114 @@
+package redimo
+
+import "strings"
+
+// globMatch reports whether s matches the glob-style pattern, with the same rules as the MATCH option of the Redis
+// SCAN commands: * matches any sequence of characters, ? matches any single character, [abc], [a-z] and [^a] match
+// one character from (or not from) a class, and \ escapes the character that follows it. Like Redis, patterns are
+// matched byte by byte.
+func globMatch(pattern string, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+
+			matched, rest := globClass(pattern[1:], s[0])
+			if !matched {
+				return false
+			}
+
+			pattern, s = rest, s[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+
+	return len(s) == 0
+}
+
+// globClass matches c against the character class at the start of pattern, just after the opening bracket, and
+// returns the rest of the pattern after the closing bracket. An unterminated class extends to the end of the pattern.
+func globClass(pattern string, c byte) (matched bool, rest string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			matched = matched || pattern[1] == c
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			low, high := pattern[0], pattern[2]
+			if low > high {
+				low, high = high, low
+			}
+
+			matched = matched || low <= c && c <= high
+			pattern = pattern[3:]
+		default:
+			matched = matched || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+
+	return matched != negate, pattern
+}
+
+// globPrefix returns the literal prefix that every string matching pattern starts with, so that scans can narrow
+// their query with begins_with before matching the rest.
+func globPrefix(pattern string) string {
+	var prefix strings.Builder
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return prefix.String()
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+
+		prefix.WriteByte(pattern[i])
+	}
+
+	return prefix.String()
+}
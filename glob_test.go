@@ -0,0 +1,45 @@
+package redimo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"", "", true},
+		{"*", "anything", true},
+		{"*", "", true},
+		{"user:*", "user:42", true},
+		{"user:*", "users:42", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "heeeello", true},
+		{"h*llo", "hell", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{"h[c-a]llo", "hbllo", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{`h[\]]llo`, "h]llo", true},
+		{"**a", "bba", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+	} {
+		assert.Equal(t, tc.match, globMatch(tc.pattern, tc.s), "%q ~ %q", tc.pattern, tc.s)
+	}
+
+	assert.Equal(t, "user:", globPrefix("user:*"))
+	assert.Equal(t, "", globPrefix("*"))
+	assert.Equal(t, "a*b", globPrefix(`a\*b?`))
+	assert.Equal(t, "plain", globPrefix("plain"))
+}
@@ -0,0 +1,341 @@
+package redimo
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// compactHashKey is the sort key of the item that holds a compact hash, with its fields in a single map attribute.
+const compactHashKey = "_redimo/hash"
+
+// The version of a compact hash makes every write conditional on the hash not having changed since it was read, and
+// the promotion time marks a hash that is being promoted to one item per field.
+const (
+	compactVersionKey   = "ver"
+	compactPromotingKey = "promoting"
+)
+
+// CompactHashMaxSize is the size in bytes beyond which a compact hash is promoted to one item per field, see
+// CompactHashes.
+const CompactHashMaxSize = 64 * 1024
+
+// compactPromotionTimeout is how long a promotion can take before another client takes it over.
+const compactPromotionTimeout = 30 * time.Second
+
+type compactHash struct {
+	fields    map[string]types.AttributeValue
+	version   int64
+	promoting int64
+}
+
+// compactHash reads the compact hash at key, if there is one.
+func (c Client) compactHash(key string) (h compactHash, found bool, err error) {
+	resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(c.consistentReads),
+		Key:            keyDef{pk: key, sk: compactHashKey}.toAV(c),
+		TableName:      aws.String(c.tableName),
+	})
+	if err != nil || len(resp.Item) == 0 {
+		return
+	}
+
+	h.fields = make(map[string]types.AttributeValue)
+	if m, ok := resp.Item[vk].(*types.AttributeValueMemberM); ok {
+		h.fields = m.Value
+	}
+
+	h.version = ReturnValue{resp.Item[compactVersionKey]}.Int()
+	h.promoting = ReturnValue{resp.Item[compactPromotingKey]}.Int()
+
+	return h, true, nil
+}
+
+// compactFields returns the fields of the hash at key if the client uses compact hashes and the hash is stored
+// compactly. While a hash is being promoted, its fields are still read from the compact item.
+func (c Client) compactFields(key string) (fields map[string]types.AttributeValue, ok bool, err error) {
+	if !c.compactHashes {
+		return
+	}
+
+	h, ok, err := c.compactHash(key)
+
+	return h.fields, ok, err
+}
+
+// updateCompactHash passes the fields of the compact hash at key to fn, which changes them in place and returns
+// whether it did, and writes them back on the condition that the hash hasn't changed in between, retrying according
+// to the RetryPolicy. A hash that doesn't exist yet is created as a compact hash, and one that grows beyond
+// CompactHashMaxSize is promoted.
+//
+// Returns false, without calling fn, if the client doesn't use compact hashes or the hash at key is stored with one
+// item per field, in which case the caller applies the write to the field items.
+func (c Client) updateCompactHash(key string, fn func(fields map[string]types.AttributeValue) (changed bool, err error)) (compact bool, err error) {
+	if !c.compactHashes {
+		return false, nil
+	}
+
+	c.consistentReads = true
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		compact = false
+
+		h, found, err := c.compactHash(key)
+		if err != nil {
+			return true, err
+		}
+
+		if found && h.promoting > 0 {
+			if time.Since(time.Unix(h.promoting, 0)) < compactPromotionTimeout {
+				return false, nil
+			}
+
+			err = c.promoteCompactHash(key, h, true, h.fields)
+			if conditionFailureError(err) {
+				return false, nil
+			}
+
+			return true, err
+		}
+
+		if !found {
+			exists, err := c.EXISTS(key)
+			if err != nil || exists {
+				return true, err
+			}
+		}
+
+		fields := make(map[string]types.AttributeValue, len(h.fields))
+		for field, av := range h.fields {
+			fields[field] = av
+		}
+
+		compact = true
+
+		changed, err := fn(fields)
+		if err != nil || !changed {
+			return true, err
+		}
+
+		if itemSize(compactHashItem(c, key, fields, h.version)) > CompactHashMaxSize {
+			err = c.promoteCompactHash(key, h, found, fields)
+		} else {
+			err = c.putCompactHash(key, h, found, fields, 0)
+		}
+
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		return true, err
+	})
+
+	return
+}
+
+// promoteHash promotes the hash at key to one item per field if it is stored compactly, so that it can be changed
+// in ways compact hashes don't support, like per-field TTLs.
+func (c Client) promoteHash(key string) error {
+	if !c.compactHashes {
+		return nil
+	}
+
+	c.consistentReads = true
+
+	return c.retryPolicy.retry(func() (done bool, err error) {
+		h, found, err := c.compactHash(key)
+		if err != nil || !found {
+			return true, err
+		}
+
+		if h.promoting > 0 && time.Since(time.Unix(h.promoting, 0)) < compactPromotionTimeout {
+			return false, nil
+		}
+
+		err = c.promoteCompactHash(key, h, true, h.fields)
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		return true, err
+	})
+}
+
+// promoteCompactHash moves the fields of the compact hash at key into one item each. The compact item is first
+// marked as being promoted, which stops compact writes while the items are written, and deleted afterwards. If the
+// promotion doesn't finish within compactPromotionTimeout, the next write takes it over.
+func (c Client) promoteCompactHash(key string, h compactHash, found bool, fields map[string]types.AttributeValue) error {
+	if err := c.putCompactHash(key, h, found, fields, time.Now().Unix()); err != nil {
+		return err
+	}
+
+	requests := make([]types.WriteRequest, 0, len(fields))
+
+	for field, av := range fields {
+		item, err := c.valueItem(key, field, ReturnValue{av})
+		if err != nil {
+			return err
+		}
+
+		requests = append(requests, putRequest(item))
+	}
+
+	if err := c.batchWrite(requests); err != nil {
+		return err
+	}
+
+	if err := c.setCardinality(key, int32(len(fields))); err != nil {
+		return err
+	}
+
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(compactVersionKey, IntValue{h.version + 1})
+
+	_, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		ConditionExpression:       builder.conditionExpression(),
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       keyDef{pk: key, sk: compactHashKey}.toAV(c),
+		TableName:                 aws.String(c.tableName),
+	})
+
+	return err
+}
+
+// putCompactHash writes the fields of the compact hash at key on the condition that its version is still the one in
+// h, or that it doesn't exist if it wasn't found. A compact hash without fields is deleted, unless it's being
+// promoted.
+func (c Client) putCompactHash(key string, h compactHash, found bool, fields map[string]types.AttributeValue, promoting int64) (err error) {
+	builder := newExpresionBuilder()
+
+	if found {
+		builder.addConditionEquality(compactVersionKey, IntValue{h.version})
+	} else {
+		builder.addConditionNotExists(c.partitionKey)
+	}
+
+	if len(fields) == 0 && promoting == 0 {
+		if !found {
+			return nil
+		}
+
+		_, err = c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+			ConditionExpression:       builder.conditionExpression(),
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			Key:                       keyDef{pk: key, sk: compactHashKey}.toAV(c),
+			TableName:                 aws.String(c.tableName),
+		})
+
+		return
+	}
+
+	item := compactHashItem(c, key, fields, h.version+1)
+	if promoting > 0 {
+		item[compactPromotingKey] = IntValue{promoting}.ToAV()
+	}
+
+	_, err = c.ddbClient.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		ConditionExpression:       builder.conditionExpression(),
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Item:                      item,
+		TableName:                 aws.String(c.tableName),
+	})
+
+	return
+}
+
+func compactHashItem(c Client, key string, fields map[string]types.AttributeValue, version int64) map[string]types.AttributeValue {
+	item := keyDef{pk: key, sk: compactHashKey}.toAV(c)
+	item[vk] = &types.AttributeValueMemberM{Value: fields}
+	item[compactVersionKey] = IntValue{version}.ToAV()
+
+	return item
+}
+
+// compactFieldNames returns the fields of a compact hash that start with prefix, in order.
+func compactFieldNames(fields map[string]types.AttributeValue, prefix string) []string {
+	names := make([]string, 0, len(fields))
+
+	for field := range fields {
+		if strings.HasPrefix(field, prefix) {
+			names = append(names, field)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// compactPages calls fn with the fields of a compact hash in order, pageSize fields at a time, or all at once if
+// pageSize is zero, like HGETALLPAGES.
+func compactPages(ctx context.Context, fields map[string]types.AttributeValue, pageSize int, fn func(page map[string]ReturnValue) bool) error {
+	names := compactFieldNames(fields, "")
+
+	for len(names) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size := len(names)
+		if pageSize > 0 && pageSize < size {
+			size = pageSize
+		}
+
+		page := make(map[string]ReturnValue, size)
+		for _, field := range names[:size] {
+			page[field] = ReturnValue{fields[field]}
+		}
+
+		if !fn(page) {
+			return nil
+		}
+
+		names = names[size:]
+	}
+
+	return nil
+}
+
+// addNumbers adds delta to a number in a compact hash, or to zero if there is none, in exact decimal arithmetic like
+// DynamoDB's ADD. Values that aren't numbers return an error wrapping ErrWrongType.
+func addNumbers(current types.AttributeValue, delta types.AttributeValue) (types.AttributeValue, error) {
+	if current == nil {
+		return delta, nil
+	}
+
+	a, ok := current.(*types.AttributeValueMemberN)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T is not a number", ErrWrongType, current)
+	}
+
+	x, okX := new(big.Rat).SetString(a.Value)
+	y, okY := new(big.Rat).SetString(delta.(*types.AttributeValueMemberN).Value)
+
+	if !okX || !okY {
+		return nil, fmt.Errorf("%w: %q is not a number", ErrWrongType, a.Value)
+	}
+
+	sum := x.Add(x, y)
+	if sum.IsInt() {
+		return &types.AttributeValueMemberN{Value: sum.Num().String()}, nil
+	}
+
+	// The denominator of a sum of decimals divides a power of ten, which gives the number of digits to print.
+	digits, ten := 0, big.NewInt(1)
+	for new(big.Int).Mod(ten, sum.Denom()).Sign() != 0 {
+		ten.Mul(ten, big.NewInt(10))
+		digits++
+	}
+
+	return &types.AttributeValueMemberN{Value: sum.FloatString(digits)}, nil
+}
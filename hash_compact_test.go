@@ -0,0 +1,173 @@
+package redimo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddNumbers(t *testing.T) {
+	sum, err := addNumbers(nil, IntValue{5}.ToAV())
+	assert.NoError(t, err)
+	assert.Equal(t, "5", ReturnValue{sum}.ToAV().(*types.AttributeValueMemberN).Value)
+
+	sum, err = addNumbers(IntValue{5}.ToAV(), IntValue{-7}.ToAV())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-2), ReturnValue{sum}.Int())
+
+	sum = FloatValue{0}.ToAV()
+	for i := 0; i < 10; i++ {
+		sum, err = addNumbers(sum, FloatValue{0.1}.ToAV())
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, "1", sum.(*types.AttributeValueMemberN).Value)
+
+	sum, err = addNumbers(FloatValue{1.25}.ToAV(), FloatValue{0.5}.ToAV())
+	assert.NoError(t, err)
+	assert.Equal(t, "1.75", sum.(*types.AttributeValueMemberN).Value)
+
+	_, err = addNumbers(StringValue{"x"}.ToAV(), IntValue{1}.ToAV())
+	assert.True(t, errors.Is(err, ErrWrongType))
+}
+
+func TestCompactHashes(t *testing.T) {
+	c := newClient(t).CompactHashes()
+
+	newFields, err := c.HSET("h", map[string]Value{"a": StringValue{"1"}, "b": IntValue{2}})
+	assert.NoError(t, err)
+	assert.Len(t, newFields, 2)
+
+	compact, found, err := c.compactHash("h")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Len(t, compact.fields, 2)
+
+	newFields, err = c.HSET("h", "a", StringValue{"one"})
+	assert.NoError(t, err)
+	assert.Empty(t, newFields)
+
+	ok, err := c.HSETNX("h", "a", StringValue{"ignored"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.HSETNX("h", "c", StringValue{"3"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	after, err := c.HINCRBY("h", "b", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), after)
+
+	afterFloat, err := c.HINCRBYFLOAT("h", "f", 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, afterFloat)
+
+	val, err := c.HGET("h", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", val.String())
+
+	exists, err := c.HEXISTS("h", "c")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	all, err := c.HGETALL("h")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ReturnValue{
+		"a": {StringValue{"one"}.ToAV()},
+		"b": {IntValue{7}.ToAV()},
+		"c": {StringValue{"3"}.ToAV()},
+		"f": {FloatValue{0.5}.ToAV()},
+	}, all)
+
+	keys, err := c.HKEYS("h", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "f"}, keys)
+
+	values, err := c.HVALS("h")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", values[0].String())
+
+	count, err := c.HLEN("h")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), count)
+
+	deleted, err := c.HDEL("h", "c", "f", "nosuchfield")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"c", "f"}, deleted)
+
+	var pages int
+
+	err = c.HGETALLPAGES(context.Background(), "h", 1, func(page map[string]ReturnValue) bool {
+		pages++
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pages)
+
+	deleted, err = c.HDEL("h", "a", "b")
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 2)
+
+	exists, err = c.EXISTS("h")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCompactHashPromotion(t *testing.T) {
+	c := newClient(t).CompactHashes().CountedCardinality()
+
+	_, err := c.HSET("h", "small", StringValue{"value"})
+	assert.NoError(t, err)
+
+	big := strings.Repeat("x", 1024)
+	fieldMap := make(map[string]Value)
+
+	for i := 0; i < 100; i++ {
+		fieldMap[fmt.Sprintf("f%03d", i)] = StringValue{big}
+	}
+
+	newFields, err := c.HSET("h", fieldMap)
+	assert.NoError(t, err)
+	assert.Len(t, newFields, 100)
+
+	_, found, err := c.compactHash("h")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	count, err := c.HLEN("h")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(101), count)
+
+	val, err := c.HGET("h", "small")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val.String())
+
+	_, err = c.HSET("h", "small", StringValue{"changed"})
+	assert.NoError(t, err)
+
+	val, err = c.HGET("h", "small")
+	assert.NoError(t, err)
+	assert.Equal(t, "changed", val.String())
+
+	_, err = c.HSET("h2", "a", StringValue{"1"})
+	assert.NoError(t, err)
+
+	updated, err := c.HEXPIRE("h2", time.Hour, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, updated)
+
+	_, found, err = c.compactHash("h2")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	ttls, err := c.HTTL("h2", "a")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), ttls["a"].Seconds(), 5)
+}
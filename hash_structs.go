@@ -0,0 +1,229 @@
+package redimo
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrInvalidStruct is returned by HSetStruct for values that aren't structs or pointers to structs, and by
+// HGetStruct for values that aren't non-nil pointers to structs.
+var ErrInvalidStruct = errors.New("value must be a struct or a pointer to one")
+
+// ValueUnmarshaler is implemented by types that decode themselves from the values read by HGetStruct. Types that
+// encode themselves for HSetStruct implement Value.
+type ValueUnmarshaler interface {
+	UnmarshalValue(rv ReturnValue) error
+}
+
+var (
+	timeType             = reflect.TypeOf(time.Time{})
+	valueType            = reflect.TypeOf((*Value)(nil)).Elem()
+	valueUnmarshalerType = reflect.TypeOf((*ValueUnmarshaler)(nil)).Elem()
+	textMarshalerType    = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields lists the exported fields of a struct type that map to hash fields. The hash field is named after
+// the struct field, or after the name in its redimo tag, and fields tagged with "-" are skipped. The fields of
+// embedded structs without a tag are included as if they were fields of the outer struct, like encoding/json does.
+func structFields(t reflect.Type) (fields []structField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("redimo")
+		if tag == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for _, embedded := range structFields(f.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		field := structField{name: name, index: []int{i}}
+
+		for _, option := range parts[1:] {
+			if option == "omitempty" {
+				field.omitEmpty = true
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return
+}
+
+// HSetStruct stores the exported fields of the struct v, or the struct v points to, as fields of the hash at key,
+// and returns the hash fields that were newly created, like HSET. Fields are named after the struct fields, or
+// after the name in their redimo tag:
+//
+//	type Profile struct {
+//		Name     string    `redimo:"name"`
+//		Born     time.Time `redimo:"born,omitempty"`
+//		Internal string    `redimo:"-"`
+//	}
+//
+// Fields tagged with omitempty are skipped when they hold their zero value, and so are nil pointers. Values are
+// converted like ToValueE, so numbers are stored as numbers and times as sortable strings. Fields of types that
+// implement Value or encoding.TextMarshaler encode themselves, and structs, slices and maps are stored as JSON.
+//
+// The fields are written like HSET, so they are only written all-or-nothing with AtomicHashes.
+func (c Client) HSetStruct(key string, v interface{}) (newlySavedFields map[string]Value, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrInvalidStruct
+	}
+
+	fieldMap := make(map[string]Value)
+
+	for _, field := range structFields(rv.Type()) {
+		fv := rv.FieldByIndex(field.index)
+		if fv.Kind() == reflect.Ptr && fv.IsNil() || field.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		if fieldMap[field.name], err = structValue(fv); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fieldMap) == 0 {
+		return map[string]Value{}, nil
+	}
+
+	return c.HSET(key, fieldMap)
+}
+
+// HGetStruct reads the fields of the hash at key into the struct v points to, mapping them like HSetStruct, and
+// returns whether any of them was found. Struct fields whose hash field doesn't exist are left as they are. Only
+// the hash fields the struct maps are read, with a single BatchGetItem (see HMGETORDERED).
+//
+// Fields of types that implement ValueUnmarshaler or encoding.TextUnmarshaler decode themselves.
+func (c Client) HGetStruct(key string, v interface{}) (found bool, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return false, ErrInvalidStruct
+	}
+
+	rv = rv.Elem()
+	fields := structFields(rv.Type())
+
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.name
+	}
+
+	values, fieldsFound, err := c.HMGETORDERED(key, names...)
+	if err != nil {
+		return
+	}
+
+	for i, field := range fields {
+		if !fieldsFound[i] {
+			continue
+		}
+
+		found = true
+
+		if err = setStructValue(rv.FieldByIndex(field.index), values[i]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// structValue converts a struct field into the Value stored for it.
+func structValue(fv reflect.Value) (Value, error) {
+	if fv.Kind() == reflect.Ptr {
+		fv = fv.Elem()
+	}
+
+	switch {
+	case fv.Type().Implements(valueType):
+		return fv.Interface().(Value), nil
+	case fv.Type() == timeType:
+		return TimeValue{fv.Interface().(time.Time)}, nil
+	case fv.Type().Implements(textMarshalerType):
+		text, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return StringValue{string(text)}, err
+	}
+
+	if value, err := reflectValue(fv.Interface()); err == nil {
+		return value, nil
+	}
+
+	return ToJSONValue(fv.Interface())
+}
+
+// setStructValue decodes a value read from a hash into a struct field.
+func setStructValue(fv reflect.Value, value ReturnValue) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+
+		fv = fv.Elem()
+	}
+
+	switch {
+	case reflect.PtrTo(fv.Type()).Implements(valueUnmarshalerType):
+		return fv.Addr().Interface().(ValueUnmarshaler).UnmarshalValue(value)
+	case fv.Type() == timeType:
+		fv.Set(reflect.ValueOf(value.Time()))
+		return nil
+	case reflect.PtrTo(fv.Type()).Implements(textUnmarshalerType):
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value.String()))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value.String())
+	case reflect.Bool:
+		fv.SetBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fv.SetInt(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(uint64(value.Int()))
+	case reflect.Float32, reflect.Float64:
+		fv.SetFloat(value.Float())
+	default:
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			fv.SetBytes(value.Bytes())
+			return nil
+		}
+
+		return value.JSON(fv.Addr().Interface())
+	}
+
+	return nil
+}
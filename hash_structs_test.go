@@ -0,0 +1,111 @@
+package redimo
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type upperName string
+
+func (n upperName) ToAV() types.AttributeValue {
+	return StringValue{strings.ToUpper(string(n))}.ToAV()
+}
+
+func (n *upperName) UnmarshalValue(rv ReturnValue) error {
+	*n = upperName(strings.ToLower(rv.String()))
+	return nil
+}
+
+type Audit struct {
+	CreatedBy string `redimo:"created_by"`
+}
+
+type profile struct {
+	Audit
+	Name     upperName         `redimo:"name"`
+	Age      int               `redimo:"age"`
+	Score    float64           `redimo:"score,omitempty"`
+	Admin    bool              `redimo:"admin"`
+	Born     time.Time         `redimo:"born"`
+	IP       net.IP            `redimo:"ip"`
+	Avatar   []byte            `redimo:"avatar,omitempty"`
+	Tags     []string          `redimo:"tags"`
+	Settings map[string]string `redimo:"settings"`
+	Nickname *string           `redimo:"nickname"`
+	Visits   uint32
+	Internal string `redimo:"-"`
+	private  string
+}
+
+func TestStructFields(t *testing.T) {
+	names := []string{}
+	for _, field := range structFields(reflect.TypeOf(profile{})) {
+		names = append(names, field.name)
+	}
+
+	assert.Equal(t, []string{
+		"created_by", "name", "age", "score", "admin", "born", "ip", "avatar", "tags", "settings", "nickname", "Visits",
+	}, names)
+
+	value, err := structValue(reflect.ValueOf(net.ParseIP("10.0.0.1")))
+	assert.NoError(t, err)
+	assert.Equal(t, StringValue{"10.0.0.1"}, value)
+
+	value, err = structValue(reflect.ValueOf([]string{"a", "b"}))
+	assert.NoError(t, err)
+	assert.Equal(t, StringValue{`["a","b"]`}, value)
+}
+
+func TestHashStructs(t *testing.T) {
+	c := newClient(t)
+	nickname := "rob"
+	saved := profile{
+		Audit:    Audit{CreatedBy: "admin"},
+		Name:     "robert",
+		Age:      42,
+		Admin:    true,
+		Born:     time.Date(1980, 4, 1, 12, 0, 0, 0, time.UTC),
+		IP:       net.ParseIP("10.0.0.1"),
+		Tags:     []string{"a", "b"},
+		Settings: map[string]string{"theme": "dark"},
+		Nickname: &nickname,
+		Visits:   7,
+		Internal: "secret",
+	}
+
+	newFields, err := c.HSetStruct("p1", &saved)
+	assert.NoError(t, err)
+	assert.Len(t, newFields, 11)
+	assert.NotContains(t, newFields, "score")
+	assert.NotContains(t, newFields, "avatar")
+
+	name, err := c.HGET("p1", "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "ROBERT", name.String())
+
+	var loaded profile
+	found, err := c.HGetStruct("p1", &loaded)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	saved.Internal = ""
+	assert.True(t, saved.Born.Equal(loaded.Born))
+	loaded.Born = saved.Born
+	assert.Equal(t, saved, loaded)
+
+	found, err = c.HGetStruct("nosuchkey", &loaded)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = c.HSetStruct("p1", "not a struct")
+	assert.Equal(t, ErrInvalidStruct, err)
+
+	_, err = c.HGetStruct("p1", loaded)
+	assert.Equal(t, ErrInvalidStruct, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -16,22 +17,74 @@ var (
 )
 
 func (c Client) HGET(key string, field string) (val ReturnValue, err error) {
+	if fields, ok, err := c.compactFields(key); err != nil || ok {
+		return ReturnValue{fields[field]}, err
+	}
+
+	projection, names := valueProjection(ttlKey)
+
 	resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
 		ConsistentRead: aws.Bool(c.consistentReads),
 		Key: keyDef{
 			pk: key,
 			sk: field,
 		}.toAV(c),
-		ProjectionExpression: aws.String(strings.Join([]string{vk}, ", ")),
-		TableName:            aws.String(c.tableName),
+		ExpressionAttributeNames: names,
+		ProjectionExpression:     projection,
+		TableName:                aws.String(c.tableName),
 	})
-	if err == nil {
-		val = parseItem(resp.Item, c).val
+	if err == nil && !itemExpired(resp.Item) {
+		val, err = c.decodeValue(resp.Item)
 	}
 
 	return
 }
 
+// HGetInt returns the value of field in the hash at key as an integer, and whether the field exists. Fields set
+// with IntValue or numeric strings can be read, other values return an error wrapping ErrWrongType.
+//
+// Cost is O(1) / 1 RCU like HGET.
+func (c Client) HGetInt(key string, field string) (value int64, found bool, err error) {
+	val, err := c.HGET(key, field)
+	if err != nil || val.Empty() {
+		return
+	}
+
+	value, err = val.IntE()
+
+	return value, true, err
+}
+
+// HGetFloat returns the value of field in the hash at key as a float, and whether the field exists. Fields set with
+// FloatValue, IntValue or numeric strings can be read, other values return an error wrapping ErrWrongType.
+//
+// Cost is O(1) / 1 RCU like HGET.
+func (c Client) HGetFloat(key string, field string) (value float64, found bool, err error) {
+	val, err := c.HGET(key, field)
+	if err != nil || val.Empty() {
+		return
+	}
+
+	value, err = val.FloatE()
+
+	return value, true, err
+}
+
+// HGetBytes returns the value of field in the hash at key as a byte slice, and whether the field exists. Fields set
+// with BytesValue or StringValue can be read, other values return an error wrapping ErrWrongType.
+//
+// Cost is O(1) / 1 RCU like HGET.
+func (c Client) HGetBytes(key string, field string) (value []byte, found bool, err error) {
+	val, err := c.HGET(key, field)
+	if err != nil || val.Empty() {
+		return
+	}
+
+	value, err = val.BytesE()
+
+	return value, true, err
+}
+
 func (c Client) HSET(key string, values ...interface{}) (newlySavedFields map[string]Value, err error) {
 	var fieldMap = map[string]Value{}
 
@@ -59,11 +112,43 @@ func (c Client) HSET(key string, values ...interface{}) (newlySavedFields map[st
 		return newlySavedFields, ErrArgsAmountNotCorrect
 	}
 
+	compact, err := c.updateCompactHash(key, func(fields map[string]types.AttributeValue) (bool, error) {
+		newlySavedFields = make(map[string]Value)
+
+		for field, value := range fieldMap {
+			if _, ok := fields[field]; !ok {
+				newlySavedFields[field] = value
+			}
+
+			fields[field] = value.ToAV()
+		}
+
+		return true, nil
+	})
+	if err != nil || compact {
+		return newlySavedFields, err
+	}
+
+	if c.atomicHashes && len(fieldMap) > 1 {
+		return c.hsetAtomic(key, fieldMap)
+	}
+
 	newlySavedFields = make(map[string]Value)
+	created := 0
+
+	defer func() {
+		if cErr := c.adjustCardinality(key, created); err == nil {
+			err = cErr
+		}
+	}()
 
 	for field, value := range fieldMap {
 		builder := newExpresionBuilder()
-		builder.updateSetAV(vk, value.ToAV())
+		if err = c.updateValue(&builder, value); err != nil {
+			return newlySavedFields, err
+		}
+
+		builder.updateTTL(Flags{})
 
 		resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
 			ConditionExpression:       builder.conditionExpression(),
@@ -79,9 +164,90 @@ func (c Client) HSET(key string, values ...interface{}) (newlySavedFields map[st
 			return newlySavedFields, err
 		}
 
-		if len(resp.Attributes) < 1 {
+		if len(resp.Attributes) < 1 || itemExpired(resp.Attributes) {
 			newlySavedFields[field] = value
 		}
+
+		if len(resp.Attributes) < 1 {
+			created++
+		}
+	}
+
+	return
+}
+
+// hsetAtomic writes all the fields in a single transaction, on the condition that the fields that were found to be
+// new by a read just before still don't exist and the others still do, retrying according to the RetryPolicy.
+func (c Client) hsetAtomic(key string, fieldMap map[string]Value) (newlySavedFields map[string]Value, err error) {
+	if len(fieldMap) > c.transactionActions {
+		return nil, ErrTooManyKeys
+	}
+
+	fields := make([]string, 0, len(fieldMap))
+	keys := make([]keyDef, 0, len(fieldMap))
+
+	for field := range fieldMap {
+		fields = append(fields, field)
+		keys = append(keys, keyDef{pk: key, sk: field})
+	}
+
+	c.consistentReads = true
+	created := 0
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		existing, err := c.batchGet(keys, c.sortKey, ttlKey)
+		if err != nil {
+			return
+		}
+
+		found := make(map[string]bool, len(existing))
+		for _, item := range existing {
+			found[parseKey(item, c).sk] = !itemExpired(item)
+		}
+
+		newlySavedFields = make(map[string]Value)
+		items := make([]types.TransactWriteItem, len(fields))
+		created = len(fields) - len(existing)
+
+		for i, field := range fields {
+			builder := newExpresionBuilder()
+			if err = c.updateValue(&builder, fieldMap[field]); err != nil {
+				return true, err
+			}
+
+			builder.updateTTL(Flags{})
+
+			if found[field] {
+				builder.addConditionExistsUnexpired(c.partitionKey)
+			} else {
+				builder.addConditionNotExistsOrExpired(c.partitionKey)
+				newlySavedFields[field] = fieldMap[field]
+			}
+
+			items[i] = types.TransactWriteItem{
+				Update: &types.Update{
+					ConditionExpression:       builder.conditionExpression(),
+					ExpressionAttributeNames:  builder.expressionAttributeNames(),
+					ExpressionAttributeValues: builder.expressionAttributeValues(),
+					Key:                       keyDef{pk: key, sk: field}.toAV(c),
+					TableName:                 aws.String(c.tableName),
+					UpdateExpression:          builder.updateExpression(),
+				},
+			}
+		}
+
+		_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: items,
+		})
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		return err == nil, err
+	})
+
+	if err == nil {
+		err = c.adjustCardinality(key, created)
 	}
 
 	return
@@ -93,6 +259,13 @@ func (c Client) HMSET(key string, vFieldMap interface{}) (err error) {
 		return err
 	}
 
+	if c.compactHashes || c.counted(key) {
+		c.atomicHashes = false
+		_, err = c.HSET(key, fieldMap)
+
+		return err
+	}
+
 	var fields []string
 	for field := range fieldMap {
 		fields = append(fields, field)
@@ -114,7 +287,11 @@ func (c Client) HMSET(key string, vFieldMap interface{}) (err error) {
 		for i, field := range fields {
 			v := fieldMap[field]
 			builder := newExpresionBuilder()
-			builder.updateSET(vk, v)
+			if err = c.updateValue(&builder, v); err != nil {
+				return err
+			}
+
+			builder.updateTTL(Flags{})
 
 			items[i] = types.TransactWriteItem{
 				Update: &types.Update{
@@ -141,60 +318,164 @@ func (c Client) HMSET(key string, vFieldMap interface{}) (err error) {
 	return
 }
 
+// HMGET returns the values of the given fields of the hash at key, with an empty ReturnValue for the fields that
+// don't exist. See HMGETORDERED.
+//
+// Works similar to https://redis.io/commands/hmget
 func (c Client) HMGET(key string, fields ...string) (values map[string]ReturnValue, err error) {
+	ordered, _, err := c.HMGETORDERED(key, fields...)
+	values = make(map[string]ReturnValue, len(fields))
+
+	for i, value := range ordered {
+		values[fields[i]] = value
+	}
+
+	return
+}
+
+// HMGETORDERED returns the values of the given fields of the hash at key in the same order as the fields, along
+// with whether each field was found.
+//
+// Each field is a separate item, so the fields are fetched with BatchGetItem, 100 at a time, which costs half as
+// much as reading them in a transaction. The fields are read independently of each other, so a concurrent HSET of
+// several fields can be seen partially applied.
+//
+// With SnapshotHashes, the fields are read in a single TransactGetItems call instead, so they are all read at the
+// same point in time, for twice the cost.
+//
+// Cost is O(N) / 1 RCU per field of up to 4 KB (0.5 RCU with eventually consistent reads).
+//
+// Works similar to https://redis.io/commands/hmget
+func (c Client) HMGETORDERED(key string, fields ...string) (values []ReturnValue, found []bool, err error) {
+	values, found = make([]ReturnValue, len(fields)), make([]bool, len(fields))
 	if len(fields) == 0 {
-		return make(map[string]ReturnValue), nil
+		return
 	}
 
-	values = make(map[string]ReturnValue)
+	if compactFields, ok, err := c.compactFields(key); err != nil || ok {
+		for i, field := range fields {
+			values[i] = ReturnValue{compactFields[field]}
+			_, found[i] = compactFields[field]
+		}
 
-	var (
-		hasMoreFields = true
-		leftFields    = fields
-	)
+		return values, found, err
+	}
 
-	for hasMoreFields {
-		if len(leftFields) > c.transactionActions {
-			fields, hasMoreFields = leftFields[:c.transactionActions], true
-			leftFields = leftFields[c.transactionActions:]
-		} else {
-			fields, hasMoreFields = leftFields, false
+	keys := make([]keyDef, len(fields))
+	for i, field := range fields {
+		keys[i] = keyDef{pk: key, sk: field}
+	}
+
+	var items []map[string]types.AttributeValue
+
+	if c.snapshotHashes {
+		items, err = c.transactGetFields(key, fields, c.sortKey, vk, codecKey, ttlKey)
+	} else {
+		items, err = c.batchGet(keys, c.sortKey, vk, codecKey, ttlKey)
+	}
+
+	if err != nil {
+		return
+	}
+
+	itemsByField := make(map[string]map[string]types.AttributeValue, len(items))
+	for _, item := range items {
+		if !itemExpired(item) {
+			itemsByField[parseKey(item, c).sk] = item
 		}
+	}
 
-		items := make([]types.TransactGetItem, len(fields))
-		for i, field := range fields {
-			items[i] = types.TransactGetItem{Get: &types.Get{
-				Key: keyDef{
-					pk: key,
-					sk: field,
-				}.toAV(c),
-				ProjectionExpression: aws.String(strings.Join([]string{c.sortKey, vk}, ", ")),
-				TableName:            aws.String(c.tableName),
-			}}
+	for i, field := range fields {
+		item, ok := itemsByField[field]
+		if !ok {
+			continue
 		}
 
-		resp, err := c.ddbClient.TransactGetItems(context.TODO(), &dynamodb.TransactGetItemsInput{
-			TransactItems: items,
-		})
-		if err != nil {
-			return values, err
+		if values[i], err = c.decodeValue(item); err != nil {
+			return
 		}
 
-		for i, field := range fields {
-			pi := parseItem(resp.Responses[i].Item, c)
-			values[field] = pi.val
+		found[i] = true
+	}
+
+	return
+}
+
+// transactGetFields reads the items of the given fields in a single TransactGetItems call, so that they are all
+// read at the same point in time. Items that don't exist are left out.
+func (c Client) transactGetFields(key string, fields []string, projection ...string) (items []map[string]types.AttributeValue, err error) {
+	expression, names := projectionWithNames(projection)
+	seen := make(map[string]struct{}, len(fields))
+	gets := make([]types.TransactGetItem, 0, len(fields))
+
+	for _, field := range fields {
+		if _, ok := seen[field]; ok {
+			continue
+		}
+
+		seen[field] = struct{}{}
+		gets = append(gets, types.TransactGetItem{
+			Get: &types.Get{
+				ExpressionAttributeNames: names,
+				Key:                      keyDef{pk: key, sk: field}.toAV(c),
+				ProjectionExpression:     expression,
+				TableName:                aws.String(c.tableName),
+			},
+		})
+	}
+
+	if len(gets) > c.transactionActions {
+		return nil, ErrTooManyKeys
+	}
+
+	resp, err := c.ddbClient.TransactGetItems(context.TODO(), &dynamodb.TransactGetItemsInput{
+		TransactItems: gets,
+	})
+	if err != nil {
+		return
+	}
+
+	for _, response := range resp.Responses {
+		if len(response.Item) > 0 {
+			items = append(items, response.Item)
 		}
 	}
 
 	return
 }
 
+// HDEL deletes the given fields from the hash at key and returns the fields that existed.
+//
+// A single field is deleted with DeleteItem. Several fields are first looked up with BatchGetItem, and the ones
+// that exist are deleted with BatchWriteItem, 25 at a time with retries, which is much faster than deleting them
+// one by one. The lookup is what makes the returned fields accurate, but a field deleted by another client between
+// the lookup and the delete can be returned by both calls.
+//
+// Cost is O(N) / 1 WCU per field deleted, plus 1 RCU per field looked up when deleting several fields.
+//
+// Works similar to https://redis.io/commands/hdel
 func (c Client) HDEL(key string, fields ...string) (deletedFields []string, err error) {
-	for _, field := range fields {
+	compact, err := c.updateCompactHash(key, func(hash map[string]types.AttributeValue) (bool, error) {
+		deletedFields = nil
+
+		for _, field := range fields {
+			if _, ok := hash[field]; ok {
+				delete(hash, field)
+				deletedFields = append(deletedFields, field)
+			}
+		}
+
+		return len(deletedFields) > 0, nil
+	})
+	if err != nil || compact {
+		return deletedFields, err
+	}
+
+	if len(fields) == 1 {
 		resp, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
 			Key: keyDef{
 				pk: key,
-				sk: field,
+				sk: fields[0],
 			}.toAV(c),
 			ReturnValues: types.ReturnValueAllOld,
 			TableName:    aws.String(c.tableName),
@@ -203,69 +484,212 @@ func (c Client) HDEL(key string, fields ...string) (deletedFields []string, err
 			return deletedFields, err
 		}
 
+		if len(resp.Attributes) > 0 && !itemExpired(resp.Attributes) {
+			deletedFields = append(deletedFields, fields[0])
+		}
+
 		if len(resp.Attributes) > 0 {
-			deletedFields = append(deletedFields, field)
+			err = c.adjustCardinality(key, -1)
 		}
+
+		return deletedFields, err
+	}
+
+	keys := make([]keyDef, len(fields))
+	for i, field := range fields {
+		keys[i] = keyDef{pk: key, sk: field}
+	}
+
+	c.consistentReads = true
+
+	items, err := c.batchGet(keys, c.sortKey, ttlKey)
+	if err != nil {
+		return
+	}
+
+	existing := make(map[string]map[string]types.AttributeValue, len(items))
+	for _, item := range items {
+		existing[parseKey(item, c).sk] = item
 	}
 
+	var requests []types.WriteRequest
+
+	for _, field := range fields {
+		if item, ok := existing[field]; ok {
+			delete(existing, field)
+			requests = append(requests, deleteRequest(keyDef{pk: key, sk: field}.toAV(c)))
+
+			if !itemExpired(item) {
+				deletedFields = append(deletedFields, field)
+			}
+		}
+	}
+
+	if err = c.batchWrite(requests); err != nil {
+		return nil, err
+	}
+
+	err = c.adjustCardinality(key, -len(requests))
+
 	return
 }
 
 func (c Client) HEXISTS(key string, field string) (exists bool, err error) {
+	if fields, ok, err := c.compactFields(key); err != nil || ok {
+		_, exists = fields[field]
+		return exists, err
+	}
+
 	resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
 		ConsistentRead: aws.Bool(c.consistentReads),
 		Key: keyDef{
 			pk: key,
 			sk: field,
 		}.toAV(c),
-		ProjectionExpression: aws.String(strings.Join([]string{c.partitionKey}, ", ")),
-		TableName:            aws.String(c.tableName),
+		ExpressionAttributeNames: map[string]string{"#" + ttlKey: ttlKey},
+		ProjectionExpression:     aws.String(strings.Join([]string{c.partitionKey, "#" + ttlKey}, ", ")),
+		TableName:                aws.String(c.tableName),
 	})
-	if err == nil && len(resp.Item) > 0 {
+	if err == nil && len(resp.Item) > 0 && !itemExpired(resp.Item) {
 		exists = true
 	}
 
 	return
 }
 
+// HGETALL returns all the fields of the hash at key with their values. The fields are read page by page, see
+// HGETALLPAGES, so a hash that is written to concurrently can be returned with some writes applied and others not.
+//
+// With SnapshotHashes, the fields are listed first, and their values are then read in a single TransactGetItems
+// call, so the values are a consistent point-in-time snapshot. Fields added after they were listed are left out.
+//
+// Cost is O(N) / 1 RCU per 4 KB of fields read, plus 2 RCU per field of up to 4 KB with SnapshotHashes.
+//
+// Works similar to https://redis.io/commands/hgetall
 func (c Client) HGETALL(key string) (fieldValues map[string]ReturnValue, err error) {
 	fieldValues = make(map[string]ReturnValue)
-	hasMoreResults := true
+
+	if c.snapshotHashes {
+		return fieldValues, c.hgetallSnapshot(key, fieldValues)
+	}
+
+	err = c.HGETALLPAGES(context.TODO(), key, 0, func(page map[string]ReturnValue) bool {
+		for field, value := range page {
+			fieldValues[field] = value
+		}
+
+		return true
+	})
+
+	return
+}
+
+// hgetallSnapshot lists the fields of the hash at key and reads them in a single transaction into fieldValues. A
+// compact hash is a single item, so it is always read as a snapshot.
+func (c Client) hgetallSnapshot(key string, fieldValues map[string]ReturnValue) error {
+	compactFields, ok, err := c.compactFields(key)
+	if err != nil || ok {
+		for field, av := range compactFields {
+			fieldValues[field] = ReturnValue{av}
+		}
+
+		return err
+	}
+
+	c.compactHashes = false
+
+	fields, err := c.HKEYS(key, "")
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+
+	values, found, err := c.HMGETORDERED(key, fields...)
+
+	for i, field := range fields {
+		if found[i] {
+			fieldValues[field] = values[i]
+		}
+	}
+
+	return err
+}
+
+// HGETALLPAGES walks the fields of the hash at key in order, calling fn with the fields and values read by each
+// query of at most pageSize fields. Only one page is held in memory, so arbitrarily large hashes can be walked. With
+// a pageSize of zero, each page holds as many fields as DynamoDB returns for a query, up to 1 MB. The walk stops
+// early when fn returns false, and when ctx is done, in which case the context error is returned.
+//
+// Pages are read lazily, so fields that are written or deleted during the walk may or may not be seen.
+//
+// Cost is O(N) / 1 RCU per 4 KB of fields read, like HGETALL.
+func (c Client) HGETALLPAGES(ctx context.Context, key string, pageSize int32, fn func(page map[string]ReturnValue) bool) error {
+	if fields, ok, err := c.compactFields(key); err != nil || ok {
+		if err != nil {
+			return err
+		}
+
+		return compactPages(ctx, fields, int(pageSize), fn)
+	}
 
 	var lastEvaluatedKey map[string]types.AttributeValue
 
-	for hasMoreResults {
+	for hasMoreResults := true; hasMoreResults; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		builder := newExpresionBuilder()
 		builder.addConditionEquality(c.partitionKey, StringValue{key})
 
-		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+		input := &dynamodb.QueryInput{
 			ConsistentRead:            aws.Bool(c.consistentReads),
 			ExclusiveStartKey:         lastEvaluatedKey,
 			ExpressionAttributeNames:  builder.expressionAttributeNames(),
 			ExpressionAttributeValues: builder.expressionAttributeValues(),
 			KeyConditionExpression:    builder.conditionExpression(),
 			TableName:                 aws.String(c.tableName),
-		})
+		}
+
+		if pageSize > 0 {
+			input.Limit = aws.Int32(pageSize)
+		}
 
+		resp, err := c.ddbClient.Query(ctx, excludeExpiredItems(input))
 		if err != nil {
-			return fieldValues, err
+			return err
 		}
 
+		page := make(map[string]ReturnValue, len(resp.Items))
+
 		for _, item := range resp.Items {
-			parsedItem := parseItem(item, c)
-			fieldValues[parsedItem.sk] = parsedItem.val
+			if page[parseKey(item, c).sk], err = c.decodeValue(item); err != nil {
+				return err
+			}
 		}
 
-		if len(resp.LastEvaluatedKey) > 0 {
-			lastEvaluatedKey = resp.LastEvaluatedKey
-		} else {
-			hasMoreResults = false
+		if len(page) > 0 && !fn(page) {
+			return nil
 		}
+
+		lastEvaluatedKey = resp.LastEvaluatedKey
+		hasMoreResults = len(lastEvaluatedKey) > 0
 	}
 
-	return
+	return nil
 }
 
+// HINCRBYFLOAT increments the number stored at the field of the hash at key with the given float64 delta and
+// returns the new value. If the field does not exist, it will be initialized with zero before applying the
+// operation. Like INCRBYFLOAT, the delta is added in DynamoDB's decimal arithmetic, so adding 0.1 ten times
+// stores exactly 1.
+//
+// The increment is a single ADD, conditional on the field not having expired, so concurrent increments of the same
+// field never conflict or need retries, and the new value comes back from the same write. A field that has expired
+// (see HEXPIRE) is started over from delta without an expiry.
+//
+// Cost is O(1) or 1 WCU, plus 1 WCU to start over an expired field.
+//
+// Works similar to https://redis.io/commands/hincrbyfloat
 func (c Client) HINCRBYFLOAT(key string, field string, delta float64) (after float64, err error) {
 	rv, err := c.hIncr(key, field, FloatValue{delta})
 	if err == nil {
@@ -276,26 +700,108 @@ func (c Client) HINCRBYFLOAT(key string, field string, delta float64) (after flo
 }
 
 func (c Client) hIncr(key string, field string, delta Value) (after ReturnValue, err error) {
+	compact, err := c.updateCompactHash(key, func(fields map[string]types.AttributeValue) (bool, error) {
+		sum, err := addNumbers(fields[field], delta.ToAV())
+		if err != nil {
+			return false, err
+		}
+
+		fields[field], after = sum, ReturnValue{sum}
+
+		return true, nil
+	})
+	if err != nil || compact {
+		return
+	}
+
+	if !c.counted(key) {
+		return c.incrItem(keyDef{pk: key, sk: field}, delta)
+	}
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		after, done, err = c.hIncrExisting(key, field, delta)
+		if err != nil || done {
+			return true, err
+		}
+
+		done, err = c.hIncrNew(key, field, delta)
+		after = ReturnValue{delta.ToAV()}
+
+		return err != nil || done, err
+	})
+
+	return
+}
+
+// hIncrExisting increments field if it exists and hasn't expired, and returns false otherwise. With counted
+// cardinality, an increment has to know whether it created the field, which a single ADD can't tell, so fields
+// are incremented and created with separate conditional writes.
+func (c Client) hIncrExisting(key string, field string, delta Value) (after ReturnValue, ok bool, err error) {
 	builder := newExpresionBuilder()
+	builder.addConditionExistsUnexpired(c.partitionKey)
+	builder.clauses["ADD"] = append(builder.clauses["ADD"], "#"+vk+" :delta")
 	builder.keys[vk] = struct{}{}
+	builder.values["delta"] = delta.ToAV()
+
 	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: builder.expressionAttributeNames(),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":delta": delta.ToAV(),
-		},
-		Key:              keyDef{pk: key, sk: field}.toAV(c),
-		ReturnValues:     types.ReturnValueAllNew,
-		TableName:        aws.String(c.tableName),
-		UpdateExpression: aws.String("ADD #val :delta"),
+		ConditionExpression:       builder.conditionExpression(),
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       keyDef{pk: key, sk: field}.toAV(c),
+		ReturnValues:              types.ReturnValueUpdatedNew,
+		TableName:                 aws.String(c.tableName),
+		UpdateExpression:          builder.updateExpression(),
 	})
+	if conditionFailureError(err) {
+		return after, false, nil
+	}
 
-	if err == nil {
-		after = ReturnValue{resp.Attributes[vk]}
+	if err != nil {
+		return after, false, err
 	}
 
-	return
+	return ReturnValue{resp.Attributes[vk]}, true, nil
 }
 
+// hIncrNew sets field to delta if it doesn't exist or has expired, and returns false otherwise. Only fields that
+// didn't exist at all are counted, since expired fields that haven't been deleted yet are still in the count.
+func (c Client) hIncrNew(key string, field string, delta Value) (ok bool, err error) {
+	builder := newExpresionBuilder()
+	builder.addConditionNotExistsOrExpired(c.partitionKey)
+	builder.updateSET(vk, delta)
+	builder.updateTTL(Flags{})
+
+	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		ConditionExpression:       builder.conditionExpression(),
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       keyDef{pk: key, sk: field}.toAV(c),
+		ReturnValues:              types.ReturnValueAllOld,
+		TableName:                 aws.String(c.tableName),
+		UpdateExpression:          builder.updateExpression(),
+	})
+	if conditionFailureError(err) {
+		return false, nil
+	}
+
+	if err == nil && len(resp.Attributes) == 0 {
+		err = c.adjustCardinality(key, 1)
+	}
+
+	return err == nil, err
+}
+
+// HINCRBY increments the number stored at the field of the hash at key with the given delta and returns the new
+// value. If the field does not exist, it will be initialized with zero before applying the operation. If the field
+// holds a value that isn't a number, an error is returned.
+//
+// The increment is a single ADD, conditional on the field not having expired, so concurrent increments of the same
+// field never conflict or need retries, and the new value comes back from the same write. A field that has expired
+// (see HEXPIRE) is started over from delta without an expiry.
+//
+// Cost is O(1) or 1 WCU, plus 1 WCU to start over an expired field.
+//
+// Works similar to https://redis.io/commands/hincrby
 func (c Client) HINCRBY(key string, field string, delta int64) (after int64, err error) {
 	rv, err := c.hIncr(key, field, IntValue{delta})
 
@@ -306,7 +812,18 @@ func (c Client) HINCRBY(key string, field string, delta int64) (after int64, err
 	return
 }
 
+// HKEYS returns the fields of the hash at key that start with pattern, or all of them if pattern is empty, in
+// order. Only the sort keys are fetched, so less data is transferred than with HGETALL, but the read cost is the
+// same, since DynamoDB charges for the whole item.
+//
+// Cost is O(N) / 1 RCU per 4 KB of fields read.
+//
+// Works similar to https://redis.io/commands/hkeys
 func (c Client) HKEYS(key string, pattern string) (keys []string, err error) {
+	if fields, ok, err := c.compactFields(key); err != nil || ok {
+		return compactFieldNames(fields, pattern), err
+	}
+
 	hasMoreResults := true
 
 	var lastEvaluatedKey map[string]types.AttributeValue
@@ -319,7 +836,7 @@ func (c Client) HKEYS(key string, pattern string) (keys []string, err error) {
 			builder.addConditionBeginWith(c.sortKey, StringValue{pattern})
 		}
 
-		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+		resp, err := c.ddbClient.Query(context.TODO(), excludeExpiredItems(&dynamodb.QueryInput{
 			ConsistentRead:            aws.Bool(c.consistentReads),
 			ExclusiveStartKey:         lastEvaluatedKey,
 			ExpressionAttributeNames:  builder.expressionAttributeNames(),
@@ -328,7 +845,7 @@ func (c Client) HKEYS(key string, pattern string) (keys []string, err error) {
 			TableName:                 aws.String(c.tableName),
 			ProjectionExpression:      aws.String(c.sortKey),
 			Select:                    types.SelectSpecificAttributes,
-		})
+		}))
 
 		if err != nil {
 			return keys, err
@@ -349,18 +866,84 @@ func (c Client) HKEYS(key string, pattern string) (keys []string, err error) {
 	return
 }
 
+// HVALS returns the values of all the fields of the hash at key, in the order of their fields. Only the values are
+// fetched, so less data is transferred than with HGETALL, but the read cost is the same, since DynamoDB charges
+// for the whole item.
+//
+// Cost is O(N) / 1 RCU per 4 KB of fields read.
+//
+// Works similar to https://redis.io/commands/hvals
 func (c Client) HVALS(key string) (values []ReturnValue, err error) {
-	all, err := c.HGETALL(key)
-	if err == nil {
-		for _, v := range all {
-			values = append(values, v)
+	if fields, ok, err := c.compactFields(key); err != nil || ok {
+		for _, field := range compactFieldNames(fields, "") {
+			values = append(values, ReturnValue{fields[field]})
+		}
+
+		return values, err
+	}
+
+	projection, names := valueProjection()
+
+	var lastEvaluatedKey map[string]types.AttributeValue
+
+	for hasMoreResults := true; hasMoreResults; {
+		builder := newExpresionBuilder()
+		builder.addConditionEquality(c.partitionKey, StringValue{key})
+
+		for _, attribute := range names {
+			builder.keys[attribute] = struct{}{}
 		}
+
+		resp, err := c.ddbClient.Query(context.TODO(), excludeExpiredItems(&dynamodb.QueryInput{
+			ConsistentRead:            aws.Bool(c.consistentReads),
+			ExclusiveStartKey:         lastEvaluatedKey,
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			KeyConditionExpression:    builder.conditionExpression(),
+			ProjectionExpression:      projection,
+			TableName:                 aws.String(c.tableName),
+		}))
+		if err != nil {
+			return values, err
+		}
+
+		for _, item := range resp.Items {
+			value, err := c.decodeValue(item)
+			if err != nil {
+				return values, err
+			}
+
+			values = append(values, value)
+		}
+
+		lastEvaluatedKey = resp.LastEvaluatedKey
+		hasMoreResults = len(lastEvaluatedKey) > 0
 	}
 
 	return
 }
 
+// HLEN returns the number of fields in the hash at key. By default the fields are counted with a query, leaving
+// out fields that have expired. With CountedCardinality the maintained count is read instead, which includes
+// fields that have expired until RepairCardinality is run.
+//
+// Cost is O(N) / 1 RCU per 4 KB of fields, or O(1) / 1 RCU with CountedCardinality.
+//
+// Works similar to https://redis.io/commands/hlen
 func (c Client) HLEN(key string) (count int32, err error) {
+	if fields, ok, err := c.compactFields(key); err != nil || ok {
+		return int32(len(fields)), err
+	}
+
+	if c.counted(key) {
+		return c.cardinality(key)
+	}
+
+	return c.countItems(key, true)
+}
+
+// countItems counts the items at key with a query, leaving out the ones that have expired if excludeExpired is set.
+func (c Client) countItems(key string, excludeExpired bool) (count int32, err error) {
 	hasMoreResults := true
 
 	var lastEvaluatedKey map[string]types.AttributeValue
@@ -369,7 +952,7 @@ func (c Client) HLEN(key string) (count int32, err error) {
 		builder := newExpresionBuilder()
 		builder.addConditionEquality(c.partitionKey, StringValue{key})
 
-		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+		input := &dynamodb.QueryInput{
 			ConsistentRead:            aws.Bool(c.consistentReads),
 			ExclusiveStartKey:         lastEvaluatedKey,
 			ExpressionAttributeNames:  builder.expressionAttributeNames(),
@@ -377,13 +960,18 @@ func (c Client) HLEN(key string) (count int32, err error) {
 			KeyConditionExpression:    builder.conditionExpression(),
 			TableName:                 aws.String(c.tableName),
 			Select:                    types.SelectCount,
-		})
+		}
+
+		if excludeExpired {
+			input = excludeExpiredItems(input)
+		}
 
+		resp, err := c.ddbClient.Query(context.TODO(), input)
 		if err != nil {
 			return count, err
 		}
 
-		count += resp.ScannedCount
+		count += resp.Count
 
 		if len(resp.LastEvaluatedKey) > 0 {
 			lastEvaluatedKey = resp.LastEvaluatedKey
@@ -395,12 +983,145 @@ func (c Client) HLEN(key string) (count int32, err error) {
 	return
 }
 
+// HEXPIRE makes the given fields of the hash at key expire after ttl, and returns the fields that exist and got the
+// expiry. A ttl of zero or less deletes the fields right away, like HDEL. Writing a field with HSET, HMSET or
+// HSETNX removes its expiry.
+//
+// Each field is a separate item, so the expiry is simply set on the item of the field, and DynamoDB's Time to Live
+// deletes it (enable Time to Live on the "ttl" attribute of the table). Fields that have expired but haven't been
+// deleted yet are hidden from all hash reads.
+//
+// Cost is O(N) / 1 WCU per field.
+//
+// Works similar to https://redis.io/commands/hexpire
+func (c Client) HEXPIRE(key string, ttl time.Duration, fields ...string) (updatedFields []string, err error) {
+	if ttl <= 0 {
+		return c.HDEL(key, fields...)
+	}
+
+	if err = c.promoteHash(key); err != nil {
+		return
+	}
+
+	return c.updateFieldTTLs(key, fields, func(builder *expressionBuilder) {
+		builder.updateSetAV(ttlKey, expiryAV(ttl))
+	})
+}
+
+// HPERSIST removes the expiry of the given fields of the hash at key, and returns the fields that had one.
+//
+// Cost is O(N) / 1 WCU per field.
+//
+// Works similar to https://redis.io/commands/hpersist
+func (c Client) HPERSIST(key string, fields ...string) (persistedFields []string, err error) {
+	if _, ok, err := c.compactFields(key); err != nil || ok {
+		return nil, err
+	}
+
+	return c.updateFieldTTLs(key, fields, func(builder *expressionBuilder) {
+		builder.updateTTL(Flags{})
+		builder.addConditionExists(ttlKey)
+	})
+}
+
+// updateFieldTTLs applies the update to each of the fields that exist and haven't expired, and returns the fields
+// it was applied to.
+func (c Client) updateFieldTTLs(key string, fields []string, update func(b *expressionBuilder)) ([]string, error) {
+	var updatedFields []string
+
+	for _, field := range fields {
+		builder := newExpresionBuilder()
+		update(&builder)
+		builder.addConditionExistsUnexpired(c.partitionKey)
+
+		_, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+			ConditionExpression:       builder.conditionExpression(),
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			Key:                       keyDef{pk: key, sk: field}.toAV(c),
+			TableName:                 aws.String(c.tableName),
+			UpdateExpression:          builder.updateExpression(),
+		})
+		if conditionFailureError(err) {
+			continue
+		}
+
+		if err != nil {
+			return updatedFields, err
+		}
+
+		updatedFields = append(updatedFields, field)
+	}
+
+	return updatedFields, nil
+}
+
+// HTTL returns the time left before each of the given fields of the hash at key expires, or NoExpiry for fields
+// that don't expire. Fields that don't exist are left out. Expiry times are stored in whole seconds.
+//
+// Cost is O(N) / 1 RCU per field (0.5 RCU with eventually consistent reads).
+//
+// Works similar to https://redis.io/commands/httl
+func (c Client) HTTL(key string, fields ...string) (ttls map[string]time.Duration, err error) {
+	ttls = make(map[string]time.Duration)
+
+	if compactFields, ok, err := c.compactFields(key); err != nil || ok {
+		for _, field := range fields {
+			if _, ok := compactFields[field]; ok {
+				ttls[field] = NoExpiry
+			}
+		}
+
+		return ttls, err
+	}
+
+	keys := make([]keyDef, len(fields))
+	for i, field := range fields {
+		keys[i] = keyDef{pk: key, sk: field}
+	}
+
+	items, err := c.batchGet(keys, c.sortKey, ttlKey)
+	if err != nil {
+		return
+	}
+
+	for _, item := range items {
+		if itemExpired(item) {
+			continue
+		}
+
+		ttls[parseKey(item, c).sk] = NoExpiry
+
+		if expiry, ok := item[ttlKey].(*types.AttributeValueMemberN); ok {
+			ttls[parseKey(item, c).sk] = time.Until(time.Unix(ReturnValue{expiry}.Int(), 0))
+		}
+	}
+
+	return
+}
+
 func (c Client) HSETNX(key string, field string, value Value) (ok bool, err error) {
+	compact, err := c.updateCompactHash(key, func(fields map[string]types.AttributeValue) (bool, error) {
+		_, exists := fields[field]
+		if ok = !exists; ok {
+			fields[field] = value.ToAV()
+		}
+
+		return ok, nil
+	})
+	if err != nil || compact {
+		return
+	}
+
 	builder := newExpresionBuilder()
-	builder.updateSET(vk, value)
-	builder.addConditionNotExists(c.partitionKey)
+	if err = c.updateValue(&builder, value); err != nil {
+		return
+	}
+
+	builder.updateTTL(Flags{})
+	builder.addConditionNotExistsOrExpired(c.partitionKey)
 
-	_, err = c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
 		ConditionExpression:       builder.conditionExpression(),
 		ExpressionAttributeNames:  builder.expressionAttributeNames(),
 		ExpressionAttributeValues: builder.expressionAttributeValues(),
@@ -408,6 +1129,7 @@ func (c Client) HSETNX(key string, field string, value Value) (ok bool, err erro
 			pk: key,
 			sk: field,
 		}.toAV(c),
+		ReturnValues:     types.ReturnValueAllOld,
 		TableName:        aws.String(c.tableName),
 		UpdateExpression: builder.updateExpression(),
 	})
@@ -420,5 +1142,9 @@ func (c Client) HSETNX(key string, field string, value Value) (ok bool, err erro
 		return false, err
 	}
 
-	return true, nil
+	if len(resp.Attributes) == 0 {
+		err = c.adjustCardinality(key, 1)
+	}
+
+	return true, err
 }
@@ -1,8 +1,17 @@
 package redimo
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -101,6 +110,27 @@ func TestAtomicHashOps(t *testing.T) {
 	assert.False(t, values["nonexistent1"].Present())
 	assert.False(t, values["nonexistent2"].Present())
 
+	ordered, found, err := c.HMGETORDERED("k1", "nonexistent1", "f2", "f1")
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, true, true}, found)
+	assert.False(t, ordered[0].Present())
+	assert.Equal(t, "v2", ordered[1].String())
+	assert.Equal(t, "v1", ordered[2].String())
+
+	manyFields := make([]string, 150)
+	for i := range manyFields {
+		manyFields[i] = fmt.Sprintf("field%d", i)
+	}
+
+	manyFields[120] = "f1"
+
+	ordered, found, err = c.HMGETORDERED("k1", manyFields...)
+	assert.NoError(t, err)
+	assert.Len(t, ordered, 150)
+	assert.True(t, found[120])
+	assert.False(t, found[121])
+	assert.Equal(t, "v1", ordered[120].String())
+
 	ok, err := c.HSETNX("k1", "f1", StringValue{"v1"})
 	assert.NoError(t, err)
 	assert.False(t, ok)
@@ -140,4 +170,421 @@ func TestHashCounters(t *testing.T) {
 	v, err := c.HGET("k1", "f2")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(42), v.Int())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.HINCRBY("k1", "hits", 1)
+			assert.NoError(t, err)
+
+			_, err = c.HINCRBYFLOAT("k1", "total", 0.1)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	afterInt, err = c.HINCRBY("k1", "hits", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), afterInt)
+
+	v, err = c.HGET("k1", "total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, v.Float())
+
+	_, err = c.HSET("k1", "name", "not a number")
+	assert.NoError(t, err)
+
+	_, err = c.HINCRBY("k1", "name", 1)
+	assert.Error(t, err)
+}
+
+func TestBinaryHashValues(t *testing.T) {
+	c := newClient(t)
+
+	blob := []byte{0, 1, 2, 255}
+
+	_, err := c.HSET("h", "blob", blob)
+	assert.NoError(t, err)
+
+	val, err := c.HGET("h", "blob")
+	assert.NoError(t, err)
+	assert.Equal(t, blob, val.Bytes())
+	assert.Equal(t, "", val.String())
+
+	err = c.HMSET("h", map[string][]byte{"b1": {1}, "b2": {2}})
+	assert.NoError(t, err)
+
+	values, err := c.HGETALL("h")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, values["b1"].Bytes())
+	assert.Equal(t, []byte{2}, values["b2"].Bytes())
+
+	_, err = c.SET("s", blob)
+	assert.NoError(t, err)
+
+	val, err = c.GET("s")
+	assert.NoError(t, err)
+	assert.Equal(t, blob, val.Bytes())
+}
+
+func TestTypedHashValues(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.HSET("h", map[string]Value{
+		"int": IntValue{42}, "float": FloatValue{1.5}, "bool": BoolValue{true}, "blob": BytesValue{[]byte{0, 255}},
+	})
+	assert.NoError(t, err)
+
+	values, err := c.HGETALL("h")
+	assert.NoError(t, err)
+	assert.IsType(t, &types.AttributeValueMemberN{}, values["int"].ToAV())
+	assert.IsType(t, &types.AttributeValueMemberBOOL{}, values["bool"].ToAV())
+	assert.True(t, values["bool"].Bool())
+
+	i, found, err := c.HGetInt("h", "int")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(42), i)
+
+	f, found, err := c.HGetFloat("h", "float")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 1.5, f)
+
+	b, found, err := c.HGetBytes("h", "blob")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte{0, 255}, b)
+
+	_, found, err = c.HGetInt("h", "float")
+	assert.True(t, errors.Is(err, ErrWrongType))
+	assert.True(t, found)
+
+	_, found, err = c.HGetBytes("h", "int")
+	assert.True(t, errors.Is(err, ErrWrongType))
+	assert.True(t, found)
+
+	_, found, err = c.HGetInt("h", "nosuchfield")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestAtomicHashes(t *testing.T) {
+	c := newClient(t).AtomicHashes()
+
+	saved, err := c.HSET("user", map[string]Value{"name": StringValue{"Ada"}, "lang": StringValue{"en"}})
+	assert.NoError(t, err)
+	assert.Len(t, saved, 2)
+
+	saved, err = c.HSET("user", map[string]Value{"name": StringValue{"Ada L."}, "city": StringValue{"London"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Value{"city": StringValue{"London"}}, saved)
+
+	values, err := c.HGETALL("user")
+	assert.NoError(t, err)
+	assert.Len(t, values, 3)
+	assert.Equal(t, "Ada L.", values["name"].String())
+
+	fields := make(map[string]Value)
+	for i := 0; i < 101; i++ {
+		fields[fmt.Sprintf("f%d", i)] = IntValue{int64(i)}
+	}
+
+	_, err = c.HSET("many", fields)
+	assert.Equal(t, ErrTooManyKeys, err)
+
+	exists, err := c.HEXISTS("many", "f0")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestBatchedHDel(t *testing.T) {
+	c := newClient(t)
+
+	fields := make(map[string]Value)
+	names := make([]string, 0, 60)
+
+	for i := 0; i < 60; i++ {
+		fields[fmt.Sprintf("f%02d", i)] = IntValue{int64(i)}
+		names = append(names, fmt.Sprintf("f%02d", i))
+	}
+
+	assert.NoError(t, c.HMSET("big", fields))
+
+	deleted, err := c.HDEL("big", append(append([]string{}, names[:50]...), "f00", "nonexistent")...)
+	assert.NoError(t, err)
+	assert.Equal(t, names[:50], deleted)
+
+	count, err := c.HLEN("big")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10), count)
+
+	deleted, err = c.HDEL("big", "f55")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f55"}, deleted)
+
+	deleted, err = c.HDEL("big", "f55", "f56")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f56"}, deleted)
+}
+
+// expireField backdates the expiry of a hash field, as if it had expired but not been deleted by DynamoDB yet.
+func expireField(t *testing.T, c Client, key string, field string) {
+	builder := newExpresionBuilder()
+	builder.updateSetAV(ttlKey, IntValue{time.Now().Add(-time.Minute).Unix()}.ToAV())
+
+	_, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       keyDef{pk: key, sk: field}.toAV(c),
+		TableName:                 aws.String(c.tableName),
+		UpdateExpression:          builder.updateExpression(),
+	})
+	assert.NoError(t, err)
+}
+
+func TestHashFieldTTL(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.HSET("session", map[string]Value{"user": StringValue{"ada"}, "token": StringValue{"t1"}, "hits": IntValue{1}})
+	assert.NoError(t, err)
+
+	updated, err := c.HEXPIRE("session", time.Hour, "token", "hits", "nonexistent")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token", "hits"}, updated)
+
+	ttls, err := c.HTTL("session", "user", "token", "nonexistent")
+	assert.NoError(t, err)
+	assert.Len(t, ttls, 2)
+	assert.Equal(t, NoExpiry, ttls["user"])
+	assert.InDelta(t, time.Hour.Seconds(), ttls["token"].Seconds(), 5)
+
+	persisted, err := c.HPERSIST("session", "token", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token"}, persisted)
+
+	expireField(t, c, "session", "hits")
+	expireField(t, c, "session", "token")
+
+	val, err := c.HGET("session", "token")
+	assert.NoError(t, err)
+	assert.True(t, val.Empty())
+
+	exists, err := c.HEXISTS("session", "token")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	values, err := c.HGETALL("session")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user"}, keysOf(values))
+
+	keys, err := c.HKEYS("session", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user"}, keys)
+
+	count, err := c.HLEN("session")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	fields, err := c.HMGET("session", "user", "token")
+	assert.NoError(t, err)
+	assert.False(t, fields["token"].Present())
+
+	after, err := c.HINCRBY("session", "hits", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), after)
+
+	ok, err := c.HSETNX("session", "token", StringValue{"t2"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ttls, err = c.HTTL("session", "token", "hits")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"token": NoExpiry, "hits": NoExpiry}, ttls)
+
+	deleted, err := c.HEXPIRE("session", 0, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user"}, deleted)
+}
+
+func keysOf(values map[string]ReturnValue) (keys []string) {
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	return
+}
+
+func TestHashPages(t *testing.T) {
+	c := newClient(t)
+
+	fields := make(map[string]Value)
+	for i := 0; i < 25; i++ {
+		fields[fmt.Sprintf("f%02d", i)] = IntValue{int64(i)}
+	}
+
+	assert.NoError(t, c.HMSET("wide", fields))
+
+	var (
+		pages int
+		seen  = make(map[string]ReturnValue)
+	)
+
+	err := c.HGETALLPAGES(context.Background(), "wide", 10, func(page map[string]ReturnValue) bool {
+		pages++
+
+		assert.True(t, len(page) <= 10)
+
+		for field, value := range page {
+			seen[field] = value
+		}
+
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pages)
+	assert.Len(t, seen, 25)
+	assert.Equal(t, int64(7), seen["f07"].Int())
+
+	pages = 0
+	err = c.HGETALLPAGES(context.Background(), "wide", 10, func(page map[string]ReturnValue) bool {
+		pages++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = c.HGETALLPAGES(ctx, "wide", 10, func(page map[string]ReturnValue) bool {
+		return true
+	})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestHashKeysAndValues(t *testing.T) {
+	c := newClient(t).Compression(GzipCodec, 100)
+	long := strings.Repeat("compressible ", 100)
+
+	_, err := c.HSET("h", map[string]Value{"a1": StringValue{"v1"}, "a2": StringValue{long}, "b1": IntValue{3}})
+	assert.NoError(t, err)
+
+	keys, err := c.HKEYS("h", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a2", "b1"}, keys)
+
+	keys, err = c.HKEYS("h", "a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a2"}, keys)
+
+	values, err := c.HVALS("h")
+	assert.NoError(t, err)
+	assert.Len(t, values, 3)
+	assert.Equal(t, "v1", values[0].String())
+	assert.Equal(t, long, values[1].String())
+	assert.Equal(t, int64(3), values[2].Int())
+}
+
+func TestCountedHashLen(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	_, err := c.HSET("h", map[string]Value{"a": StringValue{"1"}, "b": StringValue{"2"}})
+	assert.NoError(t, err)
+
+	_, err = c.HSET("h", "a", StringValue{"overwritten"})
+	assert.NoError(t, err)
+
+	ok, err := c.HSETNX("h", "c", StringValue{"3"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = c.HINCRBY("h", "d", 5)
+	assert.NoError(t, err)
+
+	after, err := c.HINCRBY("h", "d", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), after)
+
+	err = c.HMSET("h", map[string]Value{"c": StringValue{"3"}, "e": StringValue{"4"}})
+	assert.NoError(t, err)
+
+	_, err = c.AtomicHashes().HSET("h", map[string]Value{"e": StringValue{"5"}, "f": StringValue{"6"}})
+	assert.NoError(t, err)
+
+	count, err := c.HLEN("h")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), count)
+
+	_, err = c.HDEL("h", "a")
+	assert.NoError(t, err)
+
+	_, err = c.HDEL("h", "b", "c", "nosuchfield")
+	assert.NoError(t, err)
+
+	count, err = c.HLEN("h")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	metadata, err := c.HLEN(metadataKey("h"))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), metadata)
+
+	_, err = c.HSET("h", "g", StringValue{"7"})
+	assert.NoError(t, err)
+
+	uncounted := c
+	uncounted.countedCardinality = false
+
+	_, err = uncounted.HDEL("h", "g")
+	assert.NoError(t, err)
+
+	count, err = c.RepairCardinality("h")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	count, err = c.HLEN("h")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+}
+
+func TestSnapshotHashes(t *testing.T) {
+	c := newClient(t).SnapshotHashes()
+
+	_, err := c.HSET("h", map[string]Value{"a": StringValue{"1"}, "b": IntValue{2}, "c": BytesValue{[]byte{3}}})
+	assert.NoError(t, err)
+
+	all, err := c.HGETALL("h")
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	assert.Equal(t, "1", all["a"].String())
+	assert.Equal(t, int64(2), all["b"].Int())
+	assert.Equal(t, []byte{3}, all["c"].Bytes())
+
+	values, found, err := c.HMGETORDERED("h", "b", "nosuchfield", "b")
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, found)
+	assert.Equal(t, int64(2), values[2].Int())
+
+	all, err = c.HGETALL("nosuchkey")
+	assert.NoError(t, err)
+	assert.Empty(t, all)
+
+	fields := make([]string, 101)
+	for i := range fields {
+		fields[i] = fmt.Sprintf("f%d", i)
+	}
+
+	_, _, err = c.HMGETORDERED("h", fields...)
+	assert.Equal(t, ErrTooManyKeys, err)
+
+	all, err = c.CompactHashes().HGETALL("h")
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
 }
@@ -2,13 +2,31 @@ package redimo
 
 import (
 	"context"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// DEL deletes the key at key, of any type, and returns the sort keys of the items that were deleted. The metadata
+// Redimo keeps for key, like list indexes and maintained member counts, is deleted along with it, so that a key
+// written again after DEL starts from scratch.
+//
+// Works similar to https://redis.io/commands/del
 func (c Client) DEL(key string) (deletedFields []string, err error) {
+	deletedFields, err = c.deleteItems(key)
+	if err != nil {
+		return
+	}
+
+	_, err = c.deleteItems(metadataKey(key))
+
+	return
+}
+
+// deleteItems deletes every item of key, one at a time, and returns the sort keys of the items that were deleted.
+func (c Client) deleteItems(key string) (deletedFields []string, err error) {
 	fields, err := c.listSortKeys(key)
 	if err != nil {
 		return deletedFields, err
@@ -85,6 +103,7 @@ func (c Client) EXISTS(key string) (exists bool, err error) {
 		ExpressionAttributeNames:  builder.expressionAttributeNames(),
 		ExpressionAttributeValues: builder.expressionAttributeValues(),
 		KeyConditionExpression:    builder.conditionExpression(),
+		Limit:                     aws.Int32(1),
 		TableName:                 aws.String(c.tableName),
 	})
 
@@ -135,3 +154,276 @@ func (c Client) EXISTS(key string) (exists bool, err error) {
 
 // 	return
 // }
+
+// COPY copies the key at source, of any type, to destination and returns true if it was copied. Every item of the
+// source is copied, along with the metadata Redimo keeps for it, like list indexes and maintained member counts, and
+// the keys Redimo keeps alongside it: the shards of a sorted set written with SortedSetShards, the fences of a geo
+// key, and the consumers and processing lists of a reliable queue. The movement logs of geo members (see GEOMOVE)
+// and stream consumer groups are not copied.
+//
+// If destination already exists, nothing is copied and false is returned, unless replace is true, in which case
+// destination and the keys kept alongside it are deleted first. If source doesn't exist, false is returned.
+//
+// Without replace, the items are written in transactions of up to TransactionActions items, each on the condition
+// that the item doesn't exist yet, so a destination created by another client during the copy is never overwritten:
+// the items already copied are deleted again and false is returned. With replace, the copy is written with batched
+// writes. Either way the copy is not atomic: writes to source during the copy may or may not be copied, and if an
+// error is returned destination may be partially written.
+//
+// Cost is O(N) / 1 RCU per 4 KB of items read plus 1 WCU per item written – 2 WCUs without replace, as the items are
+// written in transactions – plus a query for each of the keys kept alongside source and destination.
+//
+// Works similar to https://redis.io/commands/copy
+func (c Client) COPY(source string, destination string, replace bool) (copied bool, err error) {
+	if source == destination {
+		return false, nil
+	}
+
+	exists, err := c.EXISTS(destination)
+	if err != nil || (exists && !replace) {
+		return
+	}
+
+	consumers, err := c.ReliableQueue(source, 0).consumers()
+	if err != nil {
+		return
+	}
+
+	sources := append([]string{source}, c.derivedKeys(source, consumers)...)
+	destinations := append([]string{destination}, c.derivedKeys(destination, consumers)...)
+
+	var requests []types.WriteRequest
+
+	for i := range sources {
+		items, err := c.keyItems(sources[i])
+		if err != nil {
+			return false, err
+		}
+
+		metadata, err := c.keyItems(metadataKey(sources[i]))
+		if err != nil {
+			return false, err
+		}
+
+		copied = copied || len(items) > 0
+
+		for _, item := range items {
+			requests = append(requests, putRequest(withPartitionKey(item, c.partitionKey, destinations[i])))
+		}
+
+		for _, item := range metadata {
+			requests = append(requests, putRequest(withPartitionKey(item, c.partitionKey, metadataKey(destinations[i]))))
+		}
+	}
+
+	if !copied {
+		return
+	}
+
+	if !replace {
+		return c.putIfNotExists(requests)
+	}
+
+	if err = c.delDerived(destination); err != nil {
+		return false, err
+	}
+
+	if err = c.batchWrite(requests); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// putIfNotExists writes the put requests in transactions of up to TransactionActions items, each on the condition
+// that the item doesn't exist yet. If a condition fails, the items already written are deleted again and false is
+// returned.
+func (c Client) putIfNotExists(requests []types.WriteRequest) (ok bool, err error) {
+	var written []types.WriteRequest
+
+	for _, chunk := range chunkWriteRequests(requests, c.transactionChunk()) {
+		items := make([]types.TransactWriteItem, len(chunk))
+
+		for i, request := range chunk {
+			builder := newExpresionBuilder()
+			builder.addConditionNotExists(c.partitionKey)
+
+			items[i].Put = &types.Put{
+				ConditionExpression:      builder.conditionExpression(),
+				ExpressionAttributeNames: builder.expressionAttributeNames(),
+				Item:                     request.PutRequest.Item,
+				TableName:                aws.String(c.tableName),
+			}
+		}
+
+		_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: items,
+		})
+		if conditionFailureError(err) {
+			return false, c.batchWrite(written)
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		for _, request := range chunk {
+			written = append(written, deleteRequest(parseKey(request.PutRequest.Item, c).toAV(c)))
+		}
+	}
+
+	return true, nil
+}
+
+// derivedKeys returns the keys Redimo keeps alongside key for some types, given the consumers of the reliable queue
+// at key. Each type lists its own keys, and their metadata is kept under the metadataKey of each.
+func (c Client) derivedKeys(key string, consumers []string) (keys []string) {
+	keys = append(keys, c.zDerivedKeys(key)...)
+	keys = append(keys, geoFenceDerivedKeys(key)...)
+
+	return append(keys, c.ReliableQueue(key, 0).derivedKeys(consumers)...)
+}
+
+// delDerived deletes key along with its metadata and the keys Redimo keeps alongside it, see derivedKeys.
+func (c Client) delDerived(key string) (err error) {
+	consumers, err := c.ReliableQueue(key, 0).consumers()
+	if err != nil {
+		return
+	}
+
+	for _, k := range append([]string{key}, c.derivedKeys(key, consumers)...) {
+		if _, err = c.DEL(k); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// keyItems reads every item of key with all its attributes.
+func (c Client) keyItems(key string) (items []map[string]types.AttributeValue, err error) {
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{key})
+
+	var lastEvaluatedKey map[string]types.AttributeValue
+
+	for hasMoreResults := true; hasMoreResults; {
+		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+			ConsistentRead:            aws.Bool(c.consistentReads),
+			ExclusiveStartKey:         lastEvaluatedKey,
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			KeyConditionExpression:    builder.conditionExpression(),
+			TableName:                 aws.String(c.tableName),
+		})
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, resp.Items...)
+
+		lastEvaluatedKey = resp.LastEvaluatedKey
+		hasMoreResults = len(lastEvaluatedKey) > 0
+	}
+
+	return
+}
+
+// withPartitionKey returns a copy of item with the partition key set to key.
+func withPartitionKey(item map[string]types.AttributeValue, partitionKey string, key string) map[string]types.AttributeValue {
+	moved := make(map[string]types.AttributeValue, len(item))
+	for name, av := range item {
+		moved[name] = av
+	}
+
+	moved[partitionKey] = StringValue{key}.ToAV()
+
+	return moved
+}
+
+// MaxItemSize is the largest item DynamoDB can store, in bytes. Writes that would make an item larger fail.
+const MaxItemSize = 400 * 1024
+
+// MEMORYUSAGE estimates the storage used by the items backing key, of any type, along with the size of the largest
+// of them. Sizes are calculated with DynamoDB's rules for item sizes – attribute names plus values – so comparing
+// largestItem with MaxItemSize shows keys that are close to the limit before writes to them start failing. Index
+// entries and the items Redimo keeps under internal keys, like maintained counts, are not included.
+//
+// Cost is O(N) / 1 RCU per 4 KB of items read.
+//
+// Works similar to https://redis.io/commands/memory-usage
+func (c Client) MEMORYUSAGE(key string) (bytes int64, largestItem int64, err error) {
+	items, err := c.keyItems(key)
+
+	for _, item := range items {
+		size := itemSize(item)
+		bytes += size
+
+		if size > largestItem {
+			largestItem = size
+		}
+	}
+
+	return
+}
+
+// itemSize returns the size of an item as DynamoDB calculates it: the length of every attribute name plus the size
+// of its value. See https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/CapacityUnitCalculations.html
+func itemSize(item map[string]types.AttributeValue) (size int64) {
+	for name, av := range item {
+		size += int64(len(name)) + attributeSize(av)
+	}
+
+	return
+}
+
+func attributeSize(av types.AttributeValue) (size int64) {
+	switch av := av.(type) {
+	case *types.AttributeValueMemberS:
+		return int64(len(av.Value))
+	case *types.AttributeValueMemberB:
+		return int64(len(av.Value))
+	case *types.AttributeValueMemberN:
+		return numberSize(av.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		for _, s := range av.Value {
+			size += int64(len(s))
+		}
+	case *types.AttributeValueMemberBS:
+		for _, b := range av.Value {
+			size += int64(len(b))
+		}
+	case *types.AttributeValueMemberNS:
+		for _, n := range av.Value {
+			size += numberSize(n)
+		}
+	case *types.AttributeValueMemberL:
+		size = 3
+		for _, element := range av.Value {
+			size += 1 + attributeSize(element)
+		}
+	case *types.AttributeValueMemberM:
+		size = 3
+		for name, element := range av.Value {
+			size += 1 + int64(len(name)) + attributeSize(element)
+		}
+	}
+
+	return
+}
+
+// numberSize approximates the size of a number: one byte per two significant digits, plus one.
+func numberSize(n string) int64 {
+	digits := strings.TrimLeft(strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, strings.SplitN(strings.ToLower(n), "e", 2)[0]), "0")
+	digits = strings.TrimRight(digits, "0")
+
+	return int64((len(digits)+1)/2 + 1)
+}
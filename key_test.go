@@ -1,8 +1,11 @@
 package redimo
 
 import (
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,3 +43,205 @@ func TestBasicKey(t *testing.T) {
 	// assert.NoError(t, err)
 	// assert.True(t, len(keys) == 11)
 }
+
+func TestCountedDEL(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	_, err := c.SADD("s1", "m1", "m2")
+	assert.NoError(t, err)
+
+	_, err = c.DEL("s1")
+	assert.NoError(t, err)
+
+	count, err := c.SCARD("s1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	_, err = c.SADD("s1", "m3")
+	assert.NoError(t, err)
+
+	count, err = c.SCARD("s1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+}
+
+func TestItemSize(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"pk":  StringValue{"key"}.ToAV(),
+		"val": BytesValue{[]byte{1, 2, 3, 4}}.ToAV(),
+		"n":   IntValue{12345}.ToAV(),
+		"l":   &types.AttributeValueMemberL{Value: []types.AttributeValue{StringValue{"ab"}.ToAV()}},
+	}
+
+	assert.Equal(t, int64(2+3+3+4+1+4+1+3+1+2), itemSize(item))
+	assert.Equal(t, int64(1), numberSize("0"))
+	assert.Equal(t, int64(2), numberSize("-1.5E+10"))
+	assert.Equal(t, int64(2), numberSize("1000"))
+}
+
+func TestMemoryUsage(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SET("big", strings.Repeat("x", 1000))
+	assert.NoError(t, err)
+
+	length, err := c.STRLEN("big")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), length)
+
+	bytes, largest, err := c.MEMORYUSAGE("big")
+	assert.NoError(t, err)
+	assert.Equal(t, bytes, largest)
+	assert.True(t, largest > 1000 && largest < 1100)
+
+	_, err = c.HSET("h", map[string]Value{"f1": StringValue{"v1"}, "f2": StringValue{strings.Repeat("y", 500)}})
+	assert.NoError(t, err)
+
+	bytes, largest, err = c.MEMORYUSAGE("h")
+	assert.NoError(t, err)
+	assert.True(t, bytes > largest)
+	assert.True(t, largest > 500 && largest < MaxItemSize)
+
+	bytes, largest, err = c.MEMORYUSAGE("nothing")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), bytes)
+	assert.Equal(t, int64(0), largest)
+
+	length, err = c.STRLEN("nothing")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), length)
+}
+
+func TestCopy(t *testing.T) {
+	unchecked := newClient(t)
+	c := unchecked.CountedCardinality()
+
+	_, err := c.SET("s1", "hello")
+	assert.NoError(t, err)
+
+	copied, err := c.COPY("s1", "s2", false)
+	assert.NoError(t, err)
+	assert.True(t, copied)
+
+	val, err := c.GET("s2")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", val.String())
+
+	_, err = c.SET("s1", "world")
+	assert.NoError(t, err)
+
+	copied, err = c.COPY("s1", "s2", false)
+	assert.NoError(t, err)
+	assert.False(t, copied)
+
+	copied, err = c.COPY("s1", "s2", true)
+	assert.NoError(t, err)
+	assert.True(t, copied)
+
+	val, err = c.GET("s2")
+	assert.NoError(t, err)
+	assert.Equal(t, "world", val.String())
+
+	_, err = c.RPUSH("l1", "a", "b", "c")
+	assert.NoError(t, err)
+
+	copied, err = c.COPY("l1", "l2", false)
+	assert.NoError(t, err)
+	assert.True(t, copied)
+
+	_, err = c.RPUSH("l2", "d")
+	assert.NoError(t, err)
+
+	elements, err := c.LRANGE("l2", 0, -1)
+	assert.NoError(t, err)
+	assert.Len(t, elements, 4)
+	assert.Equal(t, "a", elements[0].String())
+	assert.Equal(t, "d", elements[3].String())
+
+	_, err = c.ZADD("z1", map[string]float64{"m1": 1, "m2": 2}, Flags{})
+	assert.NoError(t, err)
+
+	copied, err = c.COPY("z1", "z2", false)
+	assert.NoError(t, err)
+	assert.True(t, copied)
+
+	count, err := c.ZCARD("z2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	score, found, err := c.ZSCORE("z2", "m2")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 2.0, score)
+
+	copied, err = c.COPY("nothing", "s3", false)
+	assert.NoError(t, err)
+	assert.False(t, copied)
+}
+
+func TestCopyDerivedKeys(t *testing.T) {
+	c := newClient(t).SortedSetShards(3)
+
+	_, err := c.ZADDSHARDED("z1", map[string]float64{"m1": 1, "m2": 2, "m3": 3, "m4": 4}, Flags{})
+	assert.NoError(t, err)
+
+	copied, err := c.COPY("z1", "z2", false)
+	assert.NoError(t, err)
+	assert.True(t, copied)
+
+	count, err := c.ZCARDSHARDED("z2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), count)
+
+	queue := c.ReliableQueue("q1", time.Minute)
+
+	_, err = queue.Push("job1", "job2")
+	assert.NoError(t, err)
+
+	element, err := queue.Pop("worker")
+	assert.NoError(t, err)
+	assert.Equal(t, "job1", element.String())
+
+	copied, err = c.COPY("q1", "q2", false)
+	assert.NoError(t, err)
+	assert.True(t, copied)
+
+	processing, err := c.LRANGE(c.ReliableQueue("q2", time.Minute).processingKey("worker"), 0, -1)
+	assert.NoError(t, err)
+	assert.Len(t, processing, 1)
+	assert.Equal(t, "job1", processing[0].String())
+
+	consumers, err := c.SMEMBERS(c.ReliableQueue("q2", time.Minute).consumersKey())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"worker"}, consumers)
+}
+
+func TestCopyWithoutReplaceIsConditional(t *testing.T) {
+	c := newClient(t).TransactionActions(1)
+
+	_, err := c.GEOADD("g1", map[string]GLocation{"chennai": {13.09, 80.28}, "madurai": {9.93, 78.12}})
+	assert.NoError(t, err)
+
+	err = c.GEOFENCESET("g1", "coast", GFence{Polygon: tamilNaduCoast})
+	assert.NoError(t, err)
+
+	// Only the fences of g2 exist, so the copy starts, but can't overwrite the fence.
+	err = c.GEOFENCESET("g2", "coast", GFence{Polygon: tamilNaduCoast})
+	assert.NoError(t, err)
+
+	copied, err := c.COPY("g1", "g2", false)
+	assert.NoError(t, err)
+	assert.False(t, copied)
+
+	exists, err := c.EXISTS("g2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	copied, err = c.COPY("g1", "g2", true)
+	assert.NoError(t, err)
+	assert.True(t, copied)
+
+	locations, err := c.GEOPOS("g2", "chennai", "madurai")
+	assert.NoError(t, err)
+	assert.Len(t, locations, 2)
+}
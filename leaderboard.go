@@ -0,0 +1,122 @@
+package redimo
+
+import "math"
+
+// RankedMember is a sorted set member along with its score and its rank on a leaderboard.
+type RankedMember struct {
+	Rank   int32
+	Member string
+	Score  float64
+}
+
+// LeaderboardTop returns the count members with the highest scores in the sorted set at key, highest first.
+// The member with the highest score has rank 0, like in ZREVRANK. Members with equal scores get consecutive ranks.
+//
+// Cost is O(count) – only the returned members are read from the score index.
+func (c Client) LeaderboardTop(key string, count int32) (entries []RankedMember, err error) {
+	if count <= 0 {
+		return
+	}
+
+	membersWithScores, err := c.zGeneralRangeOrdered(key, negInf, posInf, 0, count, false, c.sortKeyNum)
+
+	for i, ms := range membersWithScores {
+		entries = append(entries, RankedMember{Rank: int32(i), Member: ms.Member, Score: ms.Score})
+	}
+
+	return
+}
+
+// LeaderboardAround returns member along with up to count members directly above it (with higher scores) and up to
+// count members directly below it, highest score first. If member is not in the sorted set, found is false.
+//
+// Only the members in the window and the members sharing the score of member are read from the score index. The
+// rank of the window is found by counting the members with higher scores, which reads their keys from the index
+// like ZREVRANK does, so the cost grows with the rank of member.
+func (c Client) LeaderboardAround(key string, member string, count int32) (entries []RankedMember, found bool, err error) {
+	score, found, err := c.ZSCORE(key, member)
+	if err != nil || !found {
+		return
+	}
+
+	if count < 0 {
+		count = 0
+	}
+
+	var higher, ties, lower []MemberScore
+
+	if count > 0 {
+		higher, err = c.zGeneralRangeOrdered(key, zScore{ExclusiveMin(score)}, posInf, 0, count, true, c.sortKeyNum)
+		if err != nil {
+			return
+		}
+
+		lower, err = c.zGeneralRangeOrdered(key, negInf, zScore{ExclusiveMax(score)}, 0, count, false, c.sortKeyNum)
+		if err != nil {
+			return
+		}
+	}
+
+	ties, err = c.zGeneralRangeOrdered(key, zScore{score}, zScore{score}, 0, 0, false, c.sortKeyNum)
+	if err != nil {
+		return
+	}
+
+	aboveCount, err := c.zGeneralCount(key, zScore{ExclusiveMin(score)}, posInf, c.sortKeyNum)
+	if err != nil {
+		return
+	}
+
+	reverseMemberScores(higher)
+
+	window := make([]MemberScore, 0, len(higher)+len(ties)+len(lower))
+	window = append(window, higher...)
+	window = append(window, ties...)
+	window = append(window, lower...)
+
+	position := len(higher)
+
+	for i, ms := range ties {
+		if ms.Member == member {
+			position = len(higher) + i
+			break
+		}
+	}
+
+	start := position - int(count)
+	if start < 0 {
+		start = 0
+	}
+
+	stop := position + int(count) + 1
+	if stop > len(window) {
+		stop = len(window)
+	}
+
+	firstRank := aboveCount - int32(len(higher))
+
+	for i := start; i < stop; i++ {
+		entries = append(entries, RankedMember{Rank: firstRank + int32(i), Member: window[i].Member, Score: window[i].Score})
+	}
+
+	return entries, true, nil
+}
+
+// LeaderboardPercentile returns the percentage of members in the sorted set at key that have a lower score than
+// the given score, between 0 and 100. An empty or missing sorted set has a percentile of 0 for every score.
+//
+// The members below the score are counted from the score index and the total comes from ZCARD, so the cost grows
+// with the number of members below the score – use CountedCardinality to make the total O(1).
+func (c Client) LeaderboardPercentile(key string, score float64) (percentile float64, err error) {
+	total, err := c.cardinality(key)
+	if err != nil || total == 0 {
+		return
+	}
+
+	below, err := c.zGeneralCount(key, negInf, zScore{ExclusiveMax(score)}, c.sortKeyNum)
+	if err != nil {
+		return
+	}
+
+	return math.Min(100, float64(below)*100/float64(total)), nil
+}
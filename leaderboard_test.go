@@ -0,0 +1,73 @@
+package redimo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboard(t *testing.T) {
+	c := newClient(t)
+
+	membersWithScores := make(map[string]float64)
+	for i := 1; i <= 10; i++ {
+		membersWithScores[fmt.Sprintf("p%02d", i)] = float64(i * 10)
+	}
+
+	_, err := c.ZADD("board", membersWithScores, Flags{})
+	assert.NoError(t, err)
+
+	entries, err := c.LeaderboardTop("board", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankedMember{
+		{Rank: 0, Member: "p10", Score: 100},
+		{Rank: 1, Member: "p09", Score: 90},
+		{Rank: 2, Member: "p08", Score: 80},
+	}, entries)
+
+	entries, found, err := c.LeaderboardAround("board", "p05", 2)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []RankedMember{
+		{Rank: 3, Member: "p07", Score: 70},
+		{Rank: 4, Member: "p06", Score: 60},
+		{Rank: 5, Member: "p05", Score: 50},
+		{Rank: 6, Member: "p04", Score: 40},
+		{Rank: 7, Member: "p03", Score: 30},
+	}, entries)
+
+	entries, found, err = c.LeaderboardAround("board", "p09", 2)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []RankedMember{
+		{Rank: 0, Member: "p10", Score: 100},
+		{Rank: 1, Member: "p09", Score: 90},
+		{Rank: 2, Member: "p08", Score: 80},
+		{Rank: 3, Member: "p07", Score: 70},
+	}, entries)
+
+	entries, found, err = c.LeaderboardAround("board", "p01", 1)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []RankedMember{
+		{Rank: 8, Member: "p02", Score: 20},
+		{Rank: 9, Member: "p01", Score: 10},
+	}, entries)
+
+	_, found, err = c.LeaderboardAround("board", "nobody", 1)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	percentile, err := c.LeaderboardPercentile("board", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(40), percentile)
+
+	percentile, err = c.LeaderboardPercentile("board", 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), percentile)
+
+	percentile, err = c.LeaderboardPercentile("empty", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), percentile)
+}
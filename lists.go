@@ -3,9 +3,9 @@ package redimo
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -19,6 +19,9 @@ const (
 	ListSKIndexCount = "index_count"
 )
 
+// ErrIndexOutOfRange is returned by LSET when there is no element at the given index.
+var ErrIndexOutOfRange = errors.New("index is out of range")
+
 type LSide string
 
 const (
@@ -36,7 +39,18 @@ func (c Client) LINDEX(key string, index int64) (element ReturnValue, err error)
 	return elements[0], nil
 }
 
+// LLEN returns the length of the list at key. By default the elements are counted with a query. With
+// CountedCardinality every push and pop also updates a maintained length, which is read instead.
+//
+// Cost is O(N) / 1 RCU per 4 KB of elements, or O(1) / 1 RCU with CountedCardinality.
+//
+// Works similar to https://redis.io/commands/llen
 func (c Client) LLEN(key string) (length int64, err error) {
+	if c.counted(key) {
+		count, err := c.cardinality(key)
+		return int64(count), err
+	}
+
 	count, err := c.lLen(key)
 	return int64(count), err
 }
@@ -49,29 +63,44 @@ func (c Client) LPOP(key string) (element ReturnValue, err error) {
 	}
 
 	// delete item 0
-	_, err = c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
-		Key:       keyDef{pk: key, sk: items[0][c.sortKey].(*types.AttributeValueMemberS).Value}.toAV(c),
-		TableName: aws.String(c.tableName),
+	result, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		Key:          keyDef{pk: key, sk: items[0][c.sortKey].(*types.AttributeValueMemberS).Value}.toAV(c),
+		ReturnValues: types.ReturnValueAllOld,
+		TableName:    aws.String(c.tableName),
 	})
 
 	if err != nil {
 		return element, err
 	}
 
+	if len(result.Attributes) > 0 {
+		err = c.adjustCardinality(key, -1)
+	}
+
 	element = ReturnValue{
 		av: items[0][vk],
 	}
 	return
 }
 
+// createLeftIndex reserves the index of an element pushed to the front of the list at key. Elements are ordered by
+// their index, and the front and back of a list each have their own counter in the _redimo/<key> metadata hash, which
+// only ever moves away from the other end. Existing elements are never renumbered, so a push is a single atomic ADD
+// on the counter of its end, and pushes to either end never contend with each other.
 func (c Client) createLeftIndex(key string) (index int64, err error) {
-	v, err := c.HINCRBY(fmt.Sprintf("_redimo/%v", key), ListSKIndexLeft, -1)
-	return int64(v), err
+	return c.createIndex(key, ListSKIndexLeft, -1)
 }
 
+// createRightIndex reserves the index of an element pushed to the back of the list at key, see createLeftIndex.
 func (c Client) createRightIndex(key string) (index int64, err error) {
-	v, err := c.HINCRBY(fmt.Sprintf("_redimo/%v", key), ListSKIndexRight, 1)
-	return int64(v), err
+	return c.createIndex(key, ListSKIndexRight, 1)
+}
+
+// createIndex moves a list counter with an atomic ADD on its own item. The counters bypass compact hashes, which
+// would keep both counters in one item and make pushes to the two ends conflict.
+func (c Client) createIndex(key string, field string, delta int64) (index int64, err error) {
+	v, err := c.incrItem(keyDef{pk: metadataKey(key), sk: field}, IntValue{delta})
+	return v.Int(), err
 }
 
 func (c Client) lLen(key string) (count int32, err error) {
@@ -149,7 +178,7 @@ func (c Client) lPush(key string, left bool, elements ...interface{}) (newLength
 		builder.updateSetAV(c.sortKeyNum, zScore{float64(score)}.ToAV())
 		builder.updateSetAV(vk, e.(StringValue).ToAV())
 
-		_, err = c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
 			ConditionExpression:       builder.conditionExpression(),
 			ExpressionAttributeNames:  builder.expressionAttributeNames(),
 			ExpressionAttributeValues: builder.expressionAttributeValues(),
@@ -166,6 +195,12 @@ func (c Client) lPush(key string, left bool, elements ...interface{}) (newLength
 		if err != nil {
 			return length + int64(index), err
 		}
+
+		if len(resp.Attributes) == 0 {
+			if err = c.adjustCardinality(key, 1); err != nil {
+				return length + int64(index), err
+			}
+		}
 	}
 
 	return length + int64(len(vElements)), nil
@@ -175,6 +210,187 @@ func (c Client) RPUSH(key string, elements ...interface{}) (newLength int64, err
 	return c.lPush(key, false, elements...)
 }
 
+// LPUSHBULK is a bulk loading alternative to LPUSH for large numbers of elements. The indexes of all the elements are
+// reserved with a single update of the counter of the front of the list, and the elements are then written with
+// BatchWriteItem in chunks of 25, with up to concurrency chunks in flight at the same time. The list ends up the same
+// as after LPUSH, with the last element at the front.
+//
+// The load is not atomic – if an error is returned, some of the elements may have been written, and the maintained
+// length of CountedCardinality has to be rebuilt with RepairCardinality.
+//
+// Cost is O(N) / 1 WCU for the index + 1 WCU per element.
+func (c Client) LPUSHBULK(key string, elements []interface{}, concurrency int) (newLength int64, err error) {
+	return c.lPushBulk(key, true, elements, concurrency)
+}
+
+// RPUSHBULK is a bulk loading alternative to RPUSH for large numbers of elements, see LPUSHBULK.
+func (c Client) RPUSHBULK(key string, elements []interface{}, concurrency int) (newLength int64, err error) {
+	return c.lPushBulk(key, false, elements, concurrency)
+}
+
+func (c Client) lPushBulk(key string, left bool, elements []interface{}, concurrency int) (newLength int64, err error) {
+	vElements, err := ToValuesE(elements)
+	if err != nil {
+		return 0, err
+	}
+
+	length, err := c.LLEN(key)
+	if err != nil || len(vElements) == 0 {
+		return length, err
+	}
+
+	n := int64(len(vElements))
+
+	// The elements take the indexes first through first + n - 1, in the order LPUSH or RPUSH would have given them.
+	var first, step int64
+
+	if left {
+		last, err := c.createIndex(key, ListSKIndexLeft, -n)
+		if err != nil {
+			return length, err
+		}
+
+		first, step = last+n-1, -1
+	} else {
+		last, err := c.createIndex(key, ListSKIndexRight, n)
+		if err != nil {
+			return length, err
+		}
+
+		first, step = last-n+1, 1
+	}
+
+	requests := make([]types.WriteRequest, 0, n)
+
+	for i, e := range vElements {
+		index := first + int64(i)*step
+
+		item := keyDef{pk: key, sk: genSk(ReturnValue{e.ToAV()}.String(), index)}.toAV(c)
+		item[c.sortKeyNum] = zScore{float64(index)}.ToAV()
+		item[vk] = e.ToAV()
+
+		requests = append(requests, putRequest(item))
+	}
+
+	if err = c.batchWriteParallel(requests, concurrency); err != nil {
+		return length, err
+	}
+
+	return length + n, c.adjustCardinality(key, int(n))
+}
+
+// LPUSHCAPPED pushes elements to the front of the list at key like LPUSH, and trims the list to its first maxLen
+// elements as part of each push, so that bounded lists like recent activity never need a separate LTRIM. A maxLen
+// below 1 doesn't cap the list.
+//
+// Each element is put in the same transaction that deletes the elements pushed beyond maxLen, with the deletes
+// conditional on the elements still being there, so concurrent capped pushes to the same list never trim more than
+// they should. Each push trims up to the transaction size of the client, and a list that was longer than that to
+// begin with is trimmed further with LTRIM. The cap is applied to the list as read from the index, so elements
+// pushed concurrently can leave the list over maxLen until the next capped push.
+//
+// Cost is O(1) / 1 RCU + 1 WCU for the index + 2 WCU for each element pushed and each element trimmed.
+func (c Client) LPUSHCAPPED(key string, maxLen int64, elements ...interface{}) (newLength int64, err error) {
+	return c.lPushCapped(key, true, maxLen, elements...)
+}
+
+// RPUSHCAPPED pushes elements to the back of the list at key like RPUSH, and trims the list to its last maxLen
+// elements as part of each push, see LPUSHCAPPED.
+func (c Client) RPUSHCAPPED(key string, maxLen int64, elements ...interface{}) (newLength int64, err error) {
+	return c.lPushCapped(key, false, maxLen, elements...)
+}
+
+func (c Client) lPushCapped(key string, left bool, maxLen int64, elements ...interface{}) (newLength int64, err error) {
+	if maxLen < 1 {
+		return c.lPush(key, left, elements...)
+	}
+
+	vElements, err := ToValuesE(elements)
+	if err != nil {
+		return 0, err
+	}
+
+	newLength, err = c.LLEN(key)
+	if err != nil {
+		return
+	}
+
+	for _, e := range vElements {
+		var overflow []map[string]types.AttributeValue
+
+		err = c.retryPolicy.retry(func() (done bool, err error) {
+			_, overflow, err = c.lGeneralRangeWithItems_(key, maxLen-1, int64(c.transactionActions-1), left, c.sortKeyNum)
+			if err != nil {
+				return true, err
+			}
+
+			var index int64
+
+			if left {
+				index, err = c.createLeftIndex(key)
+			} else {
+				index, err = c.createRightIndex(key)
+			}
+
+			if err != nil {
+				return true, err
+			}
+
+			builder := newExpresionBuilder()
+			builder.addConditionNotExists(c.partitionKey)
+
+			item := keyDef{pk: key, sk: genSk(ReturnValue{e.ToAV()}.String(), index)}.toAV(c)
+			item[c.sortKeyNum] = zScore{float64(index)}.ToAV()
+			item[vk] = e.ToAV()
+
+			actions := append([]types.TransactWriteItem{{
+				Put: &types.Put{
+					ConditionExpression:      builder.conditionExpression(),
+					ExpressionAttributeNames: builder.expressionAttributeNames(),
+					Item:                     item,
+					TableName:                aws.String(c.tableName),
+				},
+			}}, c.lDeleteIfExistsActions(key, overflow)...)
+
+			_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+				TransactItems: actions,
+			})
+			if conditionFailureError(err) {
+				return false, nil
+			}
+
+			if err != nil {
+				return true, err
+			}
+
+			return true, c.adjustCardinality(key, 1-len(overflow))
+		})
+		if err != nil {
+			return
+		}
+
+		if newLength++; newLength > maxLen {
+			newLength = maxLen
+		}
+
+		if len(overflow) < c.transactionActions-1 {
+			continue
+		}
+
+		if left {
+			newLength, err = c.LTRIM(key, 0, maxLen-1)
+		} else {
+			newLength, err = c.LTRIM(key, -maxLen, -1)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 func (c Client) lRange(key string, start int64, end int64, forward bool) (elements []ReturnValue, err error) {
 	llen, err := c.LLEN(key)
 	if err != nil {
@@ -381,6 +597,52 @@ func (c Client) LRANGE(key string, start, stop int64) (elements []ReturnValue, e
 	return c.lRange(key, start, stop, true)
 }
 
+// LRANGESCAN iterates over the elements of the list at key from front to back, returning up to count elements per
+// call along with a cursor for the next call, so that long lists can be exported or processed without loading them
+// at once. Start with the ScanStart cursor; when the returned cursor is ScanStart again, the iteration is complete.
+//
+// The cursor is an opaque string that wraps the DynamoDB pagination key, so it can be stored and used to resume the
+// iteration later, even from another process. Elements pushed to the back of the list during the iteration are
+// returned, elements pushed to the front aren't, and elements popped before they're reached are skipped. A call may
+// return fewer than count elements (even none) while the cursor is not yet ScanStart.
+//
+// Cost is O(count) per call.
+func (c Client) LRANGESCAN(key string, cursor string, count int32) (elements []ReturnValue, nextCursor string, err error) {
+	exclusiveStartKey, err := c.decodeKeyCursor(key, cursor)
+	if err != nil {
+		return
+	}
+
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{key})
+
+	var queryLimit *int32
+	if count > 0 {
+		queryLimit = aws.Int32(count)
+	}
+
+	resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+		ConsistentRead:            aws.Bool(c.consistentReads),
+		ExclusiveStartKey:         exclusiveStartKey,
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		IndexName:                 aws.String(c.indexName),
+		KeyConditionExpression:    builder.conditionExpression(),
+		Limit:                     queryLimit,
+		ScanIndexForward:          aws.Bool(true),
+		TableName:                 aws.String(c.tableName),
+	})
+	if err != nil {
+		return elements, cursor, err
+	}
+
+	for _, item := range resp.Items {
+		elements = append(elements, ReturnValue{item[vk]})
+	}
+
+	return elements, encodeCursor(resp.LastEvaluatedKey), nil
+}
+
 func (c Client) RPOP(key string) (element ReturnValue, err error) {
 	_, items, err := c.lGeneralRangeWithItems(key, 0, 1, false, c.sortKeyNum)
 
@@ -406,6 +668,8 @@ func (c Client) RPOP(key string) (element ReturnValue, err error) {
 	}
 
 	element = parseItem(items[0], c).val
+	err = c.adjustCardinality(key, -1)
+
 	return
 }
 
@@ -429,68 +693,302 @@ func (c Client) RPUSHX(key string, elements ...interface{}) (newLength int64, er
 	return c.RPUSH(key, elements...)
 }
 
+// RPOPLPUSH atomically pops the last element of the list at sourceKey and pushes it to the front of the list at
+// destinationKey, like LMOVE(sourceKey, destinationKey, Right, Left).
+//
+// Works similar to https://redis.io/commands/rpoplpush
 func (c Client) RPOPLPUSH(sourceKey string, destinationKey string) (element ReturnValue, err error) {
-	element, err = c.RPOP(sourceKey)
+	return c.LMOVE(sourceKey, destinationKey, Right, Left)
+}
 
-	if err != nil {
-		return element, err
-	}
+// LMOVE atomically pops the element at sourceSide of the list at sourceKey, pushes it to destinationSide of the list
+// at destinationKey, and returns it. The source and destination can be the same list, which rotates it. Returns an
+// Empty() ReturnValue if the source list is empty.
+//
+// The element is removed and added in a single transaction, with the delete conditional on the element still being
+// there, so an element is never lost or handed to two callers even when several clients move elements out of the
+// same list concurrently. If another client takes the element first, the next element is tried, as allowed by the
+// RetryPolicy of the client.
+//
+// Cost is O(1) / 1 RCU + 1 WCU for the destination index + 4 WCU for the transaction.
+//
+// Works similar to https://redis.io/commands/lmove
+func (c Client) LMOVE(sourceKey string, destinationKey string, sourceSide LSide, destinationSide LSide) (element ReturnValue, err error) {
+	return c.lMove(sourceKey, destinationKey, sourceSide, destinationSide, nil)
+}
 
-	_, err = c.LPUSH(destinationKey, StringValue{element.String()})
+// BLMOVE is the blocking version of LMOVE. If the list at sourceKey is empty it keeps polling it according to the
+// given PollPolicy until an element can be moved or the context ends. Each attempt is an atomic LMOVE, so consumers
+// blocking on the same list never receive the same element.
+//
+// If the context deadline passes before anything could be moved, the returned element is Empty() and err is nil,
+// similar to the Redis timeout reply. If the context is cancelled, the context error is returned.
+//
+// Works similar to https://redis.io/commands/blmove
+func (c Client) BLMOVE(ctx context.Context, poll PollPolicy, sourceKey string, destinationKey string,
+	sourceSide LSide, destinationSide LSide) (element ReturnValue, err error) {
+	return c.bLMove(ctx, poll, sourceKey, destinationKey, sourceSide, destinationSide, nil)
+}
 
-	if err != nil {
-		return element, err
-	}
+// BRPOPLPUSH is the blocking version of RPOPLPUSH, like BLMOVE(ctx, poll, sourceKey, destinationKey, Right, Left).
+//
+// Works similar to https://redis.io/commands/brpoplpush
+func (c Client) BRPOPLPUSH(ctx context.Context, poll PollPolicy, sourceKey string, destinationKey string) (element ReturnValue, err error) {
+	return c.BLMOVE(ctx, poll, sourceKey, destinationKey, Right, Left)
+}
+
+func (c Client) bLMove(ctx context.Context, poll PollPolicy, sourceKey string, destinationKey string,
+	sourceSide LSide, destinationSide LSide, attributes map[string]types.AttributeValue) (element ReturnValue, err error) {
+	_, err = poll.poll(ctx, func() (bool, error) {
+		element, err = c.lMove(sourceKey, destinationKey, sourceSide, destinationSide, attributes)
+
+		return !element.Empty(), err
+	})
 
 	return
 }
 
-func (c Client) LSET(key string, index int64, element string) (ok bool, err error) {
-	// get the element at the index
-	_, items, err := c.lGeneralRangeWithItems(key, index, 1, true, c.sortKeyNum)
+// lMove implements LMOVE, storing attributes along with the element at the destination.
+func (c Client) lMove(sourceKey string, destinationKey string, sourceSide LSide, destinationSide LSide,
+	attributes map[string]types.AttributeValue) (element ReturnValue, err error) {
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		_, items, err := c.lGeneralRangeWithItems_(sourceKey, 0, 1, sourceSide == Left, c.sortKeyNum)
+		if err != nil || len(items) == 0 {
+			return true, err
+		}
 
-	if err != nil || len(items) == 0 {
-		return false, err
-	}
+		var index int64
+
+		if destinationSide == Left {
+			index, err = c.createLeftIndex(destinationKey)
+		} else {
+			index, err = c.createRightIndex(destinationKey)
+		}
 
-	item := items[0]
-	skn := item[c.sortKeyNum].(*types.AttributeValueMemberN).Value
+		if err != nil {
+			return true, err
+		}
 
-	sknn, err := strconv.ParseInt(skn, 10, 64)
-	if err != nil {
-		panic(err)
-	}
+		deleteBuilder := newExpresionBuilder()
+		deleteBuilder.addConditionExists(c.partitionKey)
 
-	// delete old
-	_, err = c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
-		Key:       keyDef{pk: key, sk: item[c.sortKey].(*types.AttributeValueMemberS).Value}.toAV(c),
-		TableName: aws.String(c.tableName),
+		putBuilder := newExpresionBuilder()
+		putBuilder.addConditionNotExists(c.partitionKey)
+
+		val := items[0][vk]
+		destination := keyDef{pk: destinationKey, sk: genSk(ReturnValue{val}.String(), index)}.toAV(c)
+		destination[c.sortKeyNum] = zScore{float64(index)}.ToAV()
+		destination[vk] = val
+
+		for name, av := range attributes {
+			destination[name] = av
+		}
+
+		_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{
+					Delete: &types.Delete{
+						ConditionExpression:      deleteBuilder.conditionExpression(),
+						ExpressionAttributeNames: deleteBuilder.expressionAttributeNames(),
+						Key:                      keyDef{pk: sourceKey, sk: parseKey(items[0], c).sk}.toAV(c),
+						TableName:                aws.String(c.tableName),
+					},
+				},
+				{
+					Put: &types.Put{
+						ConditionExpression:      putBuilder.conditionExpression(),
+						ExpressionAttributeNames: putBuilder.expressionAttributeNames(),
+						Item:                     destination,
+						TableName:                aws.String(c.tableName),
+					},
+				},
+			},
+		})
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		if err != nil {
+			return true, err
+		}
+
+		element = ReturnValue{val}
+
+		if err = c.adjustCardinality(sourceKey, -1); err != nil {
+			return true, err
+		}
+
+		return true, c.adjustCardinality(destinationKey, 1)
 	})
 
-	// add new
-	builder := newExpresionBuilder()
-	builder.updateSetAV(c.sortKeyNum, zScore{float64(sknn)}.ToAV())
-	builder.updateSetAV(vk, StringValue{element}.ToAV())
+	return
+}
 
-	if err != nil {
-		return false, err
+// LMPOP pops up to count elements from side of the first list among keys that isn't empty, checking the keys in
+// order, and returns the key it popped from along with the elements in the order they were popped. If all the lists
+// are empty, key is empty and no elements are returned. A count below 1 pops a single element.
+//
+// Elements are claimed with conditional deletes in transactions of up to the client's transaction size, so
+// concurrent callers never receive the same element. If another client takes some of the elements first, the next
+// ones are read again, as allowed by the RetryPolicy of the client.
+//
+// Works similar to https://redis.io/commands/lmpop
+func (c Client) LMPOP(keys []string, side LSide, count int64) (key string, elements []ReturnValue, err error) {
+	if count < 1 {
+		count = 1
+	}
+
+	for _, k := range keys {
+		elements, err = c.lPop(k, side, count)
+		if err != nil || len(elements) > 0 {
+			return k, elements, err
+		}
 	}
 
-	_, err = c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-		ConditionExpression:       builder.conditionExpression(),
-		ExpressionAttributeNames:  builder.expressionAttributeNames(),
-		ExpressionAttributeValues: builder.expressionAttributeValues(),
-		Key:                       keyDef{pk: key, sk: genSk(element, sknn)}.toAV(c),
-		ReturnValues:              types.ReturnValueAllOld,
-		TableName:                 aws.String(c.tableName),
-		UpdateExpression:          builder.updateExpression(),
+	return "", elements, nil
+}
+
+func (c Client) lPop(key string, side LSide, count int64) (elements []ReturnValue, err error) {
+	elements = make([]ReturnValue, 0)
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		_, items, err := c.lGeneralRangeWithItems_(key, 0, count-int64(len(elements)), side == Left, c.sortKeyNum)
+		if err != nil || len(items) == 0 {
+			return true, err
+		}
+
+		for len(items) > 0 {
+			chunk := items
+			if len(chunk) > c.transactionChunk() {
+				chunk = chunk[:c.transactionChunk()]
+			}
+
+			items = items[len(chunk):]
+
+			_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+				TransactItems: c.lDeleteIfExistsActions(key, chunk),
+			})
+			if conditionFailureError(err) {
+				return false, nil
+			}
+
+			if err != nil {
+				return true, err
+			}
+
+			for _, item := range chunk {
+				elements = append(elements, ReturnValue{item[vk]})
+			}
+
+			if err = c.adjustCardinality(key, -len(chunk)); err != nil {
+				return true, err
+			}
+		}
+
+		return true, nil
 	})
 
-	if err != nil {
-		return false, nil
+	return
+}
+
+func (c Client) lDeleteIfExistsActions(key string, items []map[string]types.AttributeValue) []types.TransactWriteItem {
+	actions := make([]types.TransactWriteItem, len(items))
+
+	for i, item := range items {
+		builder := newExpresionBuilder()
+		builder.addConditionExists(c.partitionKey)
+
+		actions[i] = types.TransactWriteItem{
+			Delete: &types.Delete{
+				ConditionExpression:      builder.conditionExpression(),
+				ExpressionAttributeNames: builder.expressionAttributeNames(),
+				Key:                      keyDef{pk: key, sk: parseKey(item, c).sk}.toAV(c),
+				TableName:                aws.String(c.tableName),
+			},
+		}
 	}
 
-	return true, err
+	return actions
+}
+
+// LSET replaces the element at index of the list at key with element. Negative indexes count from the back of the
+// list, so -1 is the last element. Returns ErrIndexOutOfRange if there is no element at index.
+//
+// Elements are stored under a sort key that includes their value, so the old element is deleted and the new one put
+// at the same position in a single transaction, with the delete conditional on the old element still being there. If
+// another client removes or replaces the element first, the index is resolved again, as allowed by the RetryPolicy
+// of the client.
+//
+// Cost is O(index) to find the element + 4 WCU.
+//
+// Works similar to https://redis.io/commands/lset
+func (c Client) LSET(key string, index int64, element string) (ok bool, err error) {
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		var items []map[string]types.AttributeValue
+
+		if index < 0 {
+			_, items, err = c.lGeneralRangeWithItems_(key, -index-1, 1, false, c.sortKeyNum)
+		} else {
+			_, items, err = c.lGeneralRangeWithItems_(key, index, 1, true, c.sortKeyNum)
+		}
+
+		if err != nil {
+			return true, err
+		}
+
+		if len(items) == 0 {
+			return true, ErrIndexOutOfRange
+		}
+
+		position := int64(zScoreFromAV(items[0][c.sortKeyNum]))
+		oldSk := parseKey(items[0], c).sk
+		newSk := genSk(element, position)
+
+		if oldSk == newSk {
+			ok = true
+			return true, nil
+		}
+
+		deleteBuilder := newExpresionBuilder()
+		deleteBuilder.addConditionExists(c.partitionKey)
+
+		putBuilder := newExpresionBuilder()
+		putBuilder.addConditionNotExists(c.partitionKey)
+
+		item := keyDef{pk: key, sk: newSk}.toAV(c)
+		item[c.sortKeyNum] = zScore{float64(position)}.ToAV()
+		item[vk] = StringValue{element}.ToAV()
+
+		_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{
+					Delete: &types.Delete{
+						ConditionExpression:      deleteBuilder.conditionExpression(),
+						ExpressionAttributeNames: deleteBuilder.expressionAttributeNames(),
+						Key:                      keyDef{pk: key, sk: oldSk}.toAV(c),
+						TableName:                aws.String(c.tableName),
+					},
+				},
+				{
+					Put: &types.Put{
+						ConditionExpression:      putBuilder.conditionExpression(),
+						ExpressionAttributeNames: putBuilder.expressionAttributeNames(),
+						Item:                     item,
+						TableName:                aws.String(c.tableName),
+					},
+				},
+			},
+		})
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		ok = err == nil
+
+		return true, err
+	})
+
+	return
 }
 
 func (c Client) lGeneralRangeWithItemsByMember(key string,
@@ -646,12 +1144,7 @@ func (c Client) LREM(key string, count int64, element interface{}) (newLength in
 	for i := int64(0); i < count; i++ {
 		item := items[i]
 
-		_, err = c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
-			Key:       keyDef{pk: key, sk: item[c.sortKey].(*types.AttributeValueMemberS).Value}.toAV(c),
-			TableName: aws.String(c.tableName),
-		})
-
-		if err != nil {
+		if err = c.lDeleteItem(key, item); err != nil {
 			return 0, false, err
 		}
 	}
@@ -664,6 +1157,20 @@ func (c Client) LREM(key string, count int64, element interface{}) (newLength in
 	return newLength, true, nil
 }
 
+// lDeleteItem deletes an element of the list at key, and updates the maintained length if it was still there.
+func (c Client) lDeleteItem(key string, item map[string]types.AttributeValue) error {
+	resp, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		Key:          keyDef{pk: key, sk: item[c.sortKey].(*types.AttributeValueMemberS).Value}.toAV(c),
+		ReturnValues: types.ReturnValueAllOld,
+		TableName:    aws.String(c.tableName),
+	})
+	if err != nil || len(resp.Attributes) == 0 {
+		return err
+	}
+
+	return c.adjustCardinality(key, -1)
+}
+
 func (c Client) normalizeStartStop(llen int64, start int64, stop int64) (int64, int64) {
 	end := stop
 
@@ -713,12 +1220,7 @@ func (c Client) lDelete(key string, start int64, stop int64) (newLength int64, e
 	removeCount := int64(0)
 
 	for _, item := range items {
-		_, err = c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
-			Key:       keyDef{pk: key, sk: item[c.sortKey].(*types.AttributeValueMemberS).Value}.toAV(c),
-			TableName: aws.String(c.tableName),
-		})
-
-		if err != nil {
+		if err = c.lDeleteItem(key, item); err != nil {
 			return llen - removeCount, err
 		}
 
@@ -1,7 +1,12 @@
 package redimo
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -195,6 +200,329 @@ func TestRPOPLPUSH(t *testing.T) {
 	assert.Equal(t, []string{"two"}, readStrings(elements))
 }
 
+func TestLMOVE(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.RPUSH("l1", StringValue{"one"}, StringValue{"two"}, StringValue{"three"})
+	assert.NoError(t, err)
+
+	element, err := c.LMOVE("l1", "l2", Left, Right)
+	assert.NoError(t, err)
+	assert.Equal(t, "one", element.String())
+
+	element, err = c.LMOVE("l1", "l2", Left, Left)
+	assert.NoError(t, err)
+	assert.Equal(t, "two", element.String())
+
+	element, err = c.LMOVE("l1", "l1", Right, Left)
+	assert.NoError(t, err)
+	assert.Equal(t, "three", element.String())
+
+	elements, err := c.LRANGE("l2", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"two", "one"}, readStrings(elements))
+
+	element, err = c.LMOVE("nosuchlist", "l2", Left, Right)
+	assert.NoError(t, err)
+	assert.True(t, element.Empty())
+}
+
+func TestConcurrentLMOVE(t *testing.T) {
+	c := newClient(t).RetryPolicy(RetryPolicy{MaxAttempts: 20, Jitter: 1})
+
+	for i := 0; i < 20; i++ {
+		_, err := c.RPUSH("source", StringValue{fmt.Sprintf("job%d", i)})
+		assert.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			for {
+				element, err := c.LMOVE("source", fmt.Sprintf("worker%d", worker), Left, Right)
+				if err != nil && err != ErrTooMuchContention {
+					assert.NoError(t, err)
+					return
+				}
+
+				if err == nil && element.Empty() {
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	seen := make(map[string]bool)
+
+	for i := 0; i < 4; i++ {
+		elements, err := c.LRANGE(fmt.Sprintf("worker%d", i), 0, -1)
+		assert.NoError(t, err)
+
+		for _, element := range readStrings(elements) {
+			assert.False(t, seen[element], element)
+			seen[element] = true
+		}
+	}
+
+	assert.Len(t, seen, 20)
+}
+
+func TestConcurrentPushes(t *testing.T) {
+	c := newClient(t).CompactHashes()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			for j := 0; j < 5; j++ {
+				_, err := c.LPUSH("l1", StringValue{fmt.Sprintf("left%d-%d", worker, j)})
+				assert.NoError(t, err)
+
+				_, err = c.RPUSH("l1", StringValue{fmt.Sprintf("right%d-%d", worker, j)})
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	elements, err := c.LRANGE("l1", 0, -1)
+	assert.NoError(t, err)
+	assert.Len(t, elements, 40)
+
+	for i, element := range readStrings(elements) {
+		assert.Equal(t, i < 20, strings.HasPrefix(element, "left"), element)
+	}
+}
+
+func TestBLMOVE(t *testing.T) {
+	c := newClient(t)
+	policy := PollPolicy{Interval: 10 * time.Millisecond, MaxInterval: 50 * time.Millisecond, Backoff: 2}
+
+	_, err := c.RPUSH("source", StringValue{"one"})
+	assert.NoError(t, err)
+
+	element, err := c.BLMOVE(context.Background(), policy, "source", "destination", Left, Right)
+	assert.NoError(t, err)
+	assert.Equal(t, "one", element.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	element, err = c.BRPOPLPUSH(ctx, policy, "source", "destination")
+	assert.NoError(t, err)
+	assert.True(t, element.Empty())
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		_, err := c.RPUSH("source", StringValue{"late"})
+		assert.NoError(t, err)
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	element, err = c.BRPOPLPUSH(ctx, policy, "source", "destination")
+	assert.NoError(t, err)
+	assert.Equal(t, "late", element.String())
+
+	elements, err := c.LRANGE("destination", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"late", "one"}, readStrings(elements))
+}
+
+func TestLMPOP(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.RPUSH("l2", StringValue{"a"}, StringValue{"b"}, StringValue{"c"})
+	assert.NoError(t, err)
+
+	_, err = c.RPUSH("l3", StringValue{"x"})
+	assert.NoError(t, err)
+
+	key, elements, err := c.LMPOP([]string{"l1", "l2", "l3"}, Left, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "l2", key)
+	assert.Equal(t, []string{"a", "b"}, readStrings(elements))
+
+	key, elements, err = c.LMPOP([]string{"l1", "l2", "l3"}, Right, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "l2", key)
+	assert.Equal(t, []string{"c"}, readStrings(elements))
+
+	key, elements, err = c.LMPOP([]string{"l1", "l2", "l3"}, Right, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "l3", key)
+	assert.Equal(t, []string{"x"}, readStrings(elements))
+
+	key, elements, err = c.LMPOP([]string{"l1", "l2", "l3"}, Left, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", key)
+	assert.Empty(t, elements)
+}
+
+func TestBulkPush(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	var left, right []interface{}
+
+	for i := 0; i < 60; i++ {
+		left = append(left, StringValue{fmt.Sprintf("left%02d", i)})
+		right = append(right, StringValue{fmt.Sprintf("right%02d", i)})
+	}
+
+	_, err := c.RPUSH("l1", StringValue{"middle"})
+	assert.NoError(t, err)
+
+	length, err := c.LPUSHBULK("l1", left, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(61), length)
+
+	length, err = c.RPUSHBULK("l1", right, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(121), length)
+
+	elements, err := c.LRANGE("l1", 0, -1)
+	assert.NoError(t, err)
+	assert.Len(t, elements, 121)
+
+	strs := readStrings(elements)
+	assert.Equal(t, "left59", strs[0])
+	assert.Equal(t, "left00", strs[59])
+	assert.Equal(t, "middle", strs[60])
+	assert.Equal(t, "right00", strs[61])
+	assert.Equal(t, "right59", strs[120])
+
+	length, err = c.RPUSHBULK("l2", []interface{}{42, 1.5, []byte("raw"), "text"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), length)
+
+	elements, err = c.LRANGE("l2", 0, -1)
+	assert.NoError(t, err)
+	assert.Len(t, elements, 4)
+	assert.Equal(t, int64(42), elements[0].Int())
+	assert.Equal(t, 1.5, elements[1].Float())
+	assert.Equal(t, []byte("raw"), elements[2].Bytes())
+	assert.Equal(t, "text", elements[3].String())
+}
+
+func TestCappedPush(t *testing.T) {
+	c := newClient(t)
+
+	for i, expected := range []int64{1, 2, 3, 3, 3} {
+		length, err := c.LPUSHCAPPED("recent", 3, StringValue{fmt.Sprintf("e%d", i)})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, length)
+	}
+
+	elements, err := c.LRANGE("recent", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"e4", "e3", "e2"}, readStrings(elements))
+
+	length, err := c.RPUSHCAPPED("recent", 2, StringValue{"last"}, StringValue{"final"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), length)
+
+	elements, err = c.LRANGE("recent", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"last", "final"}, readStrings(elements))
+
+	length, err = c.RPUSHCAPPED("recent", 0, StringValue{"uncapped"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), length)
+
+	length, err = c.LPUSHCAPPED("numbers", 2, 1, 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), length)
+
+	elements, err = c.LRANGE("numbers", 0, -1)
+	assert.NoError(t, err)
+	assert.Len(t, elements, 2)
+	assert.Equal(t, int64(3), elements[0].Int())
+	assert.Equal(t, int64(2), elements[1].Int())
+}
+
+func TestLRANGESCAN(t *testing.T) {
+	c := newClient(t)
+
+	var expected []string
+
+	for i := 0; i < 10; i++ {
+		expected = append(expected, fmt.Sprintf("e%d", i))
+
+		_, err := c.RPUSH("l1", StringValue{expected[i]})
+		assert.NoError(t, err)
+	}
+
+	var scanned []string
+
+	cursor := ScanStart
+
+	for {
+		elements, nextCursor, err := c.LRANGESCAN("l1", cursor, 3)
+		assert.NoError(t, err)
+		assert.True(t, len(elements) <= 3)
+
+		scanned = append(scanned, readStrings(elements)...)
+
+		if cursor = nextCursor; cursor == ScanStart {
+			break
+		}
+	}
+
+	assert.Equal(t, expected, scanned)
+
+	_, _, err := c.LRANGESCAN("l1", "not a cursor", 3)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestCountedListLen(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	_, err := c.RPUSH("l1", StringValue{"a"}, StringValue{"b"}, StringValue{"c"}, StringValue{"b"}, StringValue{"d"})
+	assert.NoError(t, err)
+
+	_, err = c.LPOP("l1")
+	assert.NoError(t, err)
+
+	_, err = c.RPOP("l1")
+	assert.NoError(t, err)
+
+	_, err = c.LMOVE("l1", "l2", Left, Left)
+	assert.NoError(t, err)
+
+	_, _, err = c.LREM("l1", 0, StringValue{"b"})
+	assert.NoError(t, err)
+
+	_, err = c.RPUSHCAPPED("l2", 2, StringValue{"x"}, StringValue{"y"})
+	assert.NoError(t, err)
+
+	for key, expected := range map[string]int64{"l1": 1, "l2": 2} {
+		length, err := c.LLEN(key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, length, key)
+
+		uncounted := c
+		uncounted.countedCardinality = false
+
+		length, err = uncounted.LLEN(key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, length, key)
+	}
+}
+
 func TestListIndexBasedCRUD(t *testing.T) {
 	c := newClient(t)
 
@@ -247,9 +575,17 @@ func TestListIndexBasedCRUD(t *testing.T) {
 	assert.Equal(t, "mama", element.String())
 
 	ok, err = c.LSET("l1", 42, "no chance")
-	assert.NoError(t, err)
+	assert.Equal(t, ErrIndexOutOfRange, err)
 	assert.False(t, ok)
 
+	ok, err = c.LSET("l1", -5, "no chance")
+	assert.Equal(t, ErrIndexOutOfRange, err)
+	assert.False(t, ok)
+
+	ok, err = c.LSET("l1", -1, "tinty")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
 	count, err := c.LLEN("l1")
 	assert.NoError(t, err)
 	assert.Equal(t, int64(4), count)
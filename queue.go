@@ -0,0 +1,226 @@
+package redimo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// claimedKey holds the unix time at which an element in a processing list of a ReliableQueue was popped.
+const claimedKey = "claimed"
+
+// ReliableQueue is a job queue built on lists, following the reliable queue pattern of Redis: elements are pushed
+// to the queue list, and popping an element moves it to the processing list of the consumer that popped it, where it
+// stays until the consumer acknowledges it with Ack. Elements whose consumer didn't acknowledge them within the
+// visibility timeout, because it crashed or took too long, are returned to the queue by Reclaim.
+//
+// Elements are delivered at least once: an element that is reclaimed while its consumer is still working on it is
+// delivered again, and the late Ack of the first consumer returns false.
+type ReliableQueue struct {
+	client            Client
+	key               string
+	visibilityTimeout time.Duration
+}
+
+// ReliableQueue returns the reliable queue stored in the list at key, whose elements are reclaimed if they aren't
+// acknowledged within visibilityTimeout of being popped. The processing lists of the consumers and the set of
+// consumers that have popped from the queue are stored under _redimo/<key>/.
+func (c Client) ReliableQueue(key string, visibilityTimeout time.Duration) ReliableQueue {
+	return ReliableQueue{client: c, key: key, visibilityTimeout: visibilityTimeout}
+}
+
+func (q ReliableQueue) processingKey(consumer string) string {
+	return fmt.Sprintf("_redimo/%v/processing/%v", q.key, consumer)
+}
+
+func (q ReliableQueue) consumersKey() string {
+	return fmt.Sprintf("_redimo/%v/consumers", q.key)
+}
+
+// consumers returns the consumers that have popped from the queue.
+func (q ReliableQueue) consumers() ([]string, error) {
+	return q.client.SMEMBERS(q.consumersKey())
+}
+
+// derivedKeys returns the set of consumers and the processing lists of the given consumers, which COPY copies along
+// with the queue.
+func (q ReliableQueue) derivedKeys(consumers []string) []string {
+	keys := []string{q.consumersKey()}
+
+	for _, consumer := range consumers {
+		keys = append(keys, q.processingKey(consumer))
+	}
+
+	return keys
+}
+
+// Push adds elements to the back of the queue, and returns the new length of the queue like LPUSH.
+func (q ReliableQueue) Push(elements ...interface{}) (newLength int64, err error) {
+	return q.client.LPUSH(q.key, elements...)
+}
+
+// Pop moves the element at the front of the queue to the processing list of consumer with LMOVE, and returns it.
+// Returns an Empty() ReturnValue if the queue is empty. The element has to be acknowledged with Ack once it's
+// processed.
+//
+// Cost is O(1) / 1 WCU to record the consumer + the cost of LMOVE.
+func (q ReliableQueue) Pop(consumer string) (element ReturnValue, err error) {
+	if _, err = q.client.SADD(q.consumersKey(), consumer); err != nil {
+		return
+	}
+
+	return q.client.lMove(q.key, q.processingKey(consumer), Right, Left, q.claim())
+}
+
+// claim returns the attributes stored with an element when it's popped.
+func (q ReliableQueue) claim() map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{claimedKey: IntValue{time.Now().Unix()}.ToAV()}
+}
+
+// BPop is the blocking version of Pop, which waits for an element like BLMOVE if the queue is empty.
+func (q ReliableQueue) BPop(ctx context.Context, poll PollPolicy, consumer string) (element ReturnValue, err error) {
+	if _, err = q.client.SADD(q.consumersKey(), consumer); err != nil {
+		return
+	}
+
+	return q.client.bLMove(ctx, poll, q.key, q.processingKey(consumer), Right, Left, q.claim())
+}
+
+// Ack removes element from the processing list of consumer once it has been processed. Returns false if the element
+// isn't in the processing list, which happens when it was reclaimed before it was acknowledged.
+func (q ReliableQueue) Ack(consumer string, element interface{}) (ok bool, err error) {
+	_, ok, err = q.client.LREM(q.processingKey(consumer), 1, element)
+
+	return
+}
+
+// Reclaim moves the elements that have been in a processing list for longer than the visibility timeout back to the
+// front of the queue, so that they're popped next, and returns them. Each element is moved in a transaction that is
+// conditional on it not having been acknowledged or reclaimed by another client in the meantime, so Reclaim can be
+// called by every consumer periodically.
+//
+// Cost is O(N) in the number of elements being processed, + 4 WCU for each element reclaimed.
+func (q ReliableQueue) Reclaim() (reclaimed []ReturnValue, err error) {
+	consumers, err := q.client.SMEMBERS(q.consumersKey())
+	if err != nil {
+		return
+	}
+
+	deadline := time.Now().Add(-q.visibilityTimeout).Unix()
+
+	for _, consumer := range consumers {
+		items, err := q.processingItems(consumer)
+		if err != nil {
+			return reclaimed, err
+		}
+
+		for _, item := range items {
+			if item[claimedKey] == nil || (ReturnValue{item[claimedKey]}).Int() > deadline {
+				continue
+			}
+
+			ok, err := q.reclaim(consumer, item)
+			if err != nil {
+				return reclaimed, err
+			}
+
+			if ok {
+				reclaimed = append(reclaimed, ReturnValue{item[vk]})
+			}
+		}
+	}
+
+	return
+}
+
+// processingItems reads the items of the processing list of consumer.
+func (q ReliableQueue) processingItems(consumer string) (items []map[string]types.AttributeValue, err error) {
+	c := q.client
+	hasMoreResults := true
+
+	var lastEvaluatedKey map[string]types.AttributeValue
+
+	for hasMoreResults {
+		builder := newExpresionBuilder()
+		builder.addConditionEquality(c.partitionKey, StringValue{q.processingKey(consumer)})
+
+		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+			ConsistentRead:            aws.Bool(c.consistentReads),
+			ExclusiveStartKey:         lastEvaluatedKey,
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			KeyConditionExpression:    builder.conditionExpression(),
+			TableName:                 aws.String(c.tableName),
+		})
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, resp.Items...)
+		lastEvaluatedKey = resp.LastEvaluatedKey
+		hasMoreResults = len(lastEvaluatedKey) > 0
+	}
+
+	return
+}
+
+// reclaim moves an item of the processing list of consumer back to the front of the queue, and returns false if it
+// was acknowledged or reclaimed in the meantime.
+func (q ReliableQueue) reclaim(consumer string, item map[string]types.AttributeValue) (ok bool, err error) {
+	c := q.client
+
+	index, err := c.createRightIndex(q.key)
+	if err != nil {
+		return
+	}
+
+	deleteBuilder := newExpresionBuilder()
+	deleteBuilder.addConditionEquality(claimedKey, ReturnValue{item[claimedKey]})
+
+	putBuilder := newExpresionBuilder()
+	putBuilder.addConditionNotExists(c.partitionKey)
+
+	val := item[vk]
+	destination := keyDef{pk: q.key, sk: genSk(ReturnValue{val}.String(), index)}.toAV(c)
+	destination[c.sortKeyNum] = zScore{float64(index)}.ToAV()
+	destination[vk] = val
+
+	_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Delete: &types.Delete{
+					ConditionExpression:       deleteBuilder.conditionExpression(),
+					ExpressionAttributeNames:  deleteBuilder.expressionAttributeNames(),
+					ExpressionAttributeValues: deleteBuilder.expressionAttributeValues(),
+					Key:                       keyDef{pk: q.processingKey(consumer), sk: parseKey(item, c).sk}.toAV(c),
+					TableName:                 aws.String(c.tableName),
+				},
+			},
+			{
+				Put: &types.Put{
+					ConditionExpression:      putBuilder.conditionExpression(),
+					ExpressionAttributeNames: putBuilder.expressionAttributeNames(),
+					Item:                     destination,
+					TableName:                aws.String(c.tableName),
+				},
+			},
+		},
+	})
+	if conditionFailureError(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if err = c.adjustCardinality(q.processingKey(consumer), -1); err != nil {
+		return false, err
+	}
+
+	return true, c.adjustCardinality(q.key, 1)
+}
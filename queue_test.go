@@ -0,0 +1,95 @@
+package redimo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReliableQueue(t *testing.T) {
+	c := newClient(t)
+	q := c.ReliableQueue("jobs", time.Hour)
+
+	length, err := q.Push(StringValue{"job1"}, StringValue{"job2"}, StringValue{"job3"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), length)
+
+	element, err := q.Pop("worker1")
+	assert.NoError(t, err)
+	assert.Equal(t, "job1", element.String())
+
+	element, err = q.Pop("worker2")
+	assert.NoError(t, err)
+	assert.Equal(t, "job2", element.String())
+
+	ok, err := q.Ack("worker1", StringValue{"job1"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = q.Ack("worker1", StringValue{"job1"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	reclaimed, err := q.Reclaim()
+	assert.NoError(t, err)
+	assert.Empty(t, reclaimed)
+
+	reclaimed, err = c.ReliableQueue("jobs", 0).Reclaim()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job2"}, readStrings(reclaimed))
+
+	ok, err = q.Ack("worker2", StringValue{"job2"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	element, err = q.Pop("worker1")
+	assert.NoError(t, err)
+	assert.Equal(t, "job2", element.String())
+
+	element, err = q.Pop("worker1")
+	assert.NoError(t, err)
+	assert.Equal(t, "job3", element.String())
+
+	element, err = q.Pop("worker1")
+	assert.NoError(t, err)
+	assert.True(t, element.Empty())
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		_, err := q.Push(StringValue{"late"})
+		assert.NoError(t, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	element, err = q.BPop(ctx, PollPolicy{Interval: 10 * time.Millisecond}, "worker2")
+	assert.NoError(t, err)
+	assert.Equal(t, "late", element.String())
+
+	ok, err = q.Ack("worker2", StringValue{"late"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestCountedReliableQueue(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+	q := c.ReliableQueue("jobs", time.Hour)
+
+	_, err := q.Push(StringValue{"job1"}, StringValue{"job2"})
+	assert.NoError(t, err)
+
+	_, err = q.Pop("worker1")
+	assert.NoError(t, err)
+
+	length, err := c.LLEN("jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), length)
+
+	length, err = c.LLEN(q.processingKey("worker1"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), length)
+}
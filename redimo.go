@@ -12,6 +12,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// ErrTooMuchContention is returned when an optimistic operation keeps failing because other clients are
+// modifying the same data at the same time.
+var ErrTooMuchContention = errors.New("too much contention")
+
 type Client struct {
 	ddbClient          *dynamodb.Client
 	consistentReads    bool
@@ -21,6 +25,18 @@ type Client struct {
 	sortKey            string
 	sortKeyNum         string
 	transactionActions int
+	countedCardinality bool
+	filterExpired      bool
+	retryPolicy        RetryPolicy
+	countSegments      int
+	countConcurrency   int
+	sortedSetShards    int
+	strictLex          bool
+	codec              Codec
+	compressThreshold  int
+	atomicHashes       bool
+	compactHashes      bool
+	snapshotHashes     bool
 }
 
 func (c Client) EventuallyConsistent() Client {
@@ -56,6 +72,128 @@ func (c Client) TransactionActions(actions int) Client {
 	return c
 }
 
+// transactionChunk returns how many actions to send per transaction when a write is split over several of them,
+// which is at least one even if TransactionActions was set to zero.
+func (c Client) transactionChunk() int {
+	if c.transactionActions < 1 {
+		return 1
+	}
+
+	return c.transactionActions
+}
+
+// ParallelCounting makes score range counts – used by ZCOUNT, ZRANK and ZREVRANK – split the range into the given
+// number of segments and count them concurrently. Counting has to read every member in the range, so this doesn't
+// reduce the cost, but makes counting ranges with many members up to segments times faster. Members are rarely
+// spread evenly over the scores, so more segments than expected may be needed for a given speedup.
+func (c Client) ParallelCounting(segments int) Client {
+	c.countSegments = segments
+	return c
+}
+
+// CountingConcurrency limits how many segments of a ParallelCounting count are queried at the same time, to keep a
+// single count from using up the read capacity of the table. With zero, all the segments are counted at once.
+func (c Client) CountingConcurrency(limit int) Client {
+	c.countConcurrency = limit
+	return c
+}
+
+// StrictLex makes the lexicographical range commands (ZRANGEBYLEX, ZREVRANGEBYLEX, ZLEXCOUNT, ZREMRANGEBYLEX and
+// their variants) check that all the members of the sorted set have the same score, which is what Redis assumes
+// for these commands, and return ErrMixedScores if they don't. Without it the lexicographical order of the member
+// names is used regardless of the scores. The check costs two extra single-item queries per command.
+func (c Client) StrictLex() Client {
+	c.strictLex = true
+	return c
+}
+
+// Compression makes the client compress string and hash values of at least threshold bytes with the given codec
+// when writing them, and decompress them when reading. Compressed values are stored as binary and marked with the
+// name of the codec, so values written with and without compression – or by clients with other thresholds – can be
+// mixed freely, and values are only stored compressed if that makes them smaller. Reading a value compressed with a
+// codec other than GzipCodec requires a client with that codec. A nil codec turns compression off.
+//
+// Compressed values can't be modified in place: INCR and friends fail on them, and APPEND and SETRANGE rewrite the
+// whole value.
+func (c Client) Compression(codec Codec, threshold int) Client {
+	c.codec = codec
+	c.compressThreshold = threshold
+
+	return c
+}
+
+// AtomicHashes makes HSET write all the fields of a call in a single transaction, so that readers never see some
+// of the fields updated and others not. Without it each field is written on its own. Atomic writes read the fields
+// first to tell which ones are new, and cost twice as much as separate writes. A single call can then set at most
+// as many fields as fit into a transaction (see TransactionActions), and returns ErrTooManyKeys for more.
+func (c Client) AtomicHashes() Client {
+	c.atomicHashes = true
+	return c
+}
+
+// CompactHashes makes the client store each new hash as a single item, with the fields in a map attribute, instead
+// of one item per field. Small hashes are then read whole with 1 RCU, and every write is atomic, since it rewrites
+// the item on the condition that it hasn't changed, retrying according to the RetryPolicy. Concurrent writers of
+// the same hash conflict, so compact hashes suit small hashes that are read much more often than written.
+//
+// A hash that grows beyond CompactHashMaxSize is promoted to one item per field, and then stays that way. Hashes
+// that already existed are left as they are. Reads of hashes that aren't compact cost an extra read of the compact
+// item, so enable it for the keys that benefit. Every client that writes to a compact hash must have compact hashes
+// enabled. Values in compact hashes aren't compressed, and setting a per-field TTL with HEXPIRE promotes the hash.
+func (c Client) CompactHashes() Client {
+	c.compactHashes = true
+	return c
+}
+
+// SnapshotHashes makes HGETALL, HMGET and HMGETORDERED read the fields in a single TransactGetItems call, so that
+// the values they return are a consistent point-in-time snapshot, never a mix of values from before and after a
+// concurrent write. Transactional reads cost twice as much, and a single call can then read at most as many fields
+// as fit into a transaction (see TransactionActions), returning ErrTooManyKeys for more.
+func (c Client) SnapshotHashes() Client {
+	c.snapshotHashes = true
+	return c
+}
+
+// SortedSetShards sets the number of partitions the SHARDED sorted set commands, like ZADDSHARDED, spread each
+// sorted set over. Every client that accesses a sharded sorted set must use the same number of shards, and the
+// sorted set must only be accessed with the SHARDED commands. With zero or one shard the SHARDED commands work
+// on the key itself, just like the regular commands.
+func (c Client) SortedSetShards(shards int) Client {
+	c.sortedSetShards = shards
+	return c
+}
+
+// RetryPolicy sets how optimistic operations retry when they conflict with other clients. See RetryPolicy for
+// the operations that honor it and how the zero value behaves.
+func (c Client) RetryPolicy(policy RetryPolicy) Client {
+	c.retryPolicy = policy
+	return c
+}
+
+// CountedCardinality makes the client maintain a member count for each set and sorted set, a field count for each
+// hash and a length for each list it writes to, so that SCARD, ZCARD, HLEN and LLEN read a single item instead of
+// counting every member with a query. Every write that adds or removes members or fields also updates the count,
+// which costs one extra WCU per write.
+//
+// The count is updated with a separate write after the members are written, so the two are not atomic: if the
+// second write fails, or the count is read in between, the count is off. Members that DynamoDB's Time to Live process
+// deletes are not subtracted from the count either. The count is also only accurate if every client that writes to
+// the key has counted cardinality enabled – use RepairCardinality to backfill existing data and to correct counts
+// that have drifted. DEL deletes the count along with the key.
+func (c Client) CountedCardinality() Client {
+	c.countedCardinality = true
+	return c
+}
+
+// FilterExpired makes the client hide members that have expired (see WithMemberTTL) but haven't been deleted by
+// DynamoDB's Time to Live process yet. Queries on the score index have to fetch the expiry time from the table for
+// every member they read, so filtering doubles the read cost of score range operations. Counts that don't read
+// the members, like ZCARD, still include expired members until they are deleted.
+func (c Client) FilterExpired() Client {
+	c.filterExpired = true
+	return c
+}
+
 func (c Client) ExistsTable() (bool, error) {
 	_, err := c.ddbClient.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
 		TableName: aws.String(c.tableName),
@@ -164,6 +302,7 @@ func NewClient(service *dynamodb.Client) Client {
 		sortKey:            "sk",
 		sortKeyNum:         "skN",
 		transactionActions: 100,
+		retryPolicy:        DefaultRetryPolicy,
 	}
 }
 
@@ -362,6 +501,18 @@ func (flags Flags) has(flag Flag) bool {
 	return false
 }
 
+// unconditional reports whether flags hold nothing but None, so that writes neither have conditions nor change
+// expiry times.
+func (flags Flags) unconditional() bool {
+	for _, f := range flags {
+		if f != None {
+			return false
+		}
+	}
+
+	return true
+}
+
 func conditionFailureError(err error) bool {
 	if err == nil {
 		return false
@@ -27,6 +27,8 @@ func TestClientBuilder(t *testing.T) {
 	assert.False(t, c2.consistentReads)
 	assert.True(t, c1.consistentReads)
 	assert.True(t, c2.StronglyConsistent().consistentReads)
+	assert.Equal(t, DefaultRetryPolicy, c1.retryPolicy)
+	assert.Equal(t, 10, c1.RetryPolicy(RetryPolicy{MaxAttempts: 10}).retryPolicy.MaxAttempts)
 }
 
 func newClient(t *testing.T) Client {
@@ -0,0 +1,91 @@
+package redimo
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how optimistic operations retry when they lose a race with another client, like
+// ZPOPMIN claiming members that were just popped by someone else, or XREADGROUP advancing a group cursor that
+// just moved. An operation is attempted up to MaxAttempts times. After each failed attempt it waits Backoff,
+// doubling the wait after every attempt up to MaxBackoff. Each wait is randomly shortened by up to the Jitter
+// fraction (between 0 and 1), so that clients that collided don't retry in lockstep.
+//
+// Zero values for MaxAttempts, Backoff and MaxBackoff are replaced with the values of DefaultRetryPolicy, while a
+// zero Jitter disables jitter.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Jitter      float64
+}
+
+// DefaultRetryPolicy makes up to 5 attempts, waiting 10ms after the first one and up to 1s between attempts.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     10 * time.Millisecond,
+	MaxBackoff:  time.Second,
+	Jitter:      0.5,
+}
+
+func (p RetryPolicy) normalized() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+
+	if p.Backoff <= 0 {
+		p.Backoff = DefaultRetryPolicy.Backoff
+	}
+
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+
+	if p.MaxBackoff < p.Backoff {
+		p.MaxBackoff = p.Backoff
+	}
+
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+
+	return p
+}
+
+// wait returns how long to wait after the given failed attempt, counting from zero.
+func (p RetryPolicy) wait(attempt int) time.Duration {
+	wait := p.Backoff
+
+	for i := 0; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+
+	return wait - time.Duration(rand.Float64()*p.Jitter*float64(wait))
+}
+
+// retry calls attempt until it reports that it's done or returns an error, waiting between attempts. If the
+// attempts run out, ErrTooMuchContention is returned.
+func (p RetryPolicy) retry(attempt func() (done bool, err error)) error {
+	p = p.normalized()
+
+	for i := 0; i < p.MaxAttempts; i++ {
+		if i > 0 {
+			time.Sleep(p.wait(i - 1))
+		}
+
+		done, err := attempt()
+		if err != nil || done {
+			return err
+		}
+	}
+
+	return ErrTooMuchContention
+}
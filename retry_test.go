@@ -0,0 +1,55 @@
+package redimo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyWait(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond, MaxBackoff: 35 * time.Millisecond}.normalized()
+
+	assert.Equal(t, 10*time.Millisecond, p.wait(0))
+	assert.Equal(t, 20*time.Millisecond, p.wait(1))
+	assert.Equal(t, 35*time.Millisecond, p.wait(2))
+	assert.Equal(t, 35*time.Millisecond, p.wait(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		wait := p.wait(0)
+		assert.True(t, wait > 5*time.Millisecond && wait <= 10*time.Millisecond)
+	}
+
+	assert.Equal(t, DefaultRetryPolicy, RetryPolicy{Jitter: 0.5}.normalized())
+}
+
+func TestRetryPolicyRetry(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	attempts := 0
+	err := p.retry(func() (bool, error) {
+		attempts++
+		return attempts == 2, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	err = p.retry(func() (bool, error) {
+		attempts++
+		return false, nil
+	})
+	assert.Equal(t, ErrTooMuchContention, err)
+	assert.Equal(t, 3, attempts)
+
+	failure := errors.New("failure")
+	attempts = 0
+	err = p.retry(func() (bool, error) {
+		attempts++
+		return false, failure
+	})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 1, attempts)
+}
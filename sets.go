@@ -2,7 +2,11 @@ package redimo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/rand"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -37,9 +41,39 @@ func (sm setMember) keyAV(c Client) map[string]types.AttributeValue {
 //
 // Works similar to https://redis.io/commands/sadd
 func (c Client) SADD(key string, members ...string) (addedMembers []string, err error) {
+	return c.sAdd(key, nil, members)
+}
+
+// SADDEX adds the given string members to the set at key like SADD, and makes them expire after ttl, for sets like
+// recently seen devices that should decay on their own. The expiry is stored in the ttl attribute of each member (see
+// WithMemberTTL), and members are left out of reads once they have expired, even before DynamoDB deletes them.
+// Adding a member again, with SADD or SADDEX, replaces its expiry.
+//
+// With CountedCardinality, expired members stay in the count until DynamoDB deletes them and RepairCardinality is
+// run.
+//
+// Cost is O(1) / 1 WCU for each member, whether it already exists or not.
+func (c Client) SADDEX(key string, ttl time.Duration, members ...string) (addedMembers []string, err error) {
+	return c.sAdd(key, expiryAV(ttl), members)
+}
+
+func (c Client) sAdd(key string, expiry types.AttributeValue, members []string) (addedMembers []string, err error) {
+	var created int
+
+	defer func() {
+		if cErr := c.adjustCardinality(key, created); err == nil {
+			err = cErr
+		}
+	}()
+
 	for _, member := range members {
+		item := setMember{pk: key, sk: member}.toAV(c)
+		if expiry != nil {
+			item[ttlKey] = expiry
+		}
+
 		resp, err := c.ddbClient.PutItem(context.TODO(), &dynamodb.PutItemInput{
-			Item:         setMember{pk: key, sk: member}.toAV(c),
+			Item:         item,
 			ReturnValues: types.ReturnValueAllOld,
 			TableName:    aws.String(c.tableName),
 		})
@@ -48,6 +82,10 @@ func (c Client) SADD(key string, members ...string) (addedMembers []string, err
 		}
 
 		if len(resp.Attributes) == 0 {
+			created++
+		}
+
+		if len(resp.Attributes) == 0 || itemExpired(resp.Attributes) {
 			addedMembers = append(addedMembers, member)
 		}
 	}
@@ -55,33 +93,59 @@ func (c Client) SADD(key string, members ...string) (addedMembers []string, err
 	return
 }
 
-// SCARD returns the cardinality (the number of elements) in the set at key.
+// SADDBULK is a bulk loading alternative to SADD for large numbers of members, and works like ZADDBULK: members are
+// written unconditionally with batched writes, up to concurrency at a time, and duplicate members are only written
+// once.
 //
-// Cost is O(size) / 1 WCU per 4KB of data counted.
+// Cost is O(N) / 1 WCU per member, plus the cost of RepairCardinality with CountedCardinality.
+func (c Client) SADDBULK(key string, members []string, concurrency int) (err error) {
+	requests := make([]types.WriteRequest, 0, len(members))
+	seen := make(map[string]struct{}, len(members))
+
+	for _, member := range members {
+		if _, ok := seen[member]; ok {
+			continue
+		}
+
+		seen[member] = struct{}{}
+		requests = append(requests, putRequest(setMember{pk: key, sk: member}.toAV(c)))
+	}
+
+	return c.bulkWrite(key, requests, concurrency)
+}
+
+// SCARD returns the cardinality (the number of elements) in the set at key. By default the members are counted with
+// a query. With CountedCardinality every write that adds or removes members also updates a maintained count, which
+// is read instead.
+//
+// Cost is O(size) / 1 RCU per 4KB of data counted, or O(1) / 1 RCU with CountedCardinality.
 //
 // Works similar to https://redis.io/commands/scard
 func (c Client) SCARD(key string) (count int32, err error) {
-	return c.HLEN(key)
+	if c.counted(key) {
+		return c.cardinality(key)
+	}
+
+	return c.countItems(key, true)
 }
 
+// SDIFF returns the members of the set at key that aren't in any of the sets at subtractKeys. The sets are read in
+// full, concurrently.
+//
+// Works similar to https://redis.io/commands/sdiff
 func (c Client) SDIFF(key string, subtractKeys ...string) (members []string, err error) {
 	memberSet := make(map[string]struct{})
-	startingList, err := c.SMEMBERS(key)
 
+	sets, err := c.sMembersOf(append([]string{key}, subtractKeys...))
 	if err != nil {
 		return
 	}
 
-	for _, member := range startingList {
+	for _, member := range sets[0] {
 		memberSet[member] = struct{}{}
 	}
 
-	for _, otherKey := range subtractKeys {
-		otherList, err := c.SMEMBERS(otherKey)
-		if err != nil {
-			return members, err
-		}
-
+	for _, otherList := range sets[1:] {
 		for _, member := range otherList {
 			delete(memberSet, member)
 		}
@@ -94,33 +158,36 @@ func (c Client) SDIFF(key string, subtractKeys ...string) (members []string, err
 	return
 }
 
+// SDIFFSTORE computes the difference of the sets like SDIFF and stores it at destinationKey, see SUNIONSTORE.
+// Returns the number of members in the result.
+//
+// Works similar to https://redis.io/commands/sdiffstore
 func (c Client) SDIFFSTORE(destinationKey string, sourceKey string, subtractKeys ...string) (count int32, err error) {
 	members, err := c.SDIFF(sourceKey, subtractKeys...)
 	if err == nil {
-		_, err = c.SADD(destinationKey, members...)
+		err = c.sStore(destinationKey, members)
 	}
 
 	return int32(len(members)), err
 }
 
+// SINTER returns the members that are in all the sets at key and otherKeys. The sets are read in full, concurrently;
+// use SINTERCARD to count the intersection of large sets.
+//
+// Works similar to https://redis.io/commands/sinter
 func (c Client) SINTER(key string, otherKeys ...string) (members []string, err error) {
 	memberSet := make(map[string]struct{})
-	startingList, err := c.SMEMBERS(key)
 
+	sets, err := c.sMembersOf(append([]string{key}, otherKeys...))
 	if err != nil {
 		return
 	}
 
-	for _, member := range startingList {
+	for _, member := range sets[0] {
 		memberSet[member] = struct{}{}
 	}
 
-	for _, otherKey := range otherKeys {
-		otherList, err := c.SMEMBERS(otherKey)
-		if err != nil {
-			return members, err
-		}
-
+	for _, otherList := range sets[1:] {
 		otherSet := make(map[string]struct{})
 
 		for _, member := range otherList {
@@ -141,10 +208,105 @@ func (c Client) SINTER(key string, otherKeys ...string) (members []string, err e
 	return
 }
 
+// SINTERCARD returns the number of members in the intersection of the sets at keys, without returning the members.
+// If limit is greater than zero, counting stops once limit members have been found, and limit is returned.
+//
+// The members of the first set are read a page at a time, and looked up in the other sets with BatchGetItem, so
+// neither the intersection nor the other sets are ever read in full. Put the smallest set first, and use a limit
+// where the exact count isn't needed, to save reads on large sets.
+//
+// Cost is O(N) in the size of the first set / 1 RCU per 4 KB of members of the first set + 1 RCU per member looked up
+// in each of the other sets.
+//
+// Works similar to https://redis.io/commands/sintercard
+func (c Client) SINTERCARD(keys []string, limit int32) (count int32, err error) {
+	if len(keys) == 0 {
+		return
+	}
+
+	projection, projectionNames := projectionWithNames([]string{c.partitionKey, c.sortKey})
+	hasMoreResults := true
+
+	var lastEvaluatedKey map[string]types.AttributeValue
+
+	for hasMoreResults {
+		builder := newExpresionBuilder()
+		builder.addConditionEquality(c.partitionKey, StringValue{keys[0]})
+
+		names := builder.expressionAttributeNames()
+		for name, attribute := range projectionNames {
+			names[name] = attribute
+		}
+
+		resp, err := c.ddbClient.Query(context.TODO(), excludeExpiredItems(&dynamodb.QueryInput{
+			ConsistentRead:            aws.Bool(c.consistentReads),
+			ExclusiveStartKey:         lastEvaluatedKey,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			KeyConditionExpression:    builder.conditionExpression(),
+			ProjectionExpression:      projection,
+			TableName:                 aws.String(c.tableName),
+		}))
+		if err != nil {
+			return count, err
+		}
+
+		members := make([]string, 0, len(resp.Items))
+		for _, item := range resp.Items {
+			members = append(members, parseKey(item, c).sk)
+		}
+
+		for _, otherKey := range keys[1:] {
+			if members, err = c.sMembersIn(otherKey, members); err != nil || len(members) == 0 {
+				break
+			}
+		}
+
+		if err != nil {
+			return count, err
+		}
+
+		count += int32(len(members))
+		if limit > 0 && count >= limit {
+			return limit, nil
+		}
+
+		lastEvaluatedKey = resp.LastEvaluatedKey
+		hasMoreResults = len(lastEvaluatedKey) > 0
+	}
+
+	return
+}
+
+// sMembersIn returns the given members that are in the set at key, looking them up with BatchGetItem.
+func (c Client) sMembersIn(key string, members []string) (found []string, err error) {
+	keys := make([]keyDef, len(members))
+	for i, member := range members {
+		keys[i] = keyDef{pk: key, sk: member}
+	}
+
+	items, err := c.batchGet(keys, c.partitionKey, c.sortKey, ttlKey)
+	if err != nil {
+		return
+	}
+
+	for _, item := range items {
+		if !itemExpired(item) {
+			found = append(found, parseKey(item, c).sk)
+		}
+	}
+
+	return
+}
+
+// SINTERSTORE computes the intersection of the sets like SINTER and stores it at destinationKey, see SUNIONSTORE.
+// Returns the number of members in the result.
+//
+// Works similar to https://redis.io/commands/sinterstore
 func (c Client) SINTERSTORE(destinationKey string, sourceKey string, otherKeys ...string) (count int32, err error) {
 	members, err := c.SINTER(sourceKey, otherKeys...)
 	if err == nil {
-		_, err = c.SADD(destinationKey, members...)
+		err = c.sStore(destinationKey, members)
 	}
 
 	return int32(len(members)), err
@@ -156,7 +318,7 @@ func (c Client) SISMEMBER(key string, member string) (ok bool, err error) {
 		Key:            setMember{pk: key, sk: member}.keyAV(c),
 		TableName:      aws.String(c.tableName),
 	})
-	if err != nil || len(resp.Item) == 0 {
+	if err != nil || len(resp.Item) == 0 || itemExpired(resp.Item) {
 		return
 	}
 
@@ -172,14 +334,14 @@ func (c Client) SMEMBERS(key string) (members []string, err error) {
 		builder := newExpresionBuilder()
 		builder.addConditionEquality(c.partitionKey, StringValue{key})
 
-		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+		resp, err := c.ddbClient.Query(context.TODO(), excludeExpiredItems(&dynamodb.QueryInput{
 			ConsistentRead:            aws.Bool(c.consistentReads),
 			ExclusiveStartKey:         lastEvaluatedKey,
 			ExpressionAttributeNames:  builder.expressionAttributeNames(),
 			ExpressionAttributeValues: builder.expressionAttributeValues(),
 			KeyConditionExpression:    builder.conditionExpression(),
 			TableName:                 aws.String(c.tableName),
-		})
+		}))
 
 		if err != nil {
 			return members, err
@@ -200,9 +362,84 @@ func (c Client) SMEMBERS(key string) (members []string, err error) {
 	return
 }
 
+// SSCAN iterates over the members of the set at key in lexicographical order, returning the members that match the
+// glob-style pattern match (all of them if match is empty) along with a cursor for the next call. Start with the
+// ScanStart cursor; when the returned cursor is ScanStart again, the iteration is complete.
+//
+// Up to count members are read per call, like the COUNT hint in Redis, and the ones that don't match the pattern are
+// left out, so a call may return fewer members (even none) while the cursor is not yet ScanStart. If the pattern
+// starts with a literal prefix, like "user:*", only the members with that prefix are read.
+//
+// The cursor is an opaque string that wraps the DynamoDB pagination key, so it can be stored and used to resume the
+// scan later, even from another process. Members present throughout the scan are returned exactly once.
+//
+// Cost is O(count) / 1 RCU per 4 KB of members read.
+//
+// Works similar to https://redis.io/commands/sscan
+func (c Client) SSCAN(key string, cursor string, match string, count int32) (members []string, nextCursor string, err error) {
+	exclusiveStartKey, err := c.decodeKeyCursor(key, cursor)
+	if err != nil {
+		return
+	}
+
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{key})
+
+	if prefix := globPrefix(match); prefix != "" {
+		builder.addConditionBeginWith(c.sortKey, StringValue{prefix})
+	}
+
+	var queryLimit *int32
+	if count > 0 {
+		queryLimit = aws.Int32(count)
+	}
+
+	resp, err := c.ddbClient.Query(context.TODO(), excludeExpiredItems(&dynamodb.QueryInput{
+		ConsistentRead:            aws.Bool(c.consistentReads),
+		ExclusiveStartKey:         exclusiveStartKey,
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		KeyConditionExpression:    builder.conditionExpression(),
+		Limit:                     queryLimit,
+		TableName:                 aws.String(c.tableName),
+	}))
+	if err != nil {
+		return members, cursor, err
+	}
+
+	for _, item := range resp.Items {
+		member := parseKey(item, c).sk
+		if match == "" || globMatch(match, member) {
+			members = append(members, member)
+		}
+	}
+
+	return members, encodeCursor(resp.LastEvaluatedKey), nil
+}
+
+// SMOVE moves member from the set at sourceKey to the set at destinationKey, and returns false if it isn't in the
+// source set. If the member is already in the destination set, it is only removed from the source set.
+//
+// The member is deleted from the source and put into the destination in a single transaction, with the delete
+// conditional on the member being in the source set, so the member is never in both sets or in neither, even while
+// other clients move or remove it concurrently.
+//
+// Cost is O(1) / 4 WCU.
+//
+// Works similar to https://redis.io/commands/smove
 func (c Client) SMOVE(sourceKey string, destinationKey string, member string) (ok bool, err error) {
+	if sourceKey == destinationKey {
+		return c.SISMEMBER(sourceKey, member)
+	}
+
 	builder := newExpresionBuilder()
-	builder.addConditionExists(c.partitionKey)
+	builder.addConditionExistsUnexpired(c.partitionKey)
+
+	// With counted cardinality the put is conditional too, to find out whether the destination count grows.
+	putBuilder := newExpresionBuilder()
+	if c.counted(destinationKey) {
+		putBuilder.addConditionNotExists(c.partitionKey)
+	}
 
 	_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
 		TransactItems: []types.TransactWriteItem{
@@ -217,13 +454,24 @@ func (c Client) SMOVE(sourceKey string, destinationKey string, member string) (o
 			},
 			{
 				Put: &types.Put{
-					Item:      setMember{pk: destinationKey, sk: member}.toAV(c),
-					TableName: aws.String(c.tableName),
+					ConditionExpression:      putBuilder.conditionExpression(),
+					ExpressionAttributeNames: putBuilder.expressionAttributeNames(),
+					Item:                     setMember{pk: destinationKey, sk: member}.toAV(c),
+					TableName:                aws.String(c.tableName),
 				},
 			},
 		},
 	})
 
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) && len(canceled.CancellationReasons) == 2 &&
+		aws.ToString(canceled.CancellationReasons[0].Code) == "None" &&
+		aws.ToString(canceled.CancellationReasons[1].Code) == "ConditionalCheckFailed" {
+		// The member is already in the destination, so it only has to leave the source.
+		removed, err := c.SREM(sourceKey, member)
+		return len(removed) > 0, err
+	}
+
 	if conditionFailureError(err) {
 		return false, nil
 	}
@@ -232,13 +480,114 @@ func (c Client) SMOVE(sourceKey string, destinationKey string, member string) (o
 		return false, err
 	}
 
-	return true, nil
+	if err = c.adjustCardinality(sourceKey, -1); err != nil {
+		return true, err
+	}
+
+	return true, c.adjustCardinality(destinationKey, 1)
 }
 
+// SPOP removes and returns up to count random members of the set at key. Members are picked at random from the
+// score index, which holds a random number for every member, and claimed with conditional deletes, so concurrent
+// callers never receive the same member. Members that have expired (see SADDEX) are deleted but not returned. If
+// another client takes some of the picked members first, more members are picked, as allowed by the RetryPolicy of
+// the client. If the retries run out, the members popped so far are returned without an error; ErrTooMuchContention
+// is only returned when no member could be popped.
+//
+// Cost is O(count) / 1 RCU + 1 WCU per member.
+//
+// Works similar to https://redis.io/commands/spop
 func (c Client) SPOP(key string, count int32) (members []string, err error) {
-	members, err = c.SRANDMEMBER(key, count)
-	if err == nil {
-		_, err = c.SREM(key, members...)
+	if count <= 0 {
+		return
+	}
+
+	var deleted int
+
+	defer func() {
+		if cErr := c.adjustCardinality(key, -deleted); err == nil {
+			err = cErr
+		}
+	}()
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		candidates, err := c.sRandomMembers(key, count-int32(len(members)))
+		if err != nil || len(candidates) == 0 {
+			return true, err
+		}
+
+		contended := false
+
+		for _, member := range candidates {
+			builder := newExpresionBuilder()
+			builder.addConditionExists(c.partitionKey)
+
+			resp, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+				ConditionExpression:      builder.conditionExpression(),
+				ExpressionAttributeNames: builder.expressionAttributeNames(),
+				Key:                      setMember{pk: key, sk: member}.keyAV(c),
+				ReturnValues:             types.ReturnValueAllOld,
+				TableName:                aws.String(c.tableName),
+			})
+			if conditionFailureError(err) {
+				contended = true
+				continue
+			}
+
+			if err != nil {
+				return true, err
+			}
+
+			deleted++
+
+			// Members that have expired are deleted like DynamoDB would, but not returned, and others picked instead.
+			if itemExpired(resp.Attributes) {
+				contended = true
+				continue
+			}
+
+			members = append(members, member)
+		}
+
+		return !contended || int32(len(members)) >= count, nil
+	})
+	if err == ErrTooMuchContention && len(members) > 0 {
+		err = nil
+	}
+
+	return
+}
+
+// sRandomMembers picks up to count distinct members of the set at key at random, by reading the score index from a
+// random score onwards, and from the start if it runs out of members.
+func (c Client) sRandomMembers(key string, count int32) (members []string, err error) {
+	start := IntValue{rand.Int63()}
+
+	for _, operator := range []string{">=", "<"} {
+		if int32(len(members)) >= count {
+			break
+		}
+
+		builder := newExpresionBuilder()
+		builder.addConditionEquality(c.partitionKey, StringValue{key})
+		builder.condition(fmt.Sprintf("#%v %v :start", c.sortKeyNum, operator), c.sortKeyNum)
+		builder.values["start"] = start.ToAV()
+
+		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			IndexName:                 aws.String(c.indexName),
+			KeyConditionExpression:    builder.conditionExpression(),
+			Limit:                     aws.Int32(count - int32(len(members))),
+			TableName:                 aws.String(c.tableName),
+		})
+		if err != nil {
+			return members, err
+		}
+
+		for _, item := range resp.Items {
+			members = append(members, parseKey(item, c).sk)
+		}
 	}
 
 	return
@@ -252,14 +601,14 @@ func (c Client) SRANDMEMBER(key string, count int32) (members []string, err erro
 	builder := newExpresionBuilder()
 	builder.addConditionEquality(c.partitionKey, StringValue{key})
 
-	resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+	resp, err := c.ddbClient.Query(context.TODO(), excludeExpiredItems(&dynamodb.QueryInput{
 		ConsistentRead:            aws.Bool(c.consistentReads),
 		ExpressionAttributeNames:  builder.expressionAttributeNames(),
 		ExpressionAttributeValues: builder.expressionAttributeValues(),
 		KeyConditionExpression:    builder.conditionExpression(),
 		Limit:                     aws.Int32(count),
 		TableName:                 aws.String(c.tableName),
-	})
+	}))
 
 	if err != nil {
 		return members, err
@@ -274,6 +623,12 @@ func (c Client) SRANDMEMBER(key string, count int32) (members []string, err erro
 }
 
 func (c Client) SREM(key string, members ...string) (removedMembers []string, err error) {
+	defer func() {
+		if cErr := c.adjustCardinality(key, -len(removedMembers)); err == nil {
+			err = cErr
+		}
+	}()
+
 	for _, member := range members {
 		resp, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
 			Key: setMember{
@@ -295,15 +650,18 @@ func (c Client) SREM(key string, members ...string) (removedMembers []string, er
 	return
 }
 
+// SUNION returns the members that are in any of the sets at keys. The sets are read in full, concurrently.
+//
+// Works similar to https://redis.io/commands/sunion
 func (c Client) SUNION(keys ...string) (members []string, err error) {
 	memberSet := make(map[string]struct{})
 
-	for _, key := range keys {
-		setMembers, err := c.SMEMBERS(key)
-		if err != nil {
-			return members, err
-		}
+	sets, err := c.sMembersOf(keys)
+	if err != nil {
+		return
+	}
 
+	for _, setMembers := range sets {
 		for _, member := range setMembers {
 			memberSet[member] = struct{}{}
 		}
@@ -316,11 +674,87 @@ func (c Client) SUNION(keys ...string) (members []string, err error) {
 	return
 }
 
+// SUNIONSTORE computes the union of the sets like SUNION and stores it at destinationKey, and returns the number of
+// members in the result. If the destination already exists it is overwritten: members that aren't part of the union
+// are removed. The destination can be one of the source sets.
+//
+// If the writes required fit inside a single transaction – see TransactionActions – the destination is replaced
+// atomically. Larger results are written with batched writes of up to 25 members each and are not atomic.
+//
+// Works similar to https://redis.io/commands/sunionstore
 func (c Client) SUNIONSTORE(destinationKey string, sourceKeys ...string) (count int32, err error) {
 	members, err := c.SUNION(sourceKeys...)
 	if err == nil {
-		_, err = c.SADD(destinationKey, members...)
+		err = c.sStore(destinationKey, members)
 	}
 
 	return int32(len(members)), err
 }
+
+// sMembersOf reads the members of the sets at keys concurrently, and returns them in the order of the keys.
+func (c Client) sMembersOf(keys []string) (sets [][]string, err error) {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	sets = make([][]string, len(keys))
+
+	for i, key := range keys {
+		wg.Add(1)
+
+		go func(i int, key string) {
+			defer wg.Done()
+
+			members, sErr := c.SMEMBERS(key)
+
+			mu.Lock()
+			sets[i] = members
+			if sErr != nil && err == nil {
+				err = sErr
+			}
+			mu.Unlock()
+		}(i, key)
+	}
+
+	wg.Wait()
+
+	return
+}
+
+// sStore replaces the set at destinationKey with members, like zStore.
+func (c Client) sStore(destinationKey string, members []string) error {
+	existingMembers, err := c.listSortKeys(destinationKey)
+	if err != nil {
+		return err
+	}
+
+	memberSet := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		memberSet[member] = struct{}{}
+	}
+
+	requests := make([]types.WriteRequest, 0, len(existingMembers)+len(members))
+
+	for _, member := range existingMembers {
+		if _, ok := memberSet[member]; !ok {
+			requests = append(requests, deleteRequest(setMember{pk: destinationKey, sk: member}.keyAV(c)))
+		}
+	}
+
+	for _, member := range members {
+		requests = append(requests, putRequest(setMember{pk: destinationKey, sk: member}.toAV(c)))
+	}
+
+	if len(requests) <= c.transactionActions {
+		err = c.transactWrite(requests)
+	} else {
+		err = c.batchWrite(requests)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.setCardinality(destinationKey, int32(len(members)))
+}
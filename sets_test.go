@@ -1,7 +1,11 @@
 package redimo
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -169,6 +173,10 @@ func TestSetModifiers(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, ok)
 
+	ok, err = c.SMOVE("s1", "s1", "m2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
 	members, err = c.SMEMBERS("s1")
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"m2", "m3"}, members)
@@ -177,3 +185,319 @@ func TestSetModifiers(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"m1"}, members)
 }
+
+func TestSINTERCARD(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SADD("s1", "m1", "m2", "m3", "m4")
+	assert.NoError(t, err)
+
+	_, err = c.SADD("s2", "m2", "m3", "m4", "m5")
+	assert.NoError(t, err)
+
+	_, err = c.SADD("s3", "m3", "m4", "m6")
+	assert.NoError(t, err)
+
+	count, err := c.SINTERCARD([]string{"s1", "s2"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	count, err = c.SINTERCARD([]string{"s1", "s2", "s3"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	count, err = c.SINTERCARD([]string{"s1", "s2", "s3"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	count, err = c.SINTERCARD([]string{"s1", "nosuchset"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	count, err = c.SINTERCARD([]string{"s1"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), count)
+}
+
+func TestSSCAN(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SADD("s1", "user:1", "user:2", "user:3", "user:10", "admin:1", "guest")
+	assert.NoError(t, err)
+
+	scan := func(match string) (members []string) {
+		cursor := ScanStart
+
+		for {
+			page, nextCursor, err := c.SSCAN("s1", cursor, match, 2)
+			assert.NoError(t, err)
+			assert.True(t, len(page) <= 2)
+
+			members = append(members, page...)
+
+			if cursor = nextCursor; cursor == ScanStart {
+				return
+			}
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "user:3", "user:10", "admin:1", "guest"}, scan(""))
+	assert.ElementsMatch(t, []string{"user:1", "user:2", "user:3", "user:10"}, scan("user:*"))
+	assert.ElementsMatch(t, []string{"user:1", "admin:1"}, scan("*:1"))
+	assert.ElementsMatch(t, []string{"user:2", "user:3"}, scan("user:[2-3]"))
+
+	_, _, err = c.SSCAN("s1", "not a cursor", "", 2)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestConcurrentSMOVE(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SADD("s1", "m1")
+	assert.NoError(t, err)
+
+	var (
+		wg    sync.WaitGroup
+		moved int32
+	)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			ok, err := c.SMOVE("s1", fmt.Sprintf("dst%d", worker), "m1")
+			assert.NoError(t, err)
+
+			if ok {
+				atomic.AddInt32(&moved, 1)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	assert.Equal(t, int32(1), moved)
+
+	var holders int
+
+	for i := 0; i < 4; i++ {
+		ok, err := c.SISMEMBER(fmt.Sprintf("dst%d", i), "m1")
+		assert.NoError(t, err)
+
+		if ok {
+			holders++
+		}
+	}
+
+	assert.Equal(t, 1, holders)
+}
+
+func TestConcurrentSPOP(t *testing.T) {
+	c := newClient(t).RetryPolicy(RetryPolicy{MaxAttempts: 20, Jitter: 1})
+
+	for i := 0; i < 40; i++ {
+		_, err := c.SADD("s1", fmt.Sprintf("m%d", i))
+		assert.NoError(t, err)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		popped = make(map[string]bool)
+	)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				members, err := c.SPOP("s1", 3)
+				if err != nil && err != ErrTooMuchContention {
+					assert.NoError(t, err)
+					return
+				}
+
+				if err == nil && len(members) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, member := range members {
+					assert.False(t, popped[member], member)
+					popped[member] = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Len(t, popped, 40)
+
+	count, err := c.SCARD("s1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+}
+
+func TestSetStoreOverwrites(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SADD("s1", "m1", "m2", "m3")
+	assert.NoError(t, err)
+
+	_, err = c.SADD("s2", "m2", "m3", "m4")
+	assert.NoError(t, err)
+
+	_, err = c.SADD("dst", "stale")
+	assert.NoError(t, err)
+
+	count, err := c.SINTERSTORE("dst", "s1", "s2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	members, err := c.SMEMBERS("dst")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m2", "m3"}, members)
+
+	count, err = c.SDIFFSTORE("s1", "s1", "s2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	members, err = c.SMEMBERS("s1")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m1"}, members)
+
+	count, err = c.SINTERSTORE("dst", "s1", "s2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	exists, err := c.EXISTS("dst")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCountedSetCard(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	_, err := c.SADD("s1", "m1", "m2", "m3", "m4", "m5")
+	assert.NoError(t, err)
+
+	_, err = c.SADD("s1", "m1")
+	assert.NoError(t, err)
+
+	_, err = c.SADD("s2", "m5")
+	assert.NoError(t, err)
+
+	_, err = c.SREM("s1", "m1", "nosuchmember")
+	assert.NoError(t, err)
+
+	_, err = c.SPOP("s1", 1)
+	assert.NoError(t, err)
+
+	members, err := c.SMEMBERS("s1")
+	assert.NoError(t, err)
+	assert.Len(t, members, 3)
+
+	ok, err := c.SMOVE("s1", "s2", members[0])
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// m5 is already in s2, unless it was popped.
+	_, err = c.SMOVE("s1", "s2", "m5")
+	assert.NoError(t, err)
+
+	uncounted := c
+	uncounted.countedCardinality = false
+
+	for _, key := range []string{"s1", "s2"} {
+		expected, err := uncounted.SCARD(key)
+		assert.NoError(t, err)
+
+		count, err := c.SCARD(key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, count, key)
+	}
+
+	_, err = uncounted.SADD("s2", "untracked")
+	assert.NoError(t, err)
+
+	count, err := c.RepairCardinality("s2")
+	assert.NoError(t, err)
+
+	scard, err := c.SCARD("s2")
+	assert.NoError(t, err)
+	assert.Equal(t, count, scard)
+}
+
+func TestSADDBULK(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	var members []string
+	for i := 0; i < 100; i++ {
+		members = append(members, fmt.Sprintf("m%d", i))
+	}
+
+	_, err := c.SADD("s1", "m0", "existing")
+	assert.NoError(t, err)
+
+	err = c.SADDBULK("s1", append(members, "m1"), 4)
+	assert.NoError(t, err)
+
+	count, err := c.SCARD("s1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(101), count)
+
+	ok, err := c.SISMEMBER("s1", "m99")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSetMemberTTL(t *testing.T) {
+	c := newClient(t)
+
+	added, err := c.SADDEX("seen", time.Hour, "d1", "d2")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"d1", "d2"}, added)
+
+	added, err = c.SADDEX("seen", -time.Hour, "d3", "d4")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"d3", "d4"}, added)
+
+	ok, err := c.SISMEMBER("seen", "d1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.SISMEMBER("seen", "d3")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	members, err := c.SMEMBERS("seen")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"d1", "d2"}, members)
+
+	count, err := c.SCARD("seen")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	_, err = c.SADD("other", "d1", "d3")
+	assert.NoError(t, err)
+
+	count, err = c.SINTERCARD([]string{"other", "seen"}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), count)
+
+	added, err = c.SADD("seen", "d3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d3"}, added)
+
+	ok, err = c.SISMEMBER("seen", "d3")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.SMOVE("seen", "other", "d4")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
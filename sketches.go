@@ -0,0 +1,399 @@
+package redimo
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// maxSketchCounters limits the counters of a sketch so that it fits into a single item with room to spare.
+const maxSketchCounters = (MaxItemSize - 16*1024) / 4
+
+// maxTopKItemLength is the longest item a Top-K tracker can hold, as the length of each item is stored in 2 bytes.
+const maxTopKItemLength = math.MaxUint16
+
+// ErrInvalidSketch is returned when creating a sketch with dimensions that aren't positive or don't fit into an
+// item, when incrementing a sketch by a negative amount, or when adding an item longer than 65535 bytes to a Top-K
+// tracker.
+var ErrInvalidSketch = errors.New("invalid sketch parameters")
+
+// ErrSketchNotFound is returned when updating a count-min sketch or Top-K tracker at a key that doesn't hold one.
+var ErrSketchNotFound = errors.New("no sketch of the requested kind at key")
+
+const (
+	countMinSketchKind byte = 'C'
+	topKKind           byte = 'T'
+)
+
+// TopKItem is an item tracked by a Top-K tracker, with the estimate of its count.
+type TopKItem struct {
+	Item  string
+	Count int64
+}
+
+// sketch is a count-min sketch of depth rows of width saturating 32 bit counters. With k set, it also tracks the k
+// items with the highest estimated counts, which makes it a Top-K tracker.
+//
+// It is stored as a binary value: the kind, k, width and depth, the counters row by row, and for Top-K trackers
+// each of the top items as an 8 byte count followed by the length and bytes of the item.
+type sketch struct {
+	kind     byte
+	k        uint32
+	width    uint32
+	depth    uint32
+	counters []uint32
+	top      []TopKItem
+}
+
+func newSketch(kind byte, k, width, depth int64) (s sketch, err error) {
+	if width <= 0 || depth <= 0 || width*depth > maxSketchCounters || kind == topKKind && (k <= 0 || k > 1000) {
+		return s, ErrInvalidSketch
+	}
+
+	return sketch{
+		kind:     kind,
+		k:        uint32(k),
+		width:    uint32(width),
+		depth:    uint32(depth),
+		counters: make([]uint32, width*depth),
+	}, nil
+}
+
+func (s sketch) marshal() []byte {
+	data := make([]byte, 13, 13+4*len(s.counters))
+	data[0] = s.kind
+	binary.BigEndian.PutUint32(data[1:], s.k)
+	binary.BigEndian.PutUint32(data[5:], s.width)
+	binary.BigEndian.PutUint32(data[9:], s.depth)
+
+	for _, counter := range s.counters {
+		data = append(data, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data[len(data)-4:], counter)
+	}
+
+	for _, item := range s.top {
+		data = append(data, make([]byte, 10)...)
+		binary.BigEndian.PutUint64(data[len(data)-10:], uint64(item.Count))
+		binary.BigEndian.PutUint16(data[len(data)-2:], uint16(len(item.Item)))
+		data = append(data, item.Item...)
+	}
+
+	return data
+}
+
+// unmarshalSketch reads a sketch of the given kind, returning ErrSketchNotFound if data doesn't hold one.
+func unmarshalSketch(data []byte, kind byte) (s sketch, err error) {
+	if len(data) < 13 || data[0] != kind {
+		return s, ErrSketchNotFound
+	}
+
+	s.kind = data[0]
+	s.k = binary.BigEndian.Uint32(data[1:])
+	s.width = binary.BigEndian.Uint32(data[5:])
+	s.depth = binary.BigEndian.Uint32(data[9:])
+	data = data[13:]
+
+	if len(data) < 4*int(s.width*s.depth) {
+		return s, ErrSketchNotFound
+	}
+
+	s.counters = make([]uint32, s.width*s.depth)
+	for i := range s.counters {
+		s.counters[i] = binary.BigEndian.Uint32(data[4*i:])
+	}
+
+	data = data[4*len(s.counters):]
+
+	for len(data) >= 10 {
+		count, length := binary.BigEndian.Uint64(data), int(binary.BigEndian.Uint16(data[8:]))
+		if len(data) < 10+length {
+			return s, ErrSketchNotFound
+		}
+
+		s.top = append(s.top, TopKItem{Item: string(data[10 : 10+length]), Count: int64(count)})
+		data = data[10+length:]
+	}
+
+	return s, nil
+}
+
+// columns returns the counter of item in each row, using double hashing over the two halves of its 128 bit FNV-1a
+// hash.
+func (s sketch) columns(item string) []int {
+	h := fnv.New128a()
+	_, _ = h.Write([]byte(item))
+	sum := h.Sum(nil)
+	h1, h2 := binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:])
+
+	columns := make([]int, s.depth)
+	for row := range columns {
+		columns[row] = row*int(s.width) + int((h1+uint64(row)*h2)%uint64(s.width))
+	}
+
+	return columns
+}
+
+// count returns the estimated count of item, the smallest of its counters.
+func (s sketch) count(item string) int64 {
+	count := uint32(math.MaxUint32)
+	for _, column := range s.columns(item) {
+		if s.counters[column] < count {
+			count = s.counters[column]
+		}
+	}
+
+	return int64(count)
+}
+
+// incr adds increment to the counters of item and returns its new estimated count. For Top-K trackers, it also
+// updates the top items and returns the item that dropped out of them, if any.
+func (s *sketch) incr(item string, increment int64) (count int64, dropped string, wasDropped bool) {
+	for _, column := range s.columns(item) {
+		sum := int64(s.counters[column]) + increment
+		if sum > math.MaxUint32 {
+			sum = math.MaxUint32
+		}
+
+		s.counters[column] = uint32(sum)
+	}
+
+	count = s.count(item)
+	if s.kind != topKKind {
+		return
+	}
+
+	found := false
+
+	for i := range s.top {
+		if s.top[i].Item == item {
+			s.top[i].Count, found = count, true
+		}
+	}
+
+	switch {
+	case found:
+	case len(s.top) < int(s.k):
+		s.top = append(s.top, TopKItem{Item: item, Count: count})
+	case count > s.top[len(s.top)-1].Count:
+		dropped, wasDropped = s.top[len(s.top)-1].Item, true
+		s.top[len(s.top)-1] = TopKItem{Item: item, Count: count}
+	}
+
+	sort.SliceStable(s.top, func(i, j int) bool {
+		return s.top[i].Count > s.top[j].Count
+	})
+
+	return
+}
+
+// CMSINITBYDIM creates a count-min sketch at key with the given number of counters per row and rows. More
+// counters per row make the estimates more accurate, and more rows make inaccurate estimates less likely. Returns
+// false if the key already exists. The sketch is stored as a binary string value at key, with 4 bytes per counter,
+// so width times depth can't be much more than 100000.
+//
+// Works similar to https://redis.io/commands/cms.initbydim
+func (c Client) CMSINITBYDIM(key string, width, depth int64) (ok bool, err error) {
+	s, err := newSketch(countMinSketchKind, 0, width, depth)
+	if err != nil {
+		return
+	}
+
+	return c.SET(key, BytesValue{s.marshal()}, IfNotExists)
+}
+
+// CMSINITBYPROB creates a count-min sketch at key whose estimates overcount by at most errorRate times the total
+// of all the counts, with a probability of 1 - probability, like 0.001 and 0.01. Returns false if the key already
+// exists.
+//
+// Works similar to https://redis.io/commands/cms.initbyprob
+func (c Client) CMSINITBYPROB(key string, errorRate float64, probability float64) (ok bool, err error) {
+	if errorRate <= 0 || errorRate >= 1 || probability <= 0 || probability >= 1 {
+		return false, ErrInvalidSketch
+	}
+
+	width := int64(math.Ceil(2 / errorRate))
+	depth := int64(math.Ceil(math.Log(probability) / math.Log(0.5)))
+
+	return c.CMSINITBYDIM(key, width, depth)
+}
+
+// CMSINCRBY increments the counts of the given items in the count-min sketch at key, and returns their new
+// estimated counts. Counts saturate at the largest 32 bit unsigned integer.
+//
+// The sketch is read and written back with a conditional write, retrying according to the RetryPolicy of the
+// client if another client updates it concurrently.
+//
+// Cost is O(1) / 1 RCU per 4 KB and 1 WCU per KB of the sketch per attempt.
+//
+// Works similar to https://redis.io/commands/cms.incrby
+func (c Client) CMSINCRBY(key string, increments map[string]int64) (counts map[string]int64, err error) {
+	items, err := sortedIncrements(increments)
+	if err != nil {
+		return
+	}
+
+	err = c.updateSketch(key, countMinSketchKind, func(s *sketch) {
+		counts = make(map[string]int64, len(items))
+
+		for _, item := range items {
+			counts[item], _, _ = s.incr(item, increments[item])
+		}
+	})
+
+	return
+}
+
+// CMSQUERY returns the estimated counts of the given items in the count-min sketch at key. Estimates are never
+// lower than the real counts.
+//
+// Cost is O(1) / 1 RCU per 4 KB of the sketch.
+//
+// Works similar to https://redis.io/commands/cms.query
+func (c Client) CMSQUERY(key string, items ...string) (counts map[string]int64, err error) {
+	s, err := c.sketch(key, countMinSketchKind)
+	if err != nil {
+		return
+	}
+
+	counts = make(map[string]int64, len(items))
+	for _, item := range items {
+		counts[item] = s.count(item)
+	}
+
+	return
+}
+
+// TOPKRESERVE creates a Top-K tracker at key that keeps the k items with the highest counts, up to 1000. The counts
+// are estimated with a count-min sketch of the given width and depth, see CMSINITBYDIM; a width of 10 times k and a
+// depth of 5 is a good start. Returns false if the key already exists.
+//
+// Works similar to https://redis.io/commands/topk.reserve
+func (c Client) TOPKRESERVE(key string, k int64, width int64, depth int64) (ok bool, err error) {
+	s, err := newSketch(topKKind, k, width, depth)
+	if err != nil {
+		return
+	}
+
+	return c.SET(key, BytesValue{s.marshal()}, IfNotExists)
+}
+
+// TOPKADD adds the given items to the Top-K tracker at key, counting each once, and returns the items that dropped
+// out of the top k because of them.
+//
+// Works similar to https://redis.io/commands/topk.add
+func (c Client) TOPKADD(key string, items ...string) (dropped []string, err error) {
+	increments := make(map[string]int64, len(items))
+	for _, item := range items {
+		increments[item]++
+	}
+
+	return c.TOPKINCRBY(key, increments)
+}
+
+// TOPKINCRBY increments the counts of the given items in the Top-K tracker at key, and returns the items that
+// dropped out of the top k because of them. It is updated like CMSINCRBY. Items longer than 65535 bytes can't be
+// tracked and return ErrInvalidSketch.
+//
+// Works similar to https://redis.io/commands/topk.incrby
+func (c Client) TOPKINCRBY(key string, increments map[string]int64) (dropped []string, err error) {
+	items, err := sortedIncrements(increments)
+	if err != nil {
+		return
+	}
+
+	for _, item := range items {
+		if len(item) > maxTopKItemLength {
+			return nil, ErrInvalidSketch
+		}
+	}
+
+	err = c.updateSketch(key, topKKind, func(s *sketch) {
+		dropped = nil
+
+		for _, item := range items {
+			if _, item, wasDropped := s.incr(item, increments[item]); wasDropped {
+				dropped = append(dropped, item)
+			}
+		}
+	})
+
+	return
+}
+
+// TOPKLIST returns the top items of the Top-K tracker at key with their estimated counts, highest first.
+//
+// Works similar to https://redis.io/commands/topk.list
+func (c Client) TOPKLIST(key string) (items []TopKItem, err error) {
+	s, err := c.sketch(key, topKKind)
+
+	return s.top, err
+}
+
+// TOPKQUERY returns whether each of the given items is among the top items of the Top-K tracker at key.
+//
+// Works similar to https://redis.io/commands/topk.query
+func (c Client) TOPKQUERY(key string, items ...string) (found map[string]bool, err error) {
+	s, err := c.sketch(key, topKKind)
+	if err != nil {
+		return
+	}
+
+	found = make(map[string]bool, len(items))
+
+	for _, item := range items {
+		found[item] = false
+	}
+
+	for _, top := range s.top {
+		if _, ok := found[top.Item]; ok {
+			found[top.Item] = true
+		}
+	}
+
+	return
+}
+
+// sketch reads the sketch of the given kind at key.
+func (c Client) sketch(key string, kind byte) (s sketch, err error) {
+	val, err := c.GET(key)
+	if err != nil {
+		return
+	}
+
+	return unmarshalSketch(val.Bytes(), kind)
+}
+
+// updateSketch reads the sketch of the given kind at key, passes it to fn, and writes it back on the condition that
+// it hasn't changed in between, retrying according to the RetryPolicy.
+func (c Client) updateSketch(key string, kind byte, fn func(s *sketch)) error {
+	_, err := c.rewriteString(key, true, func(current []byte) ([]byte, error) {
+		s, err := unmarshalSketch(current, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		fn(&s)
+
+		return s.marshal(), nil
+	})
+
+	return err
+}
+
+// sortedIncrements returns the items to increment in order, so that Top-K trackers drop the same items no matter
+// how the map is iterated. Negative increments return ErrInvalidSketch.
+func sortedIncrements(increments map[string]int64) (items []string, err error) {
+	for item, increment := range increments {
+		if increment < 0 {
+			return nil, ErrInvalidSketch
+		}
+
+		items = append(items, item)
+	}
+
+	sort.Strings(items)
+
+	return
+}
@@ -0,0 +1,97 @@
+package redimo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSketches(t *testing.T) {
+	s, err := newSketch(topKKind, 2, 100, 4)
+	assert.NoError(t, err)
+
+	count, _, dropped := s.incr("a", 5)
+	assert.Equal(t, int64(5), count)
+	assert.False(t, dropped)
+
+	s.incr("b", 3)
+
+	_, item, dropped := s.incr("c", 4)
+	assert.True(t, dropped)
+	assert.Equal(t, "b", item)
+
+	_, _, dropped = s.incr("d", 1)
+	assert.False(t, dropped)
+
+	decoded, err := unmarshalSketch(s.marshal(), topKKind)
+	assert.NoError(t, err)
+	assert.Equal(t, s, decoded)
+	assert.Equal(t, []TopKItem{{"a", 5}, {"c", 4}}, decoded.top)
+	assert.True(t, decoded.count("b") >= 3)
+
+	_, err = unmarshalSketch(s.marshal(), countMinSketchKind)
+	assert.Equal(t, ErrSketchNotFound, err)
+
+	_, err = newSketch(countMinSketchKind, 0, 100000, 10)
+	assert.Equal(t, ErrInvalidSketch, err)
+}
+
+func TestCountMinSketch(t *testing.T) {
+	c := newClient(t)
+
+	ok, err := c.CMSINITBYPROB("views", 0.001, 0.01)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.CMSINITBYDIM("views", 10, 10)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	counts, err := c.CMSINCRBY("views", map[string]int64{"home": 10, "about": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"home": 10, "about": 2}, counts)
+
+	_, err = c.CMSINCRBY("views", map[string]int64{"home": 5})
+	assert.NoError(t, err)
+
+	counts, err = c.CMSQUERY("views", "home", "about", "contact")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"home": 15, "about": 2, "contact": 0}, counts)
+
+	_, err = c.CMSINCRBY("views", map[string]int64{"home": -1})
+	assert.Equal(t, ErrInvalidSketch, err)
+
+	_, err = c.CMSINCRBY("nothing", map[string]int64{"home": 1})
+	assert.Equal(t, ErrSketchNotFound, err)
+}
+
+func TestTopK(t *testing.T) {
+	c := newClient(t)
+
+	ok, err := c.TOPKRESERVE("trending", 2, 50, 5)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	dropped, err := c.TOPKADD("trending", "go", "go", "go", "rust", "rust", "zig")
+	assert.NoError(t, err)
+	assert.Empty(t, dropped)
+
+	dropped, err = c.TOPKINCRBY("trending", map[string]int64{"zig": 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rust"}, dropped)
+
+	items, err := c.TOPKLIST("trending")
+	assert.NoError(t, err)
+	assert.Equal(t, []TopKItem{{"zig", 6}, {"go", 3}}, items)
+
+	found, err := c.TOPKQUERY("trending", "go", "rust")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"go": true, "rust": false}, found)
+
+	_, err = c.TOPKADD("trending", strings.Repeat("x", maxTopKItemLength+1))
+	assert.Equal(t, ErrInvalidSketch, err)
+
+	_, err = c.CMSQUERY("trending", "go")
+	assert.Equal(t, ErrSketchNotFound, err)
+}
@@ -0,0 +1,121 @@
+package redimo
+
+import (
+	"math/big"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// bigScorePrecision is the mantissa precision used to decode scores into a big.Float. DynamoDB numbers have up to
+// 38 significant decimal digits, which fit comfortably into 128 bits.
+const bigScorePrecision = 128
+
+// bigScoreDigits is the number of significant decimal digits DynamoDB can store in a number.
+const bigScoreDigits = 38
+
+// MemberBigScore is a sorted set member along with its score at full precision, as returned by the BIG variants of
+// the sorted set commands.
+type MemberBigScore struct {
+	Member string
+	Score  *big.Float
+}
+
+type zBigScore struct {
+	score *big.Float
+}
+
+func (zb zBigScore) ToAV() (av types.AttributeValue) {
+	if zb.present() {
+		av = &types.AttributeValueMemberN{
+			Value: zb.score.Text('g', bigScoreDigits),
+		}
+	}
+
+	return
+}
+
+func (zb zBigScore) present() bool {
+	return zb.score != nil && !zb.score.IsInf()
+}
+
+func (zb zBigScore) exclusive() bool {
+	return false
+}
+
+func zBigScoreFromAV(av types.AttributeValue) *big.Float {
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok || n.Value == "" {
+		return nil
+	}
+
+	f, _, err := big.ParseFloat(n.Value, 10, bigScorePrecision, big.ToNearestEven)
+	if err != nil {
+		return nil
+	}
+
+	return f
+}
+
+// ZADDBIG works like ZADD for a single member, but takes the score as a big.Float. DynamoDB stores numbers with up
+// to 38 significant decimal digits, and the score is written at that precision instead of going through a float64,
+// so values like currency amounts are stored exactly. A nil or infinite score is not a valid score. The flags work
+// like in ZADD, and added is true if the member is new.
+//
+// Scores written with ZADDBIG can be read by every other sorted set command, but the float64 commands will round
+// them to the nearest float64.
+//
+// Cost is O(1) / 1 WCU.
+func (c Client) ZADDBIG(key string, member string, score *big.Float, flags Flags) (added bool, err error) {
+	status, err := c.zAddMember(key, member, zBigScore{score}, nil, flags)
+	if status == MemberAdded {
+		err = c.adjustCardinality(key, 1)
+	}
+
+	return status == MemberAdded, err
+}
+
+// ZSCOREBIG works like ZSCORE, but returns the score at full precision. If member is not in the sorted set, found is
+// false and score is nil.
+//
+// Cost is O(1) / 1 RCU.
+func (c Client) ZSCOREBIG(key string, member string) (score *big.Float, found bool, err error) {
+	av, found, err := c.zScoreAV(key, member)
+	if found {
+		score = zBigScoreFromAV(av)
+	}
+
+	return
+}
+
+// ZINCRBYBIG works like ZINCRBY, but takes the delta and returns the new score as big.Float. The increment is done
+// by DynamoDB in decimal arithmetic, so repeated increments like 0.1 don't accumulate binary rounding errors.
+func (c Client) ZINCRBYBIG(key string, member string, delta *big.Float) (newScore *big.Float, err error) {
+	score, _, err := c.zIncr(key, member, zBigScore{delta}, Flags{})
+
+	return zBigScoreFromAV(score.ToAV()), err
+}
+
+// ZRANGEBYSCOREBIG works like ZRANGEBYSCOREWITHSCORES, but takes the bounds and returns the scores as big.Float. A
+// nil or infinite bound leaves that end of the range open.
+func (c Client) ZRANGEBYSCOREBIG(key string, min, max *big.Float, offset, count int32) (membersWithScores []MemberBigScore, err error) {
+	return c.zRangeBig(key, min, max, offset, count, true)
+}
+
+// ZREVRANGEBYSCOREBIG works like ZRANGEBYSCOREBIG in reverse score order (highest first). Note that max comes before
+// min.
+func (c Client) ZREVRANGEBYSCOREBIG(key string, max, min *big.Float, offset, count int32) (membersWithScores []MemberBigScore, err error) {
+	return c.zRangeBig(key, min, max, offset, count, false)
+}
+
+func (c Client) zRangeBig(key string, min, max *big.Float, offset, count int32, forward bool) (membersWithScores []MemberBigScore, err error) {
+	items, err := c.zGeneralRangeItems(key, zBigScore{min}, zBigScore{max}, offset, count, forward, c.sortKeyNum, false)
+
+	for _, item := range items {
+		membersWithScores = append(membersWithScores, MemberBigScore{
+			Member: parseKey(item, c).sk,
+			Score:  zBigScoreFromAV(item[c.sortKeyNum]),
+		})
+	}
+
+	return
+}
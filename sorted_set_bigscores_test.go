@@ -0,0 +1,71 @@
+package redimo
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bigScore(t *testing.T, s string) *big.Float {
+	f, _, err := big.ParseFloat(s, 10, bigScorePrecision, big.ToNearestEven)
+	assert.NoError(t, err)
+
+	return f
+}
+
+func TestZBigScoreValues(t *testing.T) {
+	score := bigScore(t, "12345678901234567890.123456789")
+	av := zBigScore{score}.ToAV()
+	assert.Equal(t, "12345678901234567890.123456789", zBigScoreFromAV(av).Text('f', 9))
+
+	assert.Nil(t, zBigScore{}.ToAV())
+	assert.Nil(t, zBigScore{new(big.Float).SetInf(false)}.ToAV())
+	assert.Nil(t, zBigScoreFromAV(StringValue{"x"}.ToAV()))
+}
+
+func TestZBigScores(t *testing.T) {
+	c := newClient(t)
+
+	added, err := c.ZADDBIG("z1", "alice", bigScore(t, "10000000000000000.01"), Flags{})
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = c.ZADDBIG("z1", "bob", bigScore(t, "10000000000000000.02"), Flags{})
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = c.ZADDBIG("z1", "alice", bigScore(t, "10000000000000000.03"), Flags{IfNotExists})
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	score, found, err := c.ZSCOREBIG("z1", "alice")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "10000000000000000.01", score.Text('f', 2))
+
+	_, found, err = c.ZSCOREBIG("z1", "nobody")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	newScore, err := c.ZINCRBYBIG("z1", "alice", bigScore(t, "0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "10000000000000000.11", newScore.Text('f', 2))
+
+	members, err := c.ZRANGEBYSCOREBIG("z1", bigScore(t, "10000000000000000.015"), nil, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Equal(t, "bob", members[0].Member)
+	assert.Equal(t, "10000000000000000.02", members[0].Score.Text('f', 2))
+	assert.Equal(t, "alice", members[1].Member)
+
+	members, err = c.ZREVRANGEBYSCOREBIG("z1", nil, nil, 0, 1)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "alice", members[0].Member)
+
+	floatScore, found, err := c.ZSCORE("z1", "bob")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.InDelta(t, 1e16, floatScore, 1)
+}
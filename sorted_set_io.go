@@ -0,0 +1,137 @@
+package redimo
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// zImportBatchSize is the number of members ZIMPORT buffers before writing them, which bounds its memory use.
+const zImportBatchSize = 1000
+
+// ZEXPORT writes every member of the sorted set at key to w as CSV records of the form member,score – lowest score
+// first – and returns the number of members written. Members are read from the score index a page at a time, so
+// sorted sets of any size can be exported with bounded memory. Scores are written with full precision, so the
+// output can be loaded back with ZIMPORT without changes. Exporting stops with the context error when ctx is done.
+//
+// The export is not a snapshot: members that change while the export is running may or may not be included.
+//
+// Cost is O(N) / 1 RCU per 4 KB of member keys.
+func (c Client) ZEXPORT(ctx context.Context, key string, w io.Writer) (count int, err error) {
+	writer := csv.NewWriter(w)
+
+	var writeErr error
+
+	err = c.ZRANGEBYSCOREPAGES(ctx, key, math.Inf(-1), math.Inf(+1), 0, func(page []MemberScore) bool {
+		for _, ms := range page {
+			if writeErr = writer.Write([]string{ms.Member, strconv.FormatFloat(ms.Score, 'g', -1, 64)}); writeErr != nil {
+				return false
+			}
+
+			count++
+		}
+
+		return true
+	})
+	if writeErr != nil {
+		return count, writeErr
+	}
+
+	if err != nil {
+		return
+	}
+
+	writer.Flush()
+
+	return count, writer.Error()
+}
+
+// ZIMPORT reads CSV records of the form member,score from r – the format written by ZEXPORT – and adds them to the
+// sorted set at key, returning the number of members written. Records are read in batches and written with batched
+// writes, with up to concurrency writes in flight, like ZADDBULK: existing members are overwritten and the import is
+// not atomic, and CountedCardinality rebuilds the member count afterwards. A malformed record stops the import with
+// an error that includes the record number, after the records before it have been written. Importing also stops with
+// the context error when ctx is done.
+//
+// Cost is O(N) / 1 WCU per member, plus the cost of RepairCardinality with CountedCardinality.
+func (c Client) ZIMPORT(ctx context.Context, key string, r io.Reader, concurrency int) (count int, err error) {
+	defer func() {
+		if count == 0 || !c.counted(key) {
+			return
+		}
+
+		if _, rErr := c.RepairCardinality(key); err == nil {
+			err = rErr
+		}
+	}()
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+
+	requests := make([]types.WriteRequest, 0, zImportBatchSize)
+	seen := make(map[string]struct{})
+
+	flush := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := c.batchWriteParallel(requests, concurrency); err != nil {
+			return err
+		}
+
+		count += len(requests)
+		requests = requests[:0]
+		seen = make(map[string]struct{})
+
+		return nil
+	}
+
+	for records := 1; ; records++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var score float64
+		if err == nil {
+			score, err = strconv.ParseFloat(record[1], 64)
+			if err != nil {
+				err = fmt.Errorf("invalid score in record %v: %w", records, err)
+			}
+		}
+
+		if err != nil {
+			if flushErr := flush(); flushErr != nil {
+				return count, flushErr
+			}
+
+			return count, err
+		}
+
+		// A batch can't write the same key twice, so a repeated member starts a new batch.
+		if _, ok := seen[record[0]]; ok || len(requests) == zImportBatchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+
+		seen[record[0]] = struct{}{}
+		requests = append(requests, putRequest(c.zMemberItem(key, record[0], score)))
+	}
+
+	if err = flush(); err != nil {
+		return
+	}
+
+	if c.countedCardinality {
+		_, err = c.RepairCardinality(key)
+	}
+
+	return
+}
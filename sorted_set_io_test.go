@@ -0,0 +1,59 @@
+package redimo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestZExportImport(t *testing.T) {
+	c := newClient(t)
+
+	membersWithScores := make(map[string]float64)
+	for i := 0; i < 1500; i++ {
+		membersWithScores[fmt.Sprintf("m%04d", i)] = float64(i) / 3
+	}
+
+	membersWithScores["with,comma"] = -1
+
+	err := c.ZADDBULK("z1", membersWithScores, 4)
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+
+	count, err := c.ZEXPORT(context.Background(), "z1", &buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1501, count)
+	assert.True(t, strings.HasPrefix(buffer.String(), "\"with,comma\",-1\nm0000,0\nm0001,0.3333333333333333\n"))
+
+	_, err = c.ZEXPORT(context.Background(), "z1", failingWriter{})
+	assert.Equal(t, io.ErrClosedPipe, err)
+
+	count, err = c.ZIMPORT(context.Background(), "z2", &buffer, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 1501, count)
+
+	imported, err := c.ZRANGEBYSCORE("z2", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, membersWithScores, imported)
+
+	count, err = c.ZIMPORT(context.Background(), "z3", strings.NewReader("a,1\nb,2\na,3\nc,three\nd,4\n"), 1)
+	assert.Error(t, err)
+	assert.Equal(t, 3, count)
+
+	imported, err = c.ZRANGEBYSCORE("z3", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"a": 3, "b": 2}, imported)
+}
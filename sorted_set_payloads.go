@@ -0,0 +1,79 @@
+package redimo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// MemberPayload is a sorted set member along with its score and the payload stored with it by ZADDPAYLOAD.
+// Members that were added without a payload have an empty payload.
+type MemberPayload struct {
+	Member  string
+	Score   float64
+	Payload ReturnValue
+}
+
+// ZADDPAYLOAD adds member with the given score to the sorted set at key, storing payload in the same item. The score
+// and the payload are written together in a single atomic update, so readers never see one without the other. The
+// flags work like in ZADD, and added is true if the member is new.
+//
+// ZADD and ZINCRBY only change the score, so the payload of a member stays in place when its score changes.
+//
+// Cost is O(1) / 1 WCU per 1 KB of payload.
+func (c Client) ZADDPAYLOAD(key string, member string, score float64, payload Value, flags Flags) (added bool, err error) {
+	status, err := c.zAddMember(key, member, zScore{score}, map[string]Value{vk: payload}, flags)
+	if status == MemberAdded {
+		err = c.adjustCardinality(key, 1)
+	}
+
+	return status == MemberAdded, err
+}
+
+// ZSCOREPAYLOAD returns the score and the payload of member. If member is not in the sorted set, found is false.
+//
+// Cost is O(1) / 1 RCU per 4 KB of payload.
+func (c Client) ZSCOREPAYLOAD(key string, member string) (score float64, payload ReturnValue, found bool, err error) {
+	resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(c.consistentReads),
+		Key:            keyDef{pk: key, sk: member}.toAV(c),
+		TableName:      aws.String(c.tableName),
+	})
+	if err == nil && len(resp.Item) > 0 && !c.expired(resp.Item) {
+		found = true
+		score = zScoreFromAV(resp.Item[c.sortKeyNum])
+		payload = parseItem(resp.Item, c).val
+	}
+
+	return
+}
+
+// ZRANGEBYSCOREPAYLOADS works like ZRANGEBYSCOREWITHSCORES, but returns the payload of each member as well.
+//
+// The score index only holds the keys of the members, so DynamoDB fetches each payload from the table as well, which
+// costs an extra read for every member returned.
+func (c Client) ZRANGEBYSCOREPAYLOADS(key string, min, max float64, offset, count int32) (membersWithPayloads []MemberPayload, err error) {
+	return c.zRangePayloads(key, zScore{min}, zScore{max}, offset, count, true)
+}
+
+// ZREVRANGEBYSCOREPAYLOADS works like ZRANGEBYSCOREPAYLOADS in reverse score order (highest first). Note that max
+// comes before min.
+func (c Client) ZREVRANGEBYSCOREPAYLOADS(key string, max, min float64, offset, count int32) (membersWithPayloads []MemberPayload, err error) {
+	return c.zRangePayloads(key, zScore{min}, zScore{max}, offset, count, false)
+}
+
+func (c Client) zRangePayloads(key string, min, max zScore, offset, count int32, forward bool) (membersWithPayloads []MemberPayload, err error) {
+	items, err := c.zGeneralRangeItems(key, min, max, offset, count, forward, c.sortKeyNum, true)
+
+	for _, item := range items {
+		pi := parseItem(item, c)
+		membersWithPayloads = append(membersWithPayloads, MemberPayload{
+			Member:  pi.sk,
+			Score:   zScoreFromAV(item[c.sortKeyNum]),
+			Payload: pi.val,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,56 @@
+package redimo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZPayloads(t *testing.T) {
+	c := newClient(t)
+
+	added, err := c.ZADDPAYLOAD("z1", "alice", 10, StringValue{"team red"}, Flags{})
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = c.ZADDPAYLOAD("z1", "bob", 20, BytesValue{[]byte{1, 2, 3}}, Flags{})
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	_, err = c.ZADD("z1", map[string]float64{"carol": 30}, Flags{})
+	assert.NoError(t, err)
+
+	added, err = c.ZADDPAYLOAD("z1", "alice", 15, StringValue{"team blue"}, Flags{})
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	_, err = c.ZINCRBY("z1", "bob", 5)
+	assert.NoError(t, err)
+
+	score, payload, found, err := c.ZSCOREPAYLOAD("z1", "alice")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, float64(15), score)
+	assert.Equal(t, "team blue", payload.String())
+
+	_, _, found, err = c.ZSCOREPAYLOAD("z1", "nobody")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	membersWithPayloads, err := c.ZRANGEBYSCOREPAYLOADS("z1", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(membersWithPayloads))
+	assert.Equal(t, "alice", membersWithPayloads[0].Member)
+	assert.Equal(t, "team blue", membersWithPayloads[0].Payload.String())
+	assert.Equal(t, "bob", membersWithPayloads[1].Member)
+	assert.Equal(t, float64(25), membersWithPayloads[1].Score)
+	assert.Equal(t, []byte{1, 2, 3}, membersWithPayloads[1].Payload.Bytes())
+	assert.Equal(t, "carol", membersWithPayloads[2].Member)
+	assert.True(t, membersWithPayloads[2].Payload.Empty())
+
+	membersWithPayloads, err = c.ZREVRANGEBYSCOREPAYLOADS("z1", 25, math.Inf(-1), 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(membersWithPayloads))
+	assert.Equal(t, "bob", membersWithPayloads[0].Member)
+}
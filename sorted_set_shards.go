@@ -0,0 +1,236 @@
+package redimo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// zShardKeys returns the keys of the partitions the sorted set at key is spread over.
+func (c Client) zShardKeys(key string) []string {
+	if c.sortedSetShards <= 1 {
+		return []string{key}
+	}
+
+	keys := make([]string, c.sortedSetShards)
+	for shard := range keys {
+		keys[shard] = fmt.Sprintf("_redimo/%v/shard/%v", key, shard)
+	}
+
+	return keys
+}
+
+// zDerivedKeys returns the shards of the sorted set at key, which COPY copies along with the key. Sorted sets that
+// aren't sharded have none.
+func (c Client) zDerivedKeys(key string) []string {
+	if c.sortedSetShards <= 1 {
+		return nil
+	}
+
+	return c.zShardKeys(key)
+}
+
+// zMemberShardKey returns the key of the partition that holds member. Members are assigned by a hash of their name,
+// so a member always lives in the same shard and updates to it never create duplicates in other shards.
+func (c Client) zMemberShardKey(key string, member string) string {
+	keys := c.zShardKeys(key)
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(member))
+
+	return keys[h.Sum32()%uint32(len(keys))]
+}
+
+// zEachShard calls fn for every shard of the sorted set at key concurrently and returns the first error.
+func (c Client) zEachShard(key string, fn func(shardKey string) error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for _, shardKey := range c.zShardKeys(key) {
+		wg.Add(1)
+
+		go func(shardKey string) {
+			defer wg.Done()
+
+			if err := fn(shardKey); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(shardKey)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+// ZADDSHARDED works like ZADD on a sorted set that is spread over the number of partitions set with
+// SortedSetShards. Each member is written to the shard picked by a hash of its name, so a hot sorted set can take
+// as many writes as all of its partitions together instead of being limited by a single partition.
+//
+// Cost is O(N) / 1 WCU per member, like ZADD.
+func (c Client) ZADDSHARDED(key string, membersWithScores map[string]float64, flags Flags) (addedMembers []string, err error) {
+	shards := make(map[string]map[string]float64)
+
+	for member, score := range membersWithScores {
+		shardKey := c.zMemberShardKey(key, member)
+		if shards[shardKey] == nil {
+			shards[shardKey] = make(map[string]float64)
+		}
+
+		shards[shardKey][member] = score
+	}
+
+	for shardKey, shardMembers := range shards {
+		added, err := c.ZADD(shardKey, shardMembers, flags)
+		addedMembers = append(addedMembers, added...)
+
+		if err != nil {
+			return addedMembers, err
+		}
+	}
+
+	return
+}
+
+// ZINCRBYSHARDED works like ZINCRBY on a sorted set written with ZADDSHARDED.
+func (c Client) ZINCRBYSHARDED(key string, member string, delta float64) (newScore float64, err error) {
+	return c.ZINCRBY(c.zMemberShardKey(key, member), member, delta)
+}
+
+// ZSCORESHARDED works like ZSCORE on a sorted set written with ZADDSHARDED. Only the shard that holds member is
+// read.
+func (c Client) ZSCORESHARDED(key string, member string) (score float64, found bool, err error) {
+	return c.ZSCORE(c.zMemberShardKey(key, member), member)
+}
+
+// ZREMSHARDED works like ZREM on a sorted set written with ZADDSHARDED.
+func (c Client) ZREMSHARDED(key string, members ...string) (removedMembers []string, err error) {
+	shards := make(map[string][]string)
+
+	for _, member := range members {
+		shardKey := c.zMemberShardKey(key, member)
+		shards[shardKey] = append(shards[shardKey], member)
+	}
+
+	for shardKey, shardMembers := range shards {
+		removed, err := c.ZREM(shardKey, shardMembers...)
+		removedMembers = append(removedMembers, removed...)
+
+		if err != nil {
+			return removedMembers, err
+		}
+	}
+
+	return
+}
+
+// ZCARDSHARDED works like ZCARD on a sorted set written with ZADDSHARDED, adding up the member counts of all the
+// shards, which are read concurrently.
+func (c Client) ZCARDSHARDED(key string) (count int32, err error) {
+	var mu sync.Mutex
+
+	err = c.zEachShard(key, func(shardKey string) error {
+		shardCount, err := c.ZCARD(shardKey)
+
+		mu.Lock()
+		count += shardCount
+		mu.Unlock()
+
+		return err
+	})
+
+	return
+}
+
+// ZCOUNTSHARDED works like ZCOUNT on a sorted set written with ZADDSHARDED, adding up the counts of all the shards,
+// which are counted concurrently.
+func (c Client) ZCOUNTSHARDED(key string, minScore, maxScore float64) (count int32, err error) {
+	var mu sync.Mutex
+
+	err = c.zEachShard(key, func(shardKey string) error {
+		shardCount, err := c.ZCOUNT(shardKey, minScore, maxScore)
+
+		mu.Lock()
+		count += shardCount
+		mu.Unlock()
+
+		return err
+	})
+
+	return
+}
+
+// ZRANGEBYSCORESHARDED works like ZRANGEBYSCOREWITHSCORES on a sorted set written with ZADDSHARDED. Every shard is
+// queried concurrently for the first offset+count members in the range, and the results are merged in score order,
+// with members that have the same score in lexicographical order.
+//
+// Each shard has to return up to offset+count members, so the cost is up to the number of shards times that of
+// ZRANGEBYSCOREWITHSCORES.
+func (c Client) ZRANGEBYSCORESHARDED(key string, min, max float64, offset, count int32) (membersWithScores []MemberScore, err error) {
+	return c.zRangeSharded(key, min, max, offset, count, true)
+}
+
+// ZREVRANGEBYSCORESHARDED works like ZRANGEBYSCORESHARDED in reverse score order (highest first). Note that max
+// comes before min.
+func (c Client) ZREVRANGEBYSCORESHARDED(key string, max, min float64, offset, count int32) (membersWithScores []MemberScore, err error) {
+	return c.zRangeSharded(key, min, max, offset, count, false)
+}
+
+func (c Client) zRangeSharded(key string, min, max float64, offset, count int32, forward bool) (membersWithScores []MemberScore, err error) {
+	if offset < 0 {
+		return nil, nil
+	}
+
+	shardCount := AllResults
+	if count > 0 {
+		shardCount = offset + count
+	}
+
+	var mu sync.Mutex
+
+	err = c.zEachShard(key, func(shardKey string) error {
+		shardMembers, err := c.zGeneralRangeOrdered(shardKey, zScore{min}, zScore{max}, 0, shardCount, forward, c.sortKeyNum)
+
+		mu.Lock()
+		membersWithScores = append(membersWithScores, shardMembers...)
+		mu.Unlock()
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(membersWithScores, func(i, j int) bool {
+		a, b := membersWithScores[i], membersWithScores[j]
+		if !forward {
+			a, b = b, a
+		}
+
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+
+		return a.Member < b.Member
+	})
+
+	if int(offset) >= len(membersWithScores) {
+		return nil, nil
+	}
+
+	membersWithScores = membersWithScores[offset:]
+
+	if count > 0 && int(count) < len(membersWithScores) {
+		membersWithScores = membersWithScores[:count]
+	}
+
+	return membersWithScores, nil
+}
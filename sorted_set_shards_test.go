@@ -0,0 +1,78 @@
+package redimo
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZShardKeys(t *testing.T) {
+	c := Client{}
+	assert.Equal(t, []string{"z1"}, c.zShardKeys("z1"))
+	assert.Equal(t, "z1", c.zMemberShardKey("z1", "alice"))
+
+	c = c.SortedSetShards(3)
+	assert.Equal(t, []string{"_redimo/z1/shard/0", "_redimo/z1/shard/1", "_redimo/z1/shard/2"}, c.zShardKeys("z1"))
+	assert.Equal(t, c.zMemberShardKey("z1", "alice"), c.zMemberShardKey("z1", "alice"))
+	assert.Contains(t, c.zShardKeys("z1"), c.zMemberShardKey("z1", "alice"))
+}
+
+func TestZSharded(t *testing.T) {
+	c := newClient(t).SortedSetShards(4)
+
+	membersWithScores := make(map[string]float64)
+	for i := 0; i < 20; i++ {
+		membersWithScores[fmt.Sprintf("m%02d", i)] = float64(i % 10)
+	}
+
+	added, err := c.ZADDSHARDED("z1", membersWithScores, Flags{})
+	assert.NoError(t, err)
+	assert.Len(t, added, 20)
+
+	count, err := c.ZCARDSHARDED("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(20), count)
+
+	count, err = c.ZCOUNTSHARDED("z1", 2, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), count)
+
+	newScore, err := c.ZINCRBYSHARDED("z1", "m00", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), newScore)
+
+	score, found, err := c.ZSCORESHARDED("z1", "m00")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, float64(100), score)
+
+	members, err := c.ZRANGEBYSCORESHARDED("z1", math.Inf(-1), math.Inf(+1), 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m01", 1}, {"m11", 1}, {"m02", 2}}, members)
+
+	members, err = c.ZREVRANGEBYSCORESHARDED("z1", math.Inf(+1), math.Inf(-1), 0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m00", 100}, {"m19", 9}, {"m09", 9}}, members)
+
+	removed, err := c.ZREMSHARDED("z1", "m00", "m01", "missing")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m00", "m01"}, removed)
+
+	count, err = c.ZCARDSHARDED("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(18), count)
+}
+
+func TestCountedZSharded(t *testing.T) {
+	c := newClient(t).SortedSetShards(3).CountedCardinality()
+
+	added, err := c.ZADDSHARDED("z1", map[string]float64{"a": 1, "b": 2, "c": 3, "d": 4}, Flags{})
+	assert.NoError(t, err)
+	assert.Len(t, added, 4)
+
+	count, err := c.ZCARDSHARDED("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), count)
+}
@@ -2,10 +2,13 @@ package redimo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -38,9 +41,19 @@ var accumulators = map[ZAggregation]func(float64, float64) float64{
 	},
 }
 
+// zAccumulator returns the accumulator for aggregation, defaulting to SUM like Redis does.
+func zAccumulator(aggregation ZAggregation) func(float64, float64) float64 {
+	if accumulate, ok := accumulators[aggregation]; ok {
+		return accumulate
+	}
+
+	return accumulators[ZAggregationSum]
+}
+
 type rangeCap interface {
 	Value
 	present() bool
+	exclusive() bool
 }
 type zScore struct {
 	score float64
@@ -60,8 +73,29 @@ func (zs zScore) present() bool {
 	return !math.IsInf(zs.score, +1) && !math.IsInf(zs.score, -1)
 }
 
+func (zs zScore) exclusive() bool {
+	return false
+}
+
 type zLex struct {
-	lex string
+	lex  string
+	excl bool
+}
+
+// zLexBound parses the Redis lexicographical range syntax: "[m" is an inclusive bound, "(m" is an exclusive
+// bound, and "-" and "+" are open ends. For backwards compatibility, a bound without a prefix is inclusive and
+// the empty string is an open end.
+func zLexBound(bound string) zLex {
+	switch {
+	case bound == "-" || bound == "+":
+		return zLex{}
+	case strings.HasPrefix(bound, "["):
+		return zLex{lex: bound[1:]}
+	case strings.HasPrefix(bound, "("):
+		return zLex{lex: bound[1:], excl: true}
+	}
+
+	return zLex{lex: bound}
 }
 
 func (zl zLex) ToAV() (av types.AttributeValue) {
@@ -78,82 +112,334 @@ func (zl zLex) present() bool {
 	return zl.lex != ""
 }
 
+func (zl zLex) exclusive() bool {
+	return zl.excl && zl.present()
+}
+
+// zRangeCondition adds the key condition for a range between start and stop on attribute. DynamoDB allows
+// only one condition on the sort key, so when both ends are present the range is queried with BETWEEN, and
+// any exclusive end has to be dropped from the results with zExcluded.
+func zRangeCondition(builder *expressionBuilder, attribute string, start rangeCap, stop rangeCap) {
+	if start.present() {
+		builder.values["start"] = start.ToAV()
+	}
+
+	if stop.present() {
+		builder.values["stop"] = stop.ToAV()
+	}
+
+	switch {
+	case start.present() && stop.present():
+		builder.condition(fmt.Sprintf("#%v BETWEEN :start AND :stop", attribute), attribute)
+	case start.present() && start.exclusive():
+		builder.condition(fmt.Sprintf("#%v > :start", attribute), attribute)
+	case start.present():
+		builder.condition(fmt.Sprintf("#%v >= :start", attribute), attribute)
+	case stop.present() && stop.exclusive():
+		builder.condition(fmt.Sprintf("#%v < :stop", attribute), attribute)
+	case stop.present():
+		builder.condition(fmt.Sprintf("#%v <= :stop", attribute), attribute)
+	}
+}
+
+// zExcluded reports whether a member returned by a BETWEEN query sits on an exclusive end of the range.
+func zExcluded(member string, start rangeCap, stop rangeCap) bool {
+	if !start.present() || !stop.present() {
+		return false
+	}
+
+	if lex, ok := start.(zLex); ok && lex.exclusive() && lex.lex == member {
+		return true
+	}
+
+	if lex, ok := stop.(zLex); ok && lex.exclusive() && lex.lex == member {
+		return true
+	}
+
+	return false
+}
+
 func zScoreFromAV(av types.AttributeValue) float64 {
 	return ReturnValue{av}.Float()
 }
 
-func (c Client) ZADD(key string, membersWithScores map[string]float64, flags Flags) (addedMembers []string, err error) {
-	for member, score := range membersWithScores {
-		builder := newExpresionBuilder()
-		builder.updateSetAV(c.sortKeyNum, zScore{score}.ToAV())
+// AllResults can be passed as the count of the range commands that take an offset and a count, like
+// ZRANGEBYSCORE and ZRANGEBYLEX, to return every member after the offset – the same as LIMIT offset -1 in Redis.
+// Any negative count works the same way, and so does a count of zero, which is kept for backwards compatibility.
+// A negative offset returns no members at all, like in Redis.
+const AllResults int32 = -1
 
-		if flags.has(IfNotExists) {
-			builder.addConditionNotExists(c.partitionKey)
-		}
+// ErrMixedScores is returned by the lexicographical range commands, like ZRANGEBYLEX, when StrictLex is enabled
+// and the members of the sorted set don't all have the same score.
+var ErrMixedScores = errors.New("lexicographical range on a sorted set with different scores")
+
+// MemberScore is a sorted set member along with its score, used where the order of the members matters.
+type MemberScore struct {
+	Member string
+	Score  float64
+}
+
+// MemberStatus is the outcome of writing a single member in the multi-member commands that report per-member
+// results, like ZADDRESULTS.
+type MemberStatus string
+
+const (
+	MemberAdded   MemberStatus = "ADDED"
+	MemberUpdated MemberStatus = "UPDATED"
+	MemberRemoved MemberStatus = "REMOVED"
+	MemberSkipped MemberStatus = "SKIPPED"
+	MemberFailed  MemberStatus = "FAILED"
+)
+
+// MemberResult is the outcome of writing a single member. Err is only set when Status is MemberFailed.
+type MemberResult struct {
+	Status MemberStatus
+	Err    error
+}
 
-		if flags.has(IfAlreadyExists) {
-			builder.addConditionExists(c.partitionKey)
+// ExclusiveMin turns score into an exclusive lower bound for the score range operations (ZRANGEBYSCORE,
+// ZREVRANGEBYSCORE, ZCOUNT, ZREMRANGEBYSCORE and their variants), like the "(1.5" syntax in Redis. Scores are
+// stored with full float64 precision, so the next representable float above score is an exact substitute:
+// ZCOUNT(key, ExclusiveMin(1.5), 3) counts the members with 1.5 < score <= 3.
+func ExclusiveMin(score float64) float64 {
+	return math.Nextafter(score, math.Inf(+1))
+}
+
+// ExclusiveMax turns score into an exclusive upper bound for the score range operations, the counterpart of
+// ExclusiveMin: ZCOUNT(key, 1.5, ExclusiveMax(3)) counts the members with 1.5 <= score < 3.
+func ExclusiveMax(score float64) float64 {
+	return math.Nextafter(score, math.Inf(-1))
+}
+
+// ZADD adds the members with their scores to the sorted set at key, or updates the scores of existing members, and
+// returns the members that were newly added. With IfNotExists only new members are added, and with
+// IfAlreadyExists only existing members are updated.
+//
+// Without flags, the members that already exist are read with BatchGetItem and all members are written with
+// BatchWriteItem, 25 at a time, which is much cheaper than one update per member for large numbers of members. The
+// other attributes of existing members, like payloads, are kept, but a concurrent write to one of the members between
+// the read and the write can be overwritten. With flags, every member is written with its own conditional update.
+//
+// Cost is O(N) / 1 WCU per member, plus 1 RCU per 2 members read without flags.
+//
+// Works similar to https://redis.io/commands/zadd
+func (c Client) ZADD(key string, membersWithScores map[string]float64, flags Flags) (addedMembers []string, err error) {
+	defer func() {
+		if cErr := c.adjustCardinality(key, len(addedMembers)); err == nil {
+			err = cErr
 		}
+	}()
 
-		resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-			ConditionExpression:       builder.conditionExpression(),
-			ExpressionAttributeNames:  builder.expressionAttributeNames(),
-			ExpressionAttributeValues: builder.expressionAttributeValues(),
-			Key:                       keyDef{pk: key, sk: member}.toAV(c),
-			ReturnValues:              types.ReturnValueAllOld,
-			TableName:                 aws.String(c.tableName),
-			UpdateExpression:          builder.updateExpression(),
-		})
-		if conditionFailureError(err) {
-			continue
+	addedMembers, _, err = c.zAdd(key, membersWithScores, flags)
+
+	return
+}
+
+// ZADDCOUNTS works like ZADD, but returns the number of members that were added and the number of existing members
+// whose score changed, like the CH option in Redis reports changed members. Members that are written with the
+// score they already had, or skipped because of the flags, are in neither count. The previous scores come from the
+// same reads and updates that ZADD does, so the counts don't cost anything extra.
+//
+// Works similar to https://redis.io/commands/zadd with the CH option
+func (c Client) ZADDCOUNTS(key string, membersWithScores map[string]float64, flags Flags) (added int32, updated int32, err error) {
+	defer func() {
+		if cErr := c.adjustCardinality(key, int(added)); err == nil {
+			err = cErr
 		}
+	}()
+
+	addedMembers, changedMembers, err := c.zAdd(key, membersWithScores, flags)
+
+	return int32(len(addedMembers)), int32(len(changedMembers)), err
+}
+
+// zAdd writes the members for ZADD and ZADDCOUNTS, and returns the members that were added and the existing members
+// whose score changed. The member count is left to the caller.
+func (c Client) zAdd(key string, membersWithScores map[string]float64, flags Flags) (addedMembers []string, changedMembers []string, err error) {
+	if flags.unconditional() {
+		return c.zAddBatch(key, membersWithScores)
+	}
 
+	for member, score := range membersWithScores {
+		status, oldScore, err := c.zWriteMember(key, member, zScore{score}, nil, flags)
 		if err != nil {
-			return addedMembers, err
+			return addedMembers, changedMembers, err
 		}
 
-		if len(resp.Attributes) == 0 {
+		switch {
+		case status == MemberAdded:
 			addedMembers = append(addedMembers, member)
+		case status == MemberUpdated && zScoreFromAV(oldScore) != score:
+			changedMembers = append(changedMembers, member)
 		}
 	}
 
 	return
 }
 
-func (c Client) ZCARD(key string) (count int32, err error) {
-	return c.HLEN(key)
+// ZADDRESULTS works like ZADD, but reports the outcome of every member instead of only the added ones: whether
+// it was added, updated, skipped because of the flags, or failed – in which case the result holds the error.
+// A failed member doesn't stop the remaining members from being written, so err is only set if the member count
+// of CountedCardinality couldn't be updated.
+//
+// Works similar to https://redis.io/commands/zadd
+func (c Client) ZADDRESULTS(key string, membersWithScores map[string]float64, flags Flags) (results map[string]MemberResult, err error) {
+	results = make(map[string]MemberResult)
+	added := 0
+
+	for member, score := range membersWithScores {
+		status, err := c.zAddMember(key, member, zScore{score}, nil, flags)
+		results[member] = MemberResult{Status: status, Err: err}
+
+		if status == MemberAdded {
+			added++
+		}
+	}
+
+	err = c.adjustCardinality(key, added)
+
+	return
 }
 
-func (c Client) ZCOUNT(key string, minScore, maxScore float64) (count int32, err error) {
-	return c.zGeneralCount(key, zScore{minScore}, zScore{maxScore}, c.sortKeyNum)
+func (c Client) zAddMember(key string, member string, score Value, attributes map[string]Value, flags Flags) (status MemberStatus, err error) {
+	status, _, err = c.zWriteMember(key, member, score, attributes, flags)
+
+	return
 }
 
-func (c Client) zGeneralCount(key string, min rangeCap, max rangeCap, attribute string) (count int32, err error) {
+// zWriteMember writes a single member like zAddMember, and also returns the score the member had before.
+func (c Client) zWriteMember(key string, member string, score Value, attributes map[string]Value, flags Flags) (status MemberStatus, oldScore types.AttributeValue, err error) {
 	builder := newExpresionBuilder()
-	builder.addConditionEquality(c.partitionKey, StringValue{key})
+	builder.updateSetAV(c.sortKeyNum, score.ToAV())
+	builder.updateTTL(flags)
+
+	for name, value := range attributes {
+		if value != nil {
+			builder.updateSET(name, value)
+		}
+	}
 
-	betweenRange := min.present() && max.present()
+	if flags.has(IfNotExists) {
+		builder.addConditionNotExists(c.partitionKey)
+	}
 
-	if betweenRange {
-		builder.condition(fmt.Sprintf("#%v BETWEEN :min AND :max", attribute), attribute)
+	if flags.has(IfAlreadyExists) {
+		builder.addConditionExists(c.partitionKey)
 	}
 
-	if min.present() {
-		builder.values["min"] = min.ToAV()
+	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		ConditionExpression:       builder.conditionExpression(),
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       keyDef{pk: key, sk: member}.toAV(c),
+		ReturnValues:              types.ReturnValueAllOld,
+		TableName:                 aws.String(c.tableName),
+		UpdateExpression:          builder.updateExpression(),
+	})
 
-		if !betweenRange {
-			builder.condition(fmt.Sprintf("#%v >= :min", attribute), attribute)
-		}
+	switch {
+	case conditionFailureError(err):
+		return MemberSkipped, nil, nil
+	case err != nil:
+		return MemberFailed, nil, err
+	case len(resp.Attributes) == 0:
+		return MemberAdded, nil, nil
+	}
+
+	return MemberUpdated, resp.Attributes[c.sortKeyNum], nil
+}
+
+// zAddBatch writes members without flags with BatchWriteItem, see ZADD. Existing members are read first, so that
+// their other attributes are kept and the new and changed members can be told apart from the others.
+func (c Client) zAddBatch(key string, membersWithScores map[string]float64) (addedMembers []string, changedMembers []string, err error) {
+	keys := make([]keyDef, 0, len(membersWithScores))
+	for member := range membersWithScores {
+		keys = append(keys, keyDef{pk: key, sk: member})
+	}
+
+	existing, err := c.batchGet(keys)
+	if err != nil {
+		return
 	}
 
-	if max.present() {
-		builder.values["max"] = max.ToAV()
+	existingItems := make(map[string]map[string]types.AttributeValue, len(existing))
+	for _, item := range existing {
+		existingItems[parseKey(item, c).sk] = item
+	}
 
-		if !betweenRange {
-			builder.condition(fmt.Sprintf("#%v <= :max", attribute), attribute)
+	requests := make([]types.WriteRequest, 0, len(membersWithScores))
+
+	for member, score := range membersWithScores {
+		item, found := existingItems[member]
+		if !found {
+			addedMembers = append(addedMembers, member)
+			requests = append(requests, putRequest(c.zMemberItem(key, member, score)))
+
+			continue
+		}
+
+		if zScoreFromAV(item[c.sortKeyNum]) != score {
+			changedMembers = append(changedMembers, member)
 		}
+
+		item = withPartitionKey(item, c.partitionKey, key)
+		item[c.sortKeyNum] = zScore{score}.ToAV()
+		delete(item, ttlKey)
+
+		requests = append(requests, putRequest(item))
 	}
 
+	if err = c.batchWrite(requests); err != nil {
+		return nil, nil, err
+	}
+
+	return
+}
+
+// ZADDBULK is a bulk loading alternative to ZADD for large numbers of members. Members are written with
+// BatchWriteItem in chunks of 25, with up to concurrency chunks in flight at the same time. Unprocessed
+// items are retried with backoff. The other bulk loads, like SADDBULK, work the same way.
+//
+// Unlike ZADD there are no conditional flags and no report of which members were newly added: every member
+// is written unconditionally, replacing any existing item for that member. The load is not atomic – if an
+// error is returned, some of the members may have been written. Since the writes don't tell new members from
+// existing ones, CountedCardinality rebuilds the member count with RepairCardinality after the load, which counts
+// every member of the sorted set again.
+//
+// Cost is O(N) / 1 WCU per member, plus the cost of RepairCardinality with CountedCardinality.
+func (c Client) ZADDBULK(key string, membersWithScores map[string]float64, concurrency int) (err error) {
+	requests := make([]types.WriteRequest, 0, len(membersWithScores))
+	for member, score := range membersWithScores {
+		requests = append(requests, putRequest(c.zMemberItem(key, member, score)))
+	}
+
+	return c.bulkWrite(key, requests, concurrency)
+}
+
+// ZCARD returns the number of members in the sorted set at key. By default the members are counted with a
+// query, which costs 1 RCU per 4 KB of members. With CountedCardinality the maintained count is read instead.
+//
+// Cost is O(N) / O(1) with CountedCardinality.
+//
+// Works similar to https://redis.io/commands/zcard
+func (c Client) ZCARD(key string) (count int32, err error) {
+	return c.cardinality(key)
+}
+
+// ZCOUNT counts the members with scores between minScore and maxScore, both inclusive. Counting reads the keys of
+// every member in the range from the score index, so the cost grows with the number of members counted. For huge
+// ranges, ParallelCounting splits the range into segments that are counted concurrently.
+//
+// Works similar to https://redis.io/commands/zcount
+func (c Client) ZCOUNT(key string, minScore, maxScore float64) (count int32, err error) {
+	return c.zCountScores(key, minScore, maxScore)
+}
+
+func (c Client) zGeneralCount(key string, min rangeCap, max rangeCap, attribute string) (count int32, err error) {
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{key})
+	zRangeCondition(&builder, attribute, min, max)
+
 	hasMoreResults := true
 
 	var lastEvaluatedKey map[string]types.AttributeValue
@@ -165,7 +451,7 @@ func (c Client) zGeneralCount(key string, min rangeCap, max rangeCap, attribute
 	}
 
 	for hasMoreResults {
-		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
+		resp, err := c.ddbClient.Query(context.TODO(), c.filterExpiredItems(&dynamodb.QueryInput{
 			ConsistentRead:            aws.Bool(c.consistentReads),
 			ExclusiveStartKey:         lastEvaluatedKey,
 			ExpressionAttributeNames:  builder.expressionAttributeNames(),
@@ -174,7 +460,7 @@ func (c Client) zGeneralCount(key string, min rangeCap, max rangeCap, attribute
 			KeyConditionExpression:    builder.conditionExpression(),
 			Select:                    types.SelectCount,
 			TableName:                 aws.String(c.tableName),
-		})
+		}))
 
 		if err != nil {
 			return count, err
@@ -189,195 +475,864 @@ func (c Client) zGeneralCount(key string, min rangeCap, max rangeCap, attribute
 		}
 	}
 
-	return
+	if min.present() && max.present() {
+		count, err = c.zUncountExcluded(key, count, min, max)
+	}
+
+	return
+}
+
+// zUncountExcluded removes the members on the exclusive ends of a BETWEEN range from count, if they exist.
+func (c Client) zUncountExcluded(key string, count int32, min rangeCap, max rangeCap) (int32, error) {
+	excluded := make(map[string]struct{})
+
+	for _, bound := range []rangeCap{min, max} {
+		if lex, ok := bound.(zLex); ok && lex.exclusive() {
+			excluded[lex.lex] = struct{}{}
+		}
+	}
+
+	for member := range excluded {
+		_, found, err := c.ZSCORE(key, member)
+		if err != nil {
+			return count, err
+		}
+
+		if found {
+			count--
+		}
+	}
+
+	return count, nil
+}
+
+// ZINCRBY adds delta to the score of member, creating the member with delta as its score if it doesn't exist, and
+// returns the new score.
+//
+// Scores are stored as a native DynamoDB number in the sort key number attribute, so the increment is a single
+// atomic ADD update. There is no read-modify-write cycle, and concurrent increments on a hot member are all applied
+// without retries or contention failures.
+//
+// Works similar to https://redis.io/commands/zincrby
+func (c Client) ZINCRBY(key string, member string, delta float64) (newScore float64, err error) {
+	score, _, err := c.zIncr(key, member, zScore{delta}, Flags{})
+
+	return score.Float(), err
+}
+
+// ZADDINCR is the INCR form of ZADD: the given score is treated as a delta and added to the member's current score,
+// and the resulting score is returned. A member that does not exist is created with the delta as its score.
+//
+// The flags IfNotExists and IfAlreadyExists restrict the operation to new or existing members respectively. If the
+// condition fails nothing is changed and ok is false.
+//
+// The increment is a single atomic DynamoDB ADD, so concurrent increments on the same member never conflict.
+//
+// Works similar to https://redis.io/commands/zadd with the INCR option
+func (c Client) ZADDINCR(key string, member string, delta float64, flags Flags) (newScore float64, ok bool, err error) {
+	score, ok, err := c.zIncr(key, member, zScore{delta}, flags)
+
+	return score.Float(), ok, err
+}
+
+// ZINCRBYBULK applies the score deltas of many members of the sorted set at key, like calling ZINCRBY for each of
+// them, and returns the new scores. Every increment is its own atomic ADD update, so increments on the same members
+// from other clients are never lost, and up to concurrency updates are sent at the same time.
+//
+// The increments are not applied as a transaction – if an error is returned, some of the deltas may have been
+// applied, and newScores holds the members that were.
+//
+// Cost is O(N) / 1 WCU per member.
+func (c Client) ZINCRBYBULK(key string, deltas map[string]float64, concurrency int) (newScores map[string]float64, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	newScores = make(map[string]float64)
+	work := make(chan MemberScore)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for ms := range work {
+				score, _, err := c.zIncr(key, ms.Member, zScore{ms.Score}, Flags{})
+
+				mu.Lock()
+				if err == nil {
+					newScores[ms.Member] = score.Float()
+				} else if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for member, delta := range deltas {
+		work <- MemberScore{Member: member, Score: delta}
+	}
+
+	close(work)
+	wg.Wait()
+
+	return newScores, firstErr
+}
+
+// zIncr applies the increment with a single update. With CountedCardinality, an update without flags can't
+// tell whether it created the member, so it is made conditional: the member is first incremented if it exists,
+// and created if it doesn't, alternating until one of the two succeeds or the retry policy gives up.
+func (c Client) zIncr(key string, member string, delta Value, flags Flags) (newScore ReturnValue, ok bool, err error) {
+	if !c.countedCardinality || flags.has(IfAlreadyExists) {
+		return c.zIncrOnce(key, member, delta, flags)
+	}
+
+	if flags.has(IfNotExists) {
+		newScore, ok, err = c.zIncrOnce(key, member, delta, flags)
+		if ok {
+			err = c.adjustCardinality(key, 1)
+		}
+
+		return
+	}
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		newScore, ok, err = c.zIncrOnce(key, member, delta, Flags{IfAlreadyExists})
+		if err != nil || ok {
+			return true, err
+		}
+
+		newScore, ok, err = c.zIncrOnce(key, member, delta, Flags{IfNotExists})
+		if ok {
+			err = c.adjustCardinality(key, 1)
+		}
+
+		return err != nil || ok, err
+	})
+
+	return
+}
+
+// zIncrOnce applies the increment with a single ADD update. Only a conditional update can be skipped, in which
+// case ok is false. An unconditional update can still fail if a transaction is writing to the member at the same
+// time, and is then retried according to the RetryPolicy.
+func (c Client) zIncrOnce(key string, member string, delta Value, flags Flags) (newScore ReturnValue, ok bool, err error) {
+	builder := newExpresionBuilder()
+	builder.keys[c.sortKeyNum] = struct{}{}
+	builder.values["delta"] = delta.ToAV()
+
+	conditional := flags.has(IfNotExists) || flags.has(IfAlreadyExists)
+
+	if flags.has(IfNotExists) {
+		builder.addConditionNotExists(c.partitionKey)
+	}
+
+	if flags.has(IfAlreadyExists) {
+		builder.addConditionExists(c.partitionKey)
+	}
+
+	var resp *dynamodb.UpdateItemOutput
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		resp, err = c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+			ConditionExpression:       builder.conditionExpression(),
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			Key: keyDef{
+				pk: key,
+				sk: member,
+			}.toAV(c),
+			ReturnValues:     types.ReturnValueAllNew,
+			TableName:        aws.String(c.tableName),
+			UpdateExpression: aws.String(fmt.Sprintf("ADD #%v :delta", c.sortKeyNum)),
+		})
+		if conditionFailureError(err) && !conditional {
+			return false, nil
+		}
+
+		return true, err
+	})
+	if conditional && conditionFailureError(err) {
+		return newScore, false, nil
+	}
+
+	if err != nil {
+		return newScore, false, err
+	}
+
+	return ReturnValue{resp.Attributes[c.sortKeyNum]}, true, nil
+}
+
+// ZINTERSTORE computes the intersection of the sorted sets at the source keys, in the same way as ZINTER, and
+// stores the result at the destination key, overwriting any existing members.
+//
+// The destination is replaced atomically in a single transaction, so concurrent readers see either the old or the
+// new set. If the writes required (new members plus removals of stale ones) don't fit inside a transaction – see
+// TransactionActions – nothing is written and ErrTooManyKeys is returned along with the intersection.
+//
+// Works similar to https://redis.io/commands/zinterstore
+func (c Client) ZINTERSTORE(destinationKey string, sourceKeys []string, aggregation ZAggregation, weights map[string]float64) (membersWithScores map[string]float64, err error) {
+	set, err := c.ZINTER(sourceKeys, aggregation, weights)
+	if err == nil {
+		err = c.zStore(destinationKey, set, true)
+	}
+
+	return set, err
+}
+
+// ZLEXCOUNT counts the members between min and max in lexicographical order. The bounds use the Redis syntax:
+// "[m" is inclusive, "(m" is exclusive, and "-" and "+" are open ends. A bound without a prefix is treated as
+// inclusive and the empty string as an open end.
+//
+// Works similar to https://redis.io/commands/zlexcount
+func (c Client) ZLEXCOUNT(key string, min string, max string) (count int32, err error) {
+	if err = c.zCheckLex(key); err != nil {
+		return
+	}
+
+	return c.zGeneralCount(key, zLexBound(min), zLexBound(max), c.sortKey)
+}
+
+// ZMSCORE returns the scores of the given members, in the same order as the members. If a member does not
+// exist in the set, its score is zero and the corresponding found flag is false.
+//
+// The scores are fetched with BatchGetItem, 100 members at a time.
+//
+// Cost is O(N) / 1 RCU per member.
+//
+// Works similar to https://redis.io/commands/zmscore
+func (c Client) ZMSCORE(key string, members ...string) (scores []float64, found []bool, err error) {
+	keys := make([]keyDef, len(members))
+	for i, member := range members {
+		keys[i] = keyDef{pk: key, sk: member}
+	}
+
+	items, err := c.batchGet(keys, c.sortKey, c.sortKeyNum, ttlKey)
+	if err != nil {
+		return
+	}
+
+	scoresByMember := make(map[string]float64)
+	for _, item := range items {
+		if c.expired(item) {
+			continue
+		}
+
+		scoresByMember[parseKey(item, c).sk] = zScoreFromAV(item[c.sortKeyNum])
+	}
+
+	scores = make([]float64, len(members))
+	found = make([]bool, len(members))
+
+	for i, member := range members {
+		scores[i], found[i] = scoresByMember[member]
+	}
+
+	return
+}
+
+// ZPopSide selects whether ZMPOP pops the members with the lowest or the highest scores.
+type ZPopSide string
+
+const (
+	ZPopMin ZPopSide = "MIN"
+	ZPopMax ZPopSide = "MAX"
+)
+
+// ZMPOP pops up to count members from the first sorted set among keys that isn't empty, checking the keys in
+// order, and returns the key it popped from. If all the sorted sets are empty, key is empty and no members are
+// returned. Members are popped with the same guarantees as ZPOPMIN and ZPOPMAX.
+//
+// Works similar to https://redis.io/commands/zmpop
+func (c Client) ZMPOP(keys []string, side ZPopSide, count int32) (key string, membersWithScores map[string]float64, err error) {
+	for _, k := range keys {
+		membersWithScores, err = c.zPop(k, count, side == ZPopMin)
+		if err != nil || len(membersWithScores) > 0 {
+			return k, membersWithScores, err
+		}
+	}
+
+	return "", membersWithScores, nil
+}
+
+// ZPOPMAX removes and returns up to count members with the highest scores, or all members if count is zero or
+// negative. Members are claimed atomically, so concurrent callers never receive the same member, and a member whose
+// score changes while it is being popped is read again instead of being removed with a stale score.
+// ErrTooMuchContention is returned, along with any members already claimed, if other clients keep modifying the set.
+//
+// Works similar to https://redis.io/commands/zpopmax
+func (c Client) ZPOPMAX(key string, count int32) (membersWithScores map[string]float64, err error) {
+	return c.zPop(key, count, false)
+}
+
+// ZPOPMIN removes and returns up to count members with the lowest scores, with the same guarantees as ZPOPMAX.
+//
+// Works similar to https://redis.io/commands/zpopmin
+func (c Client) ZPOPMIN(key string, count int32) (membersWithScores map[string]float64, err error) {
+	return c.zPop(key, count, true)
+}
+
+// BZPOPMAX is the blocking version of ZPOPMAX. It checks the given keys in order and pops the member with the
+// highest score from the first non-empty sorted set. If all the sets are empty it keeps polling them according
+// to the given PollPolicy until a member is available or the context ends.
+//
+// If the context deadline passes before anything could be popped, the returned key is empty and err is nil,
+// similar to the Redis timeout reply. If the context is cancelled, the context error is returned.
+//
+// Works similar to https://redis.io/commands/bzpopmax
+func (c Client) BZPOPMAX(ctx context.Context, poll PollPolicy, keys ...string) (key string, member string, score float64, err error) {
+	return c.bzPop(ctx, poll, keys, false)
+}
+
+// BZPOPMIN is the blocking version of ZPOPMIN, popping the member with the lowest score. See BZPOPMAX for
+// details on how the blocking works.
+//
+// Works similar to https://redis.io/commands/bzpopmin
+func (c Client) BZPOPMIN(ctx context.Context, poll PollPolicy, keys ...string) (key string, member string, score float64, err error) {
+	return c.bzPop(ctx, poll, keys, true)
+}
+
+func (c Client) bzPop(ctx context.Context, poll PollPolicy, keys []string, forward bool) (key string, member string, score float64, err error) {
+	_, err = poll.poll(ctx, func() (bool, error) {
+		for _, k := range keys {
+			membersWithScores, err := c.zPop(k, 1, forward)
+			if err != nil {
+				return false, err
+			}
+
+			for m, s := range membersWithScores {
+				key, member, score = k, m, s
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+
+	return
+}
+
+var negInf = zScore{math.Inf(-1)}
+var posInf = zScore{math.Inf(+1)}
+
+// zPop claims members atomically: the candidates are read from the score index and then deleted in a transaction,
+// with each delete conditional on the member still having the score that was read. If another client pops or
+// updates any of the candidates in the meantime, the transaction is cancelled and the candidates are read again,
+// as allowed by the retry policy. Only members that were actually removed by this call are returned.
+func (c Client) zPop(key string, count int32, forward bool) (membersWithScores map[string]float64, err error) {
+	poppedMembers := make(map[string]float64)
+
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		candidates, err := c.zGeneralRangeOrdered(key, negInf, posInf, 0, count-int32(len(poppedMembers)), forward, c.sortKeyNum)
+		if err != nil || len(candidates) == 0 {
+			return true, err
+		}
+
+		for len(candidates) > 0 {
+			chunk := candidates
+			if len(chunk) > c.transactionChunk() {
+				chunk = chunk[:c.transactionChunk()]
+			}
+
+			candidates = candidates[len(chunk):]
+
+			_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+				TransactItems: c.zDeleteIfScoreActions(key, chunk),
+			})
+			if conditionFailureError(err) {
+				return false, nil
+			}
+
+			if err != nil {
+				return true, err
+			}
+
+			for _, ms := range chunk {
+				poppedMembers[ms.Member] = ms.Score
+			}
+
+			if err = c.adjustCardinality(key, -len(chunk)); err != nil {
+				return true, err
+			}
+		}
+
+		return true, nil
+	})
+
+	return poppedMembers, err
+}
+
+func (c Client) zDeleteIfScoreActions(key string, candidates []MemberScore) []types.TransactWriteItem {
+	actions := make([]types.TransactWriteItem, len(candidates))
+
+	for i, ms := range candidates {
+		builder := newExpresionBuilder()
+		builder.addConditionEquality(c.sortKeyNum, zScore{ms.Score})
+
+		actions[i] = types.TransactWriteItem{
+			Delete: &types.Delete{
+				ConditionExpression:       builder.conditionExpression(),
+				ExpressionAttributeNames:  builder.expressionAttributeNames(),
+				ExpressionAttributeValues: builder.expressionAttributeValues(),
+				Key:                       keyDef{pk: key, sk: ms.Member}.toAV(c),
+				TableName:                 aws.String(c.tableName),
+			},
+		}
+	}
+
+	return actions
+}
+
+// ZRANGE returns the members between the ranks start and stop, both inclusive, with the lowest score at rank 0.
+// Negative ranks count from the end of the set, so -1 is the member with the highest score. Out of range ranks are
+// clamped to the set, and an empty range returns no members.
+//
+// Cost is O(stop - start) for ranks that are both non-negative or both negative. Mixed ranks also count the set
+// with ZCARD.
+//
+// Works similar to https://redis.io/commands/zrange
+func (c Client) ZRANGE(key string, start, stop int32) (membersWithScores map[string]float64, err error) {
+	return c.zRange(key, start, stop, true)
+}
+
+func (c Client) zRange(key string, start int32, stop int32, forward bool) (membersWithScores map[string]float64, err error) {
+	ordered, err := c.zRangeOrdered(key, start, stop, forward)
+	return zMemberScoreMap(ordered), err
+}
+
+// zRangeOrdered returns the members between the ranks start and stop, both inclusive, in the given direction.
+// Negative ranks count from the other end of the set, out of range ranks are clamped, and an empty or inverted
+// range returns no members, just like Redis. Ranges with both ranks on the same side only read the requested
+// members; mixed ranges need the size of the set, and are read from whichever end is closer.
+func (c Client) zRangeOrdered(key string, start int32, stop int32, forward bool) (membersWithScores []MemberScore, err error) {
+	// Ranks are computed in int64, as the length of a range like 0 to math.MaxInt32 doesn't fit into an int32.
+	count := clampInt32(int64(stop) - int64(start) + 1)
+
+	switch {
+	case start >= 0 && stop >= 0:
+		if start > stop {
+			return
+		}
+
+		return c.zGeneralRangeOrdered(key, negInf, posInf, start, count, forward, c.sortKeyNum)
+	case start < 0 && stop < 0:
+		if start > stop {
+			return
+		}
+
+		membersWithScores, err = c.zGeneralRangeOrdered(key, negInf, posInf, clampInt32(-int64(stop)-1), count, !forward, c.sortKeyNum)
+		reverseMemberScores(membersWithScores)
+
+		return
+	}
+
+	card, err := c.cardinality(key)
+	if err != nil {
+		return
+	}
+
+	first, last := c.normalizeStartStop(int64(card), int64(start), int64(stop))
+	if first < 0 {
+		return
+	}
+
+	if fromEnd := int64(card) - 1 - last; fromEnd < first {
+		membersWithScores, err = c.zGeneralRangeOrdered(key, negInf, posInf, clampInt32(fromEnd), clampInt32(last-first+1), !forward, c.sortKeyNum)
+		reverseMemberScores(membersWithScores)
+
+		return
+	}
+
+	return c.zGeneralRangeOrdered(key, negInf, posInf, clampInt32(first), clampInt32(last-first+1), forward, c.sortKeyNum)
+}
+
+// clampInt32 converts n to an int32, saturating at the limits of int32.
+func clampInt32(n int64) int32 {
+	switch {
+	case n > math.MaxInt32:
+		return math.MaxInt32
+	case n < math.MinInt32:
+		return math.MinInt32
+	}
+
+	return int32(n)
+}
+
+func zMemberScoreMap(ordered []MemberScore) map[string]float64 {
+	membersWithScores := make(map[string]float64)
+	for _, ms := range ordered {
+		membersWithScores[ms.Member] = ms.Score
+	}
+
+	return membersWithScores
+}
+
+func reverseMemberScores(membersWithScores []MemberScore) {
+	for i, j := 0, len(membersWithScores)-1; i < j; i, j = i+1, j-1 {
+		membersWithScores[i], membersWithScores[j] = membersWithScores[j], membersWithScores[i]
+	}
+}
+
+// ZRANGEWITHSCORES works like ZRANGE, but returns the members in rank order (lowest score first)
+// instead of as a map.
+//
+// Works similar to https://redis.io/commands/zrange with the WITHSCORES option
+func (c Client) ZRANGEWITHSCORES(key string, start, stop int32) (membersWithScores []MemberScore, err error) {
+	return c.zRangeOrdered(key, start, stop, true)
+}
+
+// ZREVRANGEWITHSCORES works like ZREVRANGE, but returns the members in reverse rank order (highest
+// score first) instead of as a map.
+//
+// Works similar to https://redis.io/commands/zrevrange with the WITHSCORES option
+func (c Client) ZREVRANGEWITHSCORES(key string, start, stop int32) (membersWithScores []MemberScore, err error) {
+	return c.zRangeOrdered(key, start, stop, false)
+}
+
+// ZRANGEBYLEX returns the members between min and max in lexicographical order, skipping offset members and
+// returning at most count members (all of them if count is AllResults). The bounds use the same syntax as ZLEXCOUNT.
+//
+// Works similar to https://redis.io/commands/zrangebylex
+func (c Client) ZRANGEBYLEX(key string, min, max string, offset, count int32) (membersWithScores map[string]float64, err error) {
+	ordered, err := c.zLexRange(key, min, max, offset, count, true)
+	return zMemberScoreMap(ordered), err
+}
+
+// ZRANGEBYLEXWITHSCORES works like ZRANGEBYLEX, but returns the members in lexicographical order
+// instead of as a map.
+func (c Client) ZRANGEBYLEXWITHSCORES(key string, min, max string, offset, count int32) (membersWithScores []MemberScore, err error) {
+	return c.zLexRange(key, min, max, offset, count, true)
+}
+
+func (c Client) zLexRange(key string, min, max string, offset, count int32, forward bool) (membersWithScores []MemberScore, err error) {
+	if err = c.zCheckLex(key); err != nil {
+		return
+	}
+
+	return c.zGeneralRangeOrdered(key, zLexBound(min), zLexBound(max), offset, count, forward, c.sortKey)
+}
+
+// zCheckLex makes sure that all the members of the sorted set at key have the same score when StrictLex is
+// enabled. The lowest and the highest score are read from the score index, which costs two small queries.
+func (c Client) zCheckLex(key string) error {
+	if !c.strictLex {
+		return nil
+	}
+
+	lowest, err := c.zGeneralRangeOrdered(key, negInf, posInf, 0, 1, true, c.sortKeyNum)
+	if err != nil || len(lowest) == 0 {
+		return err
+	}
+
+	highest, err := c.zGeneralRangeOrdered(key, negInf, posInf, 0, 1, false, c.sortKeyNum)
+	if err != nil || len(highest) == 0 {
+		return err
+	}
+
+	if lowest[0].Score != highest[0].Score {
+		return ErrMixedScores
+	}
+
+	return nil
+}
+
+// ZRANGEBYSCOREWITHSCORES works like ZRANGEBYSCORE, but returns the members in score order (lowest
+// first) instead of as a map.
+//
+// Works similar to https://redis.io/commands/zrangebyscore with the WITHSCORES option
+func (c Client) ZRANGEBYSCOREWITHSCORES(key string, min, max float64, offset, count int32) (membersWithScores []MemberScore, err error) {
+	return c.zGeneralRangeOrdered(key, zScore{min}, zScore{max}, offset, count, true, c.sortKeyNum)
+}
+
+// ZRANGEBYSCORE returns the members with scores between min and max, both inclusive, skipping offset members in
+// score order and returning at most count members (all of them if count is AllResults). Use ExclusiveMin and
+// ExclusiveMax for exclusive bounds and infinite scores for open ends.
+//
+// Works similar to https://redis.io/commands/zrangebyscore
+func (c Client) ZRANGEBYSCORE(key string, min, max float64, offset, count int32) (membersWithScores map[string]float64, err error) {
+	return c.zGeneralRange(key, zScore{min}, zScore{max}, offset, count, true, c.sortKeyNum)
+}
+
+func (c Client) zGeneralRange(key string,
+	start rangeCap, stop rangeCap,
+	offset int32, count int32,
+	forward bool, attribute string) (membersWithScores map[string]float64, err error) {
+	ordered, err := c.zGeneralRangeOrdered(key, start, stop, offset, count, forward, attribute)
+	return zMemberScoreMap(ordered), err
+}
+
+func (c Client) zGeneralRangeOrdered(key string,
+	start rangeCap, stop rangeCap,
+	offset int32, count int32,
+	forward bool, attribute string) (membersWithScores []MemberScore, err error) {
+	items, err := c.zGeneralRangeItems(key, start, stop, offset, count, forward, attribute, false)
+
+	for _, item := range items {
+		membersWithScores = append(membersWithScores, MemberScore{Member: parseKey(item, c).sk, Score: zScoreFromAV(item[c.sortKeyNum])})
+	}
+
+	return
+}
+
+// zGeneralRangeItems returns the raw items of a range query. Queries on the score index only return the keys of the
+// members unless allAttributes is set, which makes DynamoDB fetch the rest of each item from the table.
+func (c Client) zGeneralRangeItems(key string,
+	start rangeCap, stop rangeCap,
+	offset int32, count int32,
+	forward bool, attribute string, allAttributes bool) (items []map[string]types.AttributeValue, err error) {
+	if offset < 0 {
+		return nil, nil
+	}
+
+	index := int32(0)
+	remainingCount := count
+	hasMoreResults := true
+
+	var lastKey map[string]types.AttributeValue
+
+	for hasMoreResults {
+		input := c.zRangeQueryInput(key, start, stop, forward, attribute)
+		input.ExclusiveStartKey = lastKey
+
+		if allAttributes {
+			input.Select = types.SelectAllAttributes
+		}
+
+		if remainingCount > 0 {
+			input.Limit = aws.Int32(clampInt32(int64(remainingCount) + int64(offset) - int64(index)))
+		}
+
+		resp, err := c.ddbClient.Query(context.TODO(), input)
+		if err != nil {
+			return items, err
+		}
+
+		for _, item := range resp.Items {
+			if zExcluded(parseKey(item, c).sk, start, stop) {
+				continue
+			}
+
+			if index >= offset {
+				items = append(items, item)
+				remainingCount--
+			}
+			index++
+		}
+
+		if len(resp.LastEvaluatedKey) > 0 && (count <= 0 || remainingCount > 0) {
+			lastKey = resp.LastEvaluatedKey
+		} else {
+			hasMoreResults = false
+		}
+	}
+
+	return items, nil
 }
 
-func (c Client) ZINCRBY(key string, member string, delta float64) (newScore float64, err error) {
+func (c Client) zRangeQueryInput(key string, start rangeCap, stop rangeCap, forward bool, attribute string) *dynamodb.QueryInput {
 	builder := newExpresionBuilder()
-	builder.keys[c.sortKeyNum] = struct{}{}
-	builder.values["delta"] = zScore{delta}.ToAV()
+	builder.addConditionEquality(c.partitionKey, StringValue{key})
+	zRangeCondition(&builder, attribute, start, stop)
 
-	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-		ConditionExpression:       builder.conditionExpression(),
+	var queryIndex *string
+	if attribute == c.sortKeyNum {
+		queryIndex = aws.String(c.indexName)
+	}
+
+	return c.filterExpiredItems(&dynamodb.QueryInput{
+		ConsistentRead:            aws.Bool(c.consistentReads),
 		ExpressionAttributeNames:  builder.expressionAttributeNames(),
 		ExpressionAttributeValues: builder.expressionAttributeValues(),
-		Key: keyDef{
-			pk: key,
-			sk: member,
-		}.toAV(c),
-		ReturnValues:     types.ReturnValueAllNew,
-		TableName:        aws.String(c.tableName),
-		UpdateExpression: aws.String(fmt.Sprintf("ADD #%v :delta", c.sortKeyNum)),
+		IndexName:                 queryIndex,
+		KeyConditionExpression:    builder.conditionExpression(),
+		ScanIndexForward:          aws.Bool(forward),
+		TableName:                 aws.String(c.tableName),
 	})
-	if err != nil {
-		return newScore, err
-	}
+}
 
-	newScore = zScoreFromAV(resp.Attributes[c.sortKeyNum])
+// ZRANGEBYSCOREPAGES walks the members with scores between min and max in score order (lowest first), calling
+// fn with one page of at most pageSize members at a time. Only one page is held in memory, so arbitrarily large
+// sorted sets can be walked. The walk stops early when fn returns false, and when ctx is done, in which case the
+// context error is returned. Use math.Inf(-1) and math.Inf(+1) to walk the whole set.
+//
+// Pages are read lazily, so members that are added or removed during the walk may or may not be seen.
+func (c Client) ZRANGEBYSCOREPAGES(ctx context.Context, key string, min, max float64, pageSize int32, fn func(page []MemberScore) bool) error {
+	return c.zRangePages(ctx, key, zScore{min}, zScore{max}, pageSize, true, c.sortKeyNum, fn)
+}
 
-	return
+// ZREVRANGEBYSCOREPAGES works like ZRANGEBYSCOREPAGES in reverse score order (highest first). Note that max
+// comes before min.
+func (c Client) ZREVRANGEBYSCOREPAGES(ctx context.Context, key string, max, min float64, pageSize int32, fn func(page []MemberScore) bool) error {
+	return c.zRangePages(ctx, key, zScore{min}, zScore{max}, pageSize, false, c.sortKeyNum, fn)
 }
 
-func (c Client) ZINTERSTORE(destinationKey string, sourceKeys []string, aggregation ZAggregation, weights map[string]float64) (membersWithScores map[string]float64, err error) {
-	set, err := c.ZINTER(sourceKeys, aggregation, weights)
-	if err == nil {
-		_, err = c.ZADD(destinationKey, set, Flags{})
+// ZRANGEBYLEXPAGES works like ZRANGEBYSCOREPAGES for the members between min and max in lexicographical order.
+// The bounds use the same syntax as ZRANGEBYLEX.
+func (c Client) ZRANGEBYLEXPAGES(ctx context.Context, key string, min, max string, pageSize int32, fn func(page []MemberScore) bool) error {
+	if err := c.zCheckLex(key); err != nil {
+		return err
 	}
 
-	return set, err
-}
-
-func (c Client) ZLEXCOUNT(key string, min string, max string) (count int32, err error) {
-	return c.zGeneralCount(key, zLex{min}, zLex{max}, c.sortKey)
+	return c.zRangePages(ctx, key, zLexBound(min), zLexBound(max), pageSize, true, c.sortKey, fn)
 }
 
-func (c Client) ZPOPMAX(key string, count int32) (membersWithScores map[string]float64, err error) {
-	return c.zPop(key, count, false)
-}
+// ZREVRANGEBYLEXPAGES works like ZRANGEBYLEXPAGES in reverse lexicographical order. Note that max comes
+// before min.
+func (c Client) ZREVRANGEBYLEXPAGES(ctx context.Context, key string, max, min string, pageSize int32, fn func(page []MemberScore) bool) error {
+	if err := c.zCheckLex(key); err != nil {
+		return err
+	}
 
-func (c Client) ZPOPMIN(key string, count int32) (membersWithScores map[string]float64, err error) {
-	return c.zPop(key, count, true)
+	return c.zRangePages(ctx, key, zLexBound(min), zLexBound(max), pageSize, false, c.sortKey, fn)
 }
 
-var negInf = zScore{math.Inf(-1)}
-var posInf = zScore{math.Inf(+1)}
+func (c Client) zRangePages(ctx context.Context, key string,
+	start rangeCap, stop rangeCap,
+	pageSize int32, forward bool, attribute string,
+	fn func(page []MemberScore) bool) error {
+	input := c.zRangeQueryInput(key, start, stop, forward, attribute)
 
-func (c Client) zPop(key string, count int32, forward bool) (membersWithScores map[string]float64, err error) {
-	membersWithScores, err = c.zGeneralRange(key, negInf, posInf, 0, count, forward, c.sortKeyNum)
-	if err != nil {
-		return
+	if pageSize > 0 {
+		input.Limit = aws.Int32(pageSize)
 	}
 
-	poppedMembers := make(map[string]float64)
-
-	for member, score := range membersWithScores {
-		popped, err := c.ZREM(key, member)
-		if err != nil {
-			return poppedMembers, err
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		if len(popped) > 0 {
-			poppedMembers[member] = score
+		resp, err := c.ddbClient.Query(ctx, input)
+		if err != nil {
+			return err
 		}
-	}
 
-	return poppedMembers, err
-}
+		page := make([]MemberScore, 0, len(resp.Items))
 
-func (c Client) ZRANGE(key string, start, stop int32) (membersWithScores map[string]float64, err error) {
-	return c.zRange(key, start, stop, true)
-}
+		for _, item := range resp.Items {
+			pi := parseItem(item, c)
+			if !zExcluded(pi.sk, start, stop) {
+				page = append(page, MemberScore{Member: pi.sk, Score: zScoreFromAV(item[c.sortKeyNum])})
+			}
+		}
 
-func (c Client) zRange(key string, start int32, stop int32, forward bool) (membersWithScores map[string]float64, err error) {
-	if start < 0 && stop < 0 {
-		return c.zGeneralRange(key, negInf, posInf, -stop-1, -start, !forward, c.sortKeyNum)
-	}
+		if len(page) > 0 && !fn(page) {
+			return nil
+		}
 
-	if start > 0 && stop < 0 {
-		lastScore, err := c.zGeneralRange(key, negInf, posInf, -stop-1, 1, !forward, c.sortKeyNum)
-		if err != nil {
-			return membersWithScores, err
+		if len(resp.LastEvaluatedKey) == 0 {
+			return nil
 		}
 
-		return c.zGeneralRange(key, negInf, zScore{floatValues(lastScore)[0]}, start, 0, forward, c.sortKeyNum)
+		input.ExclusiveStartKey = resp.LastEvaluatedKey
 	}
-
-	return c.zGeneralRange(key, negInf, posInf, start, stop-start+1, forward, c.sortKeyNum)
 }
 
-func floatValues(floatValuedMap map[string]float64) (values []float64) {
-	for _, v := range floatValuedMap {
-		values = append(values, v)
+// ZRANDMEMBER returns random members from the sorted set at key. With a positive count, up to count distinct
+// members are returned – the whole set if count is larger than the set. With a negative count, exactly -count
+// members are returned and the same member may appear more than once.
+//
+// Sampling does not read the whole set: random points between the lowest and highest scores are picked and
+// the members at those points are read from the score index. A positive count reads a contiguous run of members
+// starting at one random point, wrapping around to the lowest score if required. As a consequence the sample is
+// not uniformly random – members that follow large gaps in score are more likely to be picked.
+//
+// Works similar to https://redis.io/commands/zrandmember
+func (c Client) ZRANDMEMBER(key string, count int32) (members []string, err error) {
+	membersWithScores, err := c.ZRANDMEMBERWITHSCORES(key, count)
+	for _, ms := range membersWithScores {
+		members = append(members, ms.Member)
 	}
 
 	return
 }
 
-func (c Client) ZRANGEBYLEX(key string, min, max string, offset, count int32) (membersWithScores map[string]float64, err error) {
-	return c.zGeneralRange(key, zLex{min}, zLex{max}, offset, count, true, c.sortKey)
-}
-
-func (c Client) ZRANGEBYSCORE(key string, min, max float64, offset, count int32) (membersWithScores map[string]float64, err error) {
-	return c.zGeneralRange(key, zScore{min}, zScore{max}, offset, count, true, c.sortKeyNum)
-}
-
-func (c Client) zGeneralRange(key string,
-	start rangeCap, stop rangeCap,
-	offset int32, count int32,
-	forward bool, attribute string) (membersWithScores map[string]float64, err error) {
-	membersWithScores = make(map[string]float64)
-	index := int32(0)
-	remainingCount := count
-	hasMoreResults := true
+// ZRANDMEMBERWITHSCORES works like ZRANDMEMBER, but returns the score of each member as well.
+//
+// Works similar to https://redis.io/commands/zrandmember with the WITHSCORES option
+func (c Client) ZRANDMEMBERWITHSCORES(key string, count int32) (membersWithScores []MemberScore, err error) {
+	if count == 0 {
+		return
+	}
 
-	var lastKey map[string]types.AttributeValue
+	lowest, err := c.zGeneralRangeOrdered(key, negInf, posInf, 0, 1, true, c.sortKeyNum)
+	if err != nil || len(lowest) == 0 {
+		return
+	}
 
-	for hasMoreResults {
-		var queryLimit *int32
-		if remainingCount > 0 {
-			queryLimit = aws.Int32(remainingCount + offset - index)
-		}
+	highest, err := c.zGeneralRangeOrdered(key, negInf, posInf, 0, 1, false, c.sortKeyNum)
+	if err != nil || len(highest) == 0 {
+		return
+	}
 
-		builder := newExpresionBuilder()
-		builder.addConditionEquality(c.partitionKey, StringValue{key})
+	randomPoint := func() zScore {
+		return zScore{lowest[0].Score + rand.Float64()*(highest[0].Score-lowest[0].Score)}
+	}
 
-		if start.present() {
-			builder.values["start"] = start.ToAV()
-		}
+	if count < 0 {
+		for i := int32(0); i < -count; i++ {
+			sample, err := c.zGeneralRangeOrdered(key, randomPoint(), posInf, 0, 1, true, c.sortKeyNum)
+			if err != nil {
+				return membersWithScores, err
+			}
 
-		if stop.present() {
-			builder.values["stop"] = stop.ToAV()
-		}
+			if len(sample) == 0 {
+				sample = lowest
+			}
 
-		switch {
-		case start.present() && stop.present():
-			builder.condition(fmt.Sprintf("#%v BETWEEN :start AND :stop", attribute), attribute)
-		case start.present():
-			builder.condition(fmt.Sprintf("#%v >= :start", attribute), attribute)
-		case stop.present():
-			builder.condition(fmt.Sprintf("#%v <= :stop", attribute), attribute)
+			membersWithScores = append(membersWithScores, sample[0])
 		}
 
-		var queryIndex *string
-		if attribute == c.sortKeyNum {
-			queryIndex = aws.String(c.indexName)
-		}
+		return
+	}
 
-		resp, err := c.ddbClient.Query(context.TODO(), &dynamodb.QueryInput{
-			ConsistentRead:            aws.Bool(c.consistentReads),
-			ExclusiveStartKey:         lastKey,
-			ExpressionAttributeNames:  builder.expressionAttributeNames(),
-			ExpressionAttributeValues: builder.expressionAttributeValues(),
-			IndexName:                 queryIndex,
-			KeyConditionExpression:    builder.conditionExpression(),
-			Limit:                     queryLimit,
-			ScanIndexForward:          aws.Bool(forward),
-			TableName:                 aws.String(c.tableName),
-		})
+	membersWithScores, err = c.zGeneralRangeOrdered(key, randomPoint(), posInf, 0, count, true, c.sortKeyNum)
+	if err != nil {
+		return
+	}
 
+	if remaining := count - int32(len(membersWithScores)); remaining > 0 {
+		wrapped, err := c.zGeneralRangeOrdered(key, negInf, posInf, 0, remaining, true, c.sortKeyNum)
 		if err != nil {
 			return membersWithScores, err
 		}
 
-		for _, item := range resp.Items {
-			if index >= offset {
-				pi := parseItem(item, c)
-				membersWithScores[pi.sk] = zScoreFromAV(item[c.sortKeyNum])
-				remainingCount--
-			}
-			index++
+		seen := make(map[string]struct{})
+		for _, ms := range membersWithScores {
+			seen[ms.Member] = struct{}{}
 		}
 
-		if len(resp.LastEvaluatedKey) > 0 && remainingCount > 0 {
-			lastKey = resp.LastEvaluatedKey
-		} else {
-			hasMoreResults = false
+		for _, ms := range wrapped {
+			if _, ok := seen[ms.Member]; !ok {
+				membersWithScores = append(membersWithScores, ms)
+			}
 		}
 	}
 
-	return membersWithScores, nil
+	rand.Shuffle(len(membersWithScores), func(i, j int) {
+		membersWithScores[i], membersWithScores[j] = membersWithScores[j], membersWithScores[i]
+	})
+
+	return
 }
 
+// ZRANK returns the rank of member in the sorted set at key, with the lowest score at rank 0. If member is not
+// in the sorted set, found is false.
+//
+// The rank is found by counting the members with lower scores on the score index, which reads the keys of all those
+// members. Counting deep ranks in a large sorted set takes many sequential queries – use ParallelCounting to split
+// the count into segments that are queried concurrently. That cuts the latency, but not the read cost.
+//
+// Cost is O(rank).
+//
+// Works similar to https://redis.io/commands/zrank
 func (c Client) ZRANK(key string, member string) (rank int32, found bool, err error) {
 	return c.zRank(key, member, true)
 }
@@ -391,9 +1346,9 @@ func (c Client) zRank(key string, member string, forward bool) (rank int32, ok b
 	var count int32
 
 	if forward {
-		count, err = c.zGeneralCount(key, negInf, zScore{score}, c.sortKeyNum)
+		count, err = c.zCountScores(key, math.Inf(-1), score)
 	} else {
-		count, err = c.zGeneralCount(key, zScore{score}, posInf, c.sortKeyNum)
+		count, err = c.zCountScores(key, score, math.Inf(+1))
 	}
 
 	if err == nil {
@@ -403,19 +1358,108 @@ func (c Client) zRank(key string, member string, forward bool) (rank int32, ok b
 	return
 }
 
+// zCountScores counts the members with scores between min and max, inclusive. With ParallelCounting the range is
+// split into segments of equal width between the lowest and highest matching scores, and the segments are counted
+// concurrently, with at most CountingConcurrency segments in flight at the same time.
+func (c Client) zCountScores(key string, min, max float64) (count int32, err error) {
+	if c.countSegments <= 1 {
+		return c.zGeneralCount(key, zScore{min}, zScore{max}, c.sortKeyNum)
+	}
+
+	lowest, err := c.zGeneralRangeOrdered(key, zScore{min}, zScore{max}, 0, 1, true, c.sortKeyNum)
+	if err != nil || len(lowest) == 0 {
+		return
+	}
+
+	highest, err := c.zGeneralRangeOrdered(key, zScore{min}, zScore{max}, 0, 1, false, c.sortKeyNum)
+	if err != nil || len(highest) == 0 {
+		return
+	}
+
+	segments := zScoreSegments(lowest[0].Score, highest[0].Score, c.countSegments)
+
+	concurrency := c.countConcurrency
+	if concurrency < 1 || concurrency > len(segments) {
+		concurrency = len(segments)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	slots := make(chan struct{}, concurrency)
+
+	for _, segment := range segments {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(segment [2]float64) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			segmentCount, err := c.zGeneralCount(key, zScore{segment[0]}, zScore{segment[1]}, c.sortKeyNum)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			count += segmentCount
+
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(segment)
+	}
+
+	wg.Wait()
+
+	return count, firstErr
+}
+
+// zScoreSegments splits the inclusive range between min and max into up to n non-overlapping inclusive segments
+// of (nearly) equal width that together cover the whole range.
+func zScoreSegments(min, max float64, n int) (segments [][2]float64) {
+	if n < 1 || min >= max {
+		return [][2]float64{{min, max}}
+	}
+
+	start := min
+
+	for i := 1; i <= n; i++ {
+		f := float64(i) / float64(n)
+		end := min*(1-f) + max*f
+
+		if i == n {
+			segments = append(segments, [2]float64{start, max})
+			break
+		}
+
+		if end <= start || end > max {
+			continue
+		}
+
+		segments = append(segments, [2]float64{start, ExclusiveMax(end)})
+		start = end
+	}
+
+	return
+}
+
 func (c Client) ZREM(key string, members ...string) (removedMembers []string, err error) {
-	for _, member := range members {
-		resp, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
-			Key:          keyDef{pk: key, sk: member}.toAV(c),
-			ReturnValues: types.ReturnValueAllOld,
-			TableName:    aws.String(c.tableName),
-		})
+	defer func() {
+		if cErr := c.adjustCardinality(key, -len(removedMembers)); err == nil {
+			err = cErr
+		}
+	}()
 
+	for _, member := range members {
+		status, err := c.zRemMember(key, member)
 		if err != nil {
 			return removedMembers, err
 		}
 
-		if len(resp.Attributes) > 0 {
+		if status == MemberRemoved {
 			removedMembers = append(removedMembers, member)
 		}
 	}
@@ -423,6 +1467,47 @@ func (c Client) ZREM(key string, members ...string) (removedMembers []string, er
 	return
 }
 
+// ZREMRESULTS works like ZREM, but reports the outcome of every member: whether it was removed, skipped because
+// it didn't exist, or failed – in which case the result holds the error. A failed member doesn't stop the
+// remaining members from being removed, so err is only set if the member count of CountedCardinality couldn't
+// be updated.
+//
+// Works similar to https://redis.io/commands/zrem
+func (c Client) ZREMRESULTS(key string, members ...string) (results map[string]MemberResult, err error) {
+	results = make(map[string]MemberResult)
+	removed := 0
+
+	for _, member := range members {
+		status, err := c.zRemMember(key, member)
+		results[member] = MemberResult{Status: status, Err: err}
+
+		if status == MemberRemoved {
+			removed++
+		}
+	}
+
+	err = c.adjustCardinality(key, -removed)
+
+	return
+}
+
+func (c Client) zRemMember(key string, member string) (status MemberStatus, err error) {
+	resp, err := c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		Key:          keyDef{pk: key, sk: member}.toAV(c),
+		ReturnValues: types.ReturnValueAllOld,
+		TableName:    aws.String(c.tableName),
+	})
+
+	switch {
+	case err != nil:
+		return MemberFailed, err
+	case len(resp.Attributes) == 0:
+		return MemberSkipped, nil
+	}
+
+	return MemberRemoved, nil
+}
+
 func (c Client) ZREMRANGEBYLEX(key string, min, max string) (removedMembers []string, err error) {
 	membersWithScores, err := c.ZRANGEBYLEX(key, min, max, 0, 0)
 	if err == nil {
@@ -441,67 +1526,281 @@ func zReadKeys(membersWithScores map[string]float64) []string {
 	return members
 }
 
+// ZREMRANGEBYRANK removes all the members with ranks between start and stop (both inclusive, zero based, lowest
+// score first) and returns the members that were removed. Negative indexes count from the end of the set,
+// so -1 is the member with the highest score. Out of range indexes are clamped to the set, and an empty range
+// removes nothing.
+//
+// The deletes are conditional on the scores that were read, in the same way as ZREMRANGEBYSCORE, and are not
+// atomic as a whole.
+//
+// Works similar to https://redis.io/commands/zremrangebyrank
 func (c Client) ZREMRANGEBYRANK(key string, start, stop int32) (removedMembers []string, err error) {
-	membersWithScores, err := c.ZRANGE(key, start, stop)
+	membersWithScores, err := c.zRangeOrdered(key, start, stop, true)
 	if err == nil {
-		removedMembers, err = c.ZREM(key, zReadKeys(membersWithScores)...)
+		removedMembers, err = c.zRemIfScore(key, membersWithScores)
 	}
 
 	return
 }
 
+// ZREMRANGEBYSCORE removes all the members with scores between min and max (inclusive) and returns
+// the members that were removed. Use math.Inf(-1) and math.Inf(+1) for open ends, and ExclusiveMin or
+// ExclusiveMax for exclusive bounds.
+//
+// The matching members are read page by page from the score index and then deleted in transactions of up to
+// TransactionActions members each, so the removal as a whole is not atomic. Each delete is conditional on the
+// member still having the score that was read, so a member whose score is changed concurrently – possibly to
+// a score outside the range – is left alone and not returned.
+//
+// Cost is O(N) / 2 WCUs per removed member, as transactional writes cost twice as much.
+//
+// Works similar to https://redis.io/commands/zremrangebyscore
 func (c Client) ZREMRANGEBYSCORE(key string, min, max float64) (removedMembers []string, err error) {
-	membersWithScores, err := c.ZRANGEBYSCORE(key, min, max, 0, 0)
+	membersWithScores, err := c.zGeneralRangeOrdered(key, zScore{min}, zScore{max}, 0, 0, true, c.sortKeyNum)
 	if err == nil {
-		removedMembers, err = c.ZREM(key, zReadKeys(membersWithScores)...)
+		removedMembers, err = c.zRemIfScore(key, membersWithScores)
 	}
 
 	return
 }
 
+// zRemIfScore deletes members that were read from the score index, each only if it still has the score it was
+// read with. The deletes are sent in transactions of up to TransactionActions members; if a transaction is
+// cancelled because some of its members changed, its members are deleted one by one instead, skipping the ones
+// that changed. Only the members that were actually deleted are returned.
+func (c Client) zRemIfScore(key string, membersWithScores []MemberScore) (removedMembers []string, err error) {
+	defer func() {
+		if cErr := c.adjustCardinality(key, -len(removedMembers)); err == nil {
+			err = cErr
+		}
+	}()
+
+	for len(membersWithScores) > 0 {
+		chunk := membersWithScores
+		if len(chunk) > c.transactionChunk() {
+			chunk = chunk[:c.transactionChunk()]
+		}
+
+		membersWithScores = membersWithScores[len(chunk):]
+
+		_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: c.zDeleteIfScoreActions(key, chunk),
+		})
+		if err == nil {
+			for _, ms := range chunk {
+				removedMembers = append(removedMembers, ms.Member)
+			}
+
+			continue
+		}
+
+		if !conditionFailureError(err) {
+			return removedMembers, err
+		}
+
+		for _, ms := range chunk {
+			deleteAction := c.zDeleteIfScoreActions(key, []MemberScore{ms})[0].Delete
+
+			_, err = c.ddbClient.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+				ConditionExpression:       deleteAction.ConditionExpression,
+				ExpressionAttributeNames:  deleteAction.ExpressionAttributeNames,
+				ExpressionAttributeValues: deleteAction.ExpressionAttributeValues,
+				Key:                       deleteAction.Key,
+				TableName:                 deleteAction.TableName,
+			})
+			if conditionFailureError(err) {
+				continue
+			}
+
+			if err != nil {
+				return removedMembers, err
+			}
+
+			removedMembers = append(removedMembers, ms.Member)
+		}
+	}
+
+	return removedMembers, nil
+}
+
+// ZREVRANGE works like ZRANGE with the highest score at rank 0.
+//
+// Works similar to https://redis.io/commands/zrevrange
 func (c Client) ZREVRANGE(key string, start, stop int32) (membersWithScores map[string]float64, err error) {
 	return c.zRange(key, start, stop, false)
 }
 
+// ZREVRANGEBYLEX works like ZRANGEBYLEX in reverse lexicographical order. Note that max comes before min.
+//
+// Works similar to https://redis.io/commands/zrevrangebylex
 func (c Client) ZREVRANGEBYLEX(key string, max, min string, offset, count int32) (membersWithScores map[string]float64, err error) {
-	return c.zGeneralRange(key, zLex{min}, zLex{max}, offset, count, false, c.sortKey)
+	ordered, err := c.zLexRange(key, min, max, offset, count, false)
+	return zMemberScoreMap(ordered), err
+}
+
+// ZREVRANGEBYLEXWITHSCORES works like ZREVRANGEBYLEX, but returns the members in reverse lexicographical
+// order instead of as a map.
+func (c Client) ZREVRANGEBYLEXWITHSCORES(key string, max, min string, offset, count int32) (membersWithScores []MemberScore, err error) {
+	return c.zLexRange(key, min, max, offset, count, false)
 }
 
+// ZREVRANGEBYSCORE works like ZRANGEBYSCORE in reverse score order (highest first). Note that max comes before min.
+//
+// Works similar to https://redis.io/commands/zrevrangebyscore
 func (c Client) ZREVRANGEBYSCORE(key string, max, min float64, offset, count int32) (membersWithScores map[string]float64, err error) {
 	return c.zGeneralRange(key, zScore{min}, zScore{max}, offset, count, false, c.sortKeyNum)
 }
 
+// ZREVRANGEBYSCOREWITHSCORES works like ZREVRANGEBYSCORE, but returns the members in reverse score order
+// (highest first) instead of as a map.
+//
+// Works similar to https://redis.io/commands/zrevrangebyscore with the WITHSCORES option
+func (c Client) ZREVRANGEBYSCOREWITHSCORES(key string, max, min float64, offset, count int32) (membersWithScores []MemberScore, err error) {
+	return c.zGeneralRangeOrdered(key, zScore{min}, zScore{max}, offset, count, false, c.sortKeyNum)
+}
+
 func (c Client) ZREVRANK(key string, member string) (rank int32, found bool, err error) {
 	return c.zRank(key, member, false)
 }
 
+// ZSCAN iterates over the members of the sorted set at key in lexicographical order of the members,
+// returning up to count members per call along with a cursor for the next call. Start with the ScanStart
+// cursor; when the returned cursor is ScanStart again, the iteration is complete.
+//
+// The cursor is an opaque string that wraps the DynamoDB pagination key, so it can be stored and used to
+// resume the scan later, even from another process. Members added or removed during the scan may or may not
+// be returned, but members present throughout the scan are returned exactly once. A call may return fewer
+// than count members (even none) while the cursor is not yet ScanStart.
+//
+// Works similar to https://redis.io/commands/zscan
+func (c Client) ZSCAN(key string, cursor string, count int32) (membersWithScores []MemberScore, nextCursor string, err error) {
+	exclusiveStartKey, err := c.decodeKeyCursor(key, cursor)
+	if err != nil {
+		return
+	}
+
+	builder := newExpresionBuilder()
+	builder.addConditionEquality(c.partitionKey, StringValue{key})
+
+	var queryLimit *int32
+	if count > 0 {
+		queryLimit = aws.Int32(count)
+	}
+
+	resp, err := c.ddbClient.Query(context.TODO(), c.filterExpiredItems(&dynamodb.QueryInput{
+		ConsistentRead:            aws.Bool(c.consistentReads),
+		ExclusiveStartKey:         exclusiveStartKey,
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		KeyConditionExpression:    builder.conditionExpression(),
+		Limit:                     queryLimit,
+		TableName:                 aws.String(c.tableName),
+	}))
+	if err != nil {
+		return membersWithScores, cursor, err
+	}
+
+	for _, item := range resp.Items {
+		membersWithScores = append(membersWithScores, MemberScore{
+			Member: parseKey(item, c).sk,
+			Score:  zScoreFromAV(item[c.sortKeyNum]),
+		})
+	}
+
+	return membersWithScores, encodeCursor(resp.LastEvaluatedKey), nil
+}
+
 func (c Client) ZSCORE(key string, member string) (score float64, found bool, err error) {
+	av, found, err := c.zScoreAV(key, member)
+
+	return zScoreFromAV(av), found, err
+}
+
+// zScoreAV reads the stored score of member without converting it, so callers can decode it at the precision they
+// need.
+func (c Client) zScoreAV(key string, member string) (score types.AttributeValue, found bool, err error) {
+	projection, projectionNames := projectionWithNames([]string{c.sortKeyNum, ttlKey})
+
 	resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
-		ConsistentRead: aws.Bool(c.consistentReads),
+		ConsistentRead:           aws.Bool(c.consistentReads),
+		ExpressionAttributeNames: projectionNames,
 		Key: keyDef{
 			pk: key,
 			sk: member,
 		}.toAV(c),
-		ProjectionExpression: aws.String(strings.Join([]string{c.sortKeyNum}, ", ")),
+		ProjectionExpression: projection,
 		TableName:            aws.String(c.tableName),
 	})
-	if err == nil && len(resp.Item) > 0 {
+	if err == nil && len(resp.Item) > 0 && !c.expired(resp.Item) {
 		found = true
-		score = zScoreFromAV(resp.Item[c.sortKeyNum])
+		score = resp.Item[c.sortKeyNum]
 	}
 
 	return
 }
 
+// ZUNIONSTORE computes the union of the sorted sets at the source keys, in the same way as ZUNION, and stores
+// the result at the destination key. If the destination already exists it is overwritten: members that are
+// not part of the union are removed.
+//
+// If the writes required fit inside a single transaction – see TransactionActions – the destination is replaced
+// atomically. Larger results are written with batched writes of up to 25 members each and are not atomic.
+//
+// Works similar to https://redis.io/commands/zunionstore
 func (c Client) ZUNIONSTORE(destinationKey string, sourceKeys []string, aggregation ZAggregation, weights map[string]float64) (membersWithScores map[string]float64, err error) {
 	set, err := c.ZUNION(sourceKeys, aggregation, weights)
 	if err == nil {
-		_, err = c.ZADD(destinationKey, set, Flags{})
+		err = c.zStore(destinationKey, set, false)
 	}
 
 	return set, err
 }
 
+// zStore replaces the members of destinationKey with membersWithScores, in a single transaction if the writes fit.
+// Larger results return ErrTooManyKeys without writing anything if atomic is set, and are written with batched
+// writes otherwise.
+func (c Client) zStore(destinationKey string, membersWithScores map[string]float64, atomic bool) error {
+	existingMembers, err := c.listSortKeys(destinationKey)
+	if err != nil {
+		return err
+	}
+
+	requests := make([]types.WriteRequest, 0, len(existingMembers)+len(membersWithScores))
+
+	for _, member := range existingMembers {
+		if _, ok := membersWithScores[member]; !ok {
+			requests = append(requests, deleteRequest(keyDef{pk: destinationKey, sk: member}.toAV(c)))
+		}
+	}
+
+	for member, score := range membersWithScores {
+		requests = append(requests, putRequest(c.zMemberItem(destinationKey, member, score)))
+	}
+
+	switch {
+	case len(requests) <= c.transactionActions:
+		err = c.transactWrite(requests)
+	case atomic:
+		return ErrTooManyKeys
+	default:
+		err = c.batchWrite(requests)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.setCardinality(destinationKey, int32(len(membersWithScores)))
+}
+
+func (c Client) zMemberItem(key string, member string, score float64) map[string]types.AttributeValue {
+	item := keyDef{pk: key, sk: member}.toAV(c)
+	item[c.sortKeyNum] = zScore{score}.ToAV()
+
+	return item
+}
+
 func zGetWeight(weights map[string]float64, key string) float64 {
 	if weights == nil {
 		return 1
@@ -513,8 +1812,17 @@ func zGetWeight(weights map[string]float64, key string) float64 {
 
 	return 1
 }
+
+// ZUNION returns the union of the sorted sets at the source keys, without storing it anywhere. The score of each
+// source member is multiplied by the weight of its key (1 if the key has no weight), and the weighted scores of a
+// member that appears in several sets are combined with the aggregation – SUM if the aggregation is empty.
+//
+// Every source set is read in full, so the cost is O(N) over the total size of the sets.
+//
+// Works similar to https://redis.io/commands/zunion
 func (c Client) ZUNION(sourceKeys []string, aggregation ZAggregation, weights map[string]float64) (membersWithScores map[string]float64, err error) {
 	membersWithScores = make(map[string]float64)
+	accumulate := zAccumulator(aggregation)
 
 	for _, sourceKey := range sourceKeys {
 		currentSet, err := c.ZRANGEBYSCORE(sourceKey, math.Inf(-1), math.Inf(+1), 0, 0)
@@ -524,7 +1832,7 @@ func (c Client) ZUNION(sourceKeys []string, aggregation ZAggregation, weights ma
 
 		for member, score := range currentSet {
 			if existingValue, ok := membersWithScores[member]; ok {
-				membersWithScores[member] = accumulators[aggregation](existingValue, score*zGetWeight(weights, sourceKey))
+				membersWithScores[member] = accumulate(existingValue, score*zGetWeight(weights, sourceKey))
 			} else {
 				membersWithScores[member] = score * zGetWeight(weights, sourceKey)
 			}
@@ -534,12 +1842,29 @@ func (c Client) ZUNION(sourceKeys []string, aggregation ZAggregation, weights ma
 	return
 }
 
+// ZINTER returns the intersection of the sorted sets at the source keys, without storing it anywhere. Weights and
+// aggregation work the same way as in ZUNION, and apply to every source key including the first one.
+//
+// Every source set is read in full, so the cost is O(N) over the total size of the sets.
+//
+// Works similar to https://redis.io/commands/zinter
 func (c Client) ZINTER(sourceKeys []string, aggregation ZAggregation, weights map[string]float64) (membersWithScores map[string]float64, err error) {
-	membersWithScores, err = c.ZRANGEBYSCORE(sourceKeys[0], math.Inf(-1), math.Inf(+1), 0, 0)
+	membersWithScores = make(map[string]float64)
+	accumulate := zAccumulator(aggregation)
+
+	if len(sourceKeys) == 0 {
+		return
+	}
+
+	firstSet, err := c.ZRANGEBYSCORE(sourceKeys[0], math.Inf(-1), math.Inf(+1), 0, 0)
 	if err != nil {
 		return
 	}
 
+	for member, score := range firstSet {
+		membersWithScores[member] = score * zGetWeight(weights, sourceKeys[0])
+	}
+
 	for i := 1; i < len(sourceKeys); i++ {
 		sourceKey := sourceKeys[i]
 		currentSet, err := c.ZRANGEBYSCORE(sourceKey, math.Inf(-1), math.Inf(+1), 0, 0)
@@ -550,7 +1875,7 @@ func (c Client) ZINTER(sourceKeys []string, aggregation ZAggregation, weights ma
 
 		for member, score := range membersWithScores {
 			if currentSetValue, ok := currentSet[member]; ok {
-				membersWithScores[member] = accumulators[aggregation](score, currentSetValue*zGetWeight(weights, sourceKey))
+				membersWithScores[member] = accumulate(score, currentSetValue*zGetWeight(weights, sourceKey))
 			} else {
 				delete(membersWithScores, member)
 			}
@@ -1,8 +1,12 @@
 package redimo
 
 import (
+	"context"
+	"fmt"
 	"math"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -133,6 +137,115 @@ func TestZPops(t *testing.T) {
 	count, err = c.ZCARD("z1")
 	assert.NoError(t, err)
 	assert.Equal(t, int32(0), count)
+
+	unchunked := c.TransactionActions(0)
+
+	_, err = unchunked.ZADD("z2", map[string]float64{"m1": 1, "m2": 2, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+
+	membersWithScores, err = unchunked.ZPOPMIN("z2", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m1": 1, "m2": 2}, membersWithScores)
+
+	membersWithScores, err = unchunked.ZPOPMAX("z2", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m3": 3}, membersWithScores)
+}
+
+func TestZConcurrentIncrements(t *testing.T) {
+	c := newClient(t)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+				_, err := c.ZINCRBY("z1", "hot", 1)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	score, ok, err := c.ZSCORE("z1", "hot")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(100), score)
+}
+
+func TestZIncrByBulk(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	_, err := c.ZADD("z1", map[string]float64{"alice": 10, "bob": 20}, Flags{})
+	assert.NoError(t, err)
+
+	newScores, err := c.ZINCRBYBULK("z1", map[string]float64{"alice": 5, "bob": -5, "carol": 1}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"alice": 15, "bob": 15, "carol": 1}, newScores)
+
+	count, err := c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+}
+
+func TestZConcurrentPops(t *testing.T) {
+	c := newClient(t).RetryPolicy(RetryPolicy{MaxAttempts: 20, Jitter: 1})
+
+	membersWithScores := make(map[string]float64)
+	for i := 0; i < 20; i++ {
+		membersWithScores[fmt.Sprintf("m%d", i)] = float64(i)
+	}
+
+	_, err := c.ZADD("z1", membersWithScores, Flags{})
+	assert.NoError(t, err)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		popped []string
+	)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				claimed, err := c.ZPOPMIN("z1", 2)
+				if err != nil && err != ErrTooMuchContention {
+					assert.NoError(t, err)
+					return
+				}
+
+				if err == nil && len(claimed) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for member := range claimed {
+					popped = append(popped, member)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 20, len(popped))
+
+	unique := make(map[string]bool)
+	for _, member := range popped {
+		unique[member] = true
+	}
+
+	assert.Equal(t, 20, len(unique))
 }
 
 func TestZRanges(t *testing.T) {
@@ -370,6 +483,18 @@ func TestZAggregations(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]float64{"m3": 10}, set)
 
+	set, err = c.ZINTER([]string{"z1", "z2"}, ZAggregationSum, map[string]float64{"z1": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m3": 9.5}, set)
+
+	set, err = c.ZINTER([]string{"z1", "z2"}, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m3": 6.5}, set)
+
+	set, err = c.ZINTER(nil, ZAggregationSum, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{}, set)
+
 	set, err = c.ZINTER([]string{"z1", "z2"}, ZAggregationMin, map[string]float64{"z2": 2})
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]float64{"m3": 3}, set)
@@ -386,3 +511,993 @@ func TestZAggregations(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]float64{"m3": 7}, set)
 }
+
+func TestZUnionStoreOverwrites(t *testing.T) {
+	c := newClient(t)
+
+	bigSet := make(map[string]float64)
+	for i := 0; i < 60; i++ {
+		bigSet[fmt.Sprintf("m%v", i)] = float64(i)
+	}
+
+	_, err := c.ZADD("z1", bigSet, Flags{})
+	assert.NoError(t, err)
+	_, err = c.ZADD("z2", map[string]float64{"m1": 10, "extra": 100}, Flags{})
+	assert.NoError(t, err)
+	_, err = c.ZADD("dest", map[string]float64{"stale": 1, "m2": 42}, Flags{})
+	assert.NoError(t, err)
+
+	set, err := c.ZUNIONSTORE("dest", []string{"z1", "z2"}, ZAggregationSum, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 61, len(set))
+
+	count, err := c.ZCARD("dest")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(61), count)
+
+	_, ok, err := c.ZSCORE("dest", "stale")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	score, ok, err := c.ZSCORE("dest", "m1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(11), score)
+
+	score, ok, err = c.ZSCORE("dest", "m2")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(2), score)
+}
+
+func TestZInterStoreReplacesDestination(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{"m1": 1, "m2": 2, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+	_, err = c.ZADD("z2", map[string]float64{"m2": 20, "m3": 30, "m4": 40}, Flags{})
+	assert.NoError(t, err)
+	_, err = c.ZADD("dest", map[string]float64{"m1": 1, "m2": 100}, Flags{})
+	assert.NoError(t, err)
+
+	set, err := c.ZINTERSTORE("dest", []string{"z1", "z2"}, ZAggregationMin, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m2": 2, "m3": 3}, set)
+
+	set, err = c.ZRANGEBYSCORE("dest", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m2": 2, "m3": 3}, set)
+
+	set, err = c.ZINTERSTORE("dest", []string{"z1", "nosuchkey"}, ZAggregationSum, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, set)
+
+	count, err := c.ZCARD("dest")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	limited := c.TransactionActions(2)
+
+	set, err = limited.ZINTERSTORE("dest", []string{"z1", "z1"}, ZAggregationMin, nil)
+	assert.Equal(t, ErrTooManyKeys, err)
+	assert.Len(t, set, 3)
+
+	count, err = c.ZCARD("dest")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+}
+
+func TestZRemRangeByScoreBatches(t *testing.T) {
+	c := newClient(t)
+
+	set := make(map[string]float64)
+	for i := 0; i < 70; i++ {
+		set[fmt.Sprintf("m%v", i)] = float64(i)
+	}
+
+	_, err := c.ZADD("z1", set, Flags{})
+	assert.NoError(t, err)
+
+	removedMembers, err := c.ZREMRANGEBYSCORE("z1", 10, 59)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, len(removedMembers))
+
+	count, err := c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(20), count)
+
+	count, err = c.ZCOUNT("z1", 10, 59)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	removedMembers, err = c.ZREMRANGEBYSCORE("z1", 100, 200)
+	assert.NoError(t, err)
+	assert.Empty(t, removedMembers)
+}
+
+func TestZRemRangeByRankNegativeIndexes(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{
+		"m1": 1, "m2": 2, "m3": 3, "m4": 4, "m5": 5, "m6": 6,
+	}, Flags{})
+	assert.NoError(t, err)
+
+	removedMembers, err := c.ZREMRANGEBYRANK("z1", -2, -1)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m5", "m6"}, removedMembers)
+
+	removedMembers, err = c.ZREMRANGEBYRANK("z1", 1, -2)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m2", "m3"}, removedMembers)
+
+	removedMembers, err = c.ZREMRANGEBYRANK("z1", 5, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, removedMembers)
+
+	removedMembers, err = c.ZREMRANGEBYRANK("z1", -100, 100)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"m1", "m4"}, removedMembers)
+
+	count, err := c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+}
+
+func TestZAddIncr(t *testing.T) {
+	c := newClient(t)
+
+	score, ok, err := c.ZADDINCR("z1", "m1", 1.5, Flags{})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, score)
+
+	score, ok, err = c.ZADDINCR("z1", "m1", 2, Flags{})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3.5, score)
+
+	_, ok, err = c.ZADDINCR("z1", "m1", 2, Flags{IfNotExists})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = c.ZADDINCR("z1", "m2", 2, Flags{IfAlreadyExists})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, found, err := c.ZSCORE("z1", "m2")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	score, ok, err = c.ZADDINCR("z1", "m2", -4, Flags{IfNotExists})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(-4), score)
+
+	score, ok, err = c.ZADDINCR("z1", "m1", -0.5, Flags{IfAlreadyExists})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(3), score)
+}
+
+func TestZAddBulk(t *testing.T) {
+	c := newClient(t)
+
+	set := make(map[string]float64)
+	for i := 0; i < 130; i++ {
+		set[fmt.Sprintf("m%03d", i)] = float64(i)
+	}
+
+	err := c.ZADDBULK("z1", set, 4)
+	assert.NoError(t, err)
+
+	count, err := c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(130), count)
+
+	score, ok, err := c.ZSCORE("z1", "m129")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(129), score)
+
+	readSet, err := c.ZRANGEBYSCORE("z1", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, set, readSet)
+}
+
+func TestZAddBatched(t *testing.T) {
+	c := newClient(t)
+
+	added, err := c.ZADDPAYLOAD("z1", "m000", 1, StringValue{"payload"}, Flags{})
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	set := make(map[string]float64)
+	for i := 0; i < 60; i++ {
+		set[fmt.Sprintf("m%03d", i)] = float64(i)
+	}
+
+	addedMembers, err := c.ZADD("z1", set, Flags{})
+	assert.NoError(t, err)
+	assert.Len(t, addedMembers, 59)
+	assert.NotContains(t, addedMembers, "m000")
+
+	score, payload, found, err := c.ZSCOREPAYLOAD("z1", "m000")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, float64(0), score)
+	assert.Equal(t, "payload", payload.String())
+
+	readSet, err := c.ZRANGEBYSCORE("z1", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, set, readSet)
+}
+
+func TestZBlockingPops(t *testing.T) {
+	c := newClient(t)
+	policy := PollPolicy{Interval: 10 * time.Millisecond, MaxInterval: 50 * time.Millisecond, Backoff: 2}
+
+	_, err := c.ZADD("z2", map[string]float64{"m1": 1, "m2": 2}, Flags{})
+	assert.NoError(t, err)
+
+	key, member, score, err := c.BZPOPMAX(context.Background(), policy, "z1", "z2")
+	assert.NoError(t, err)
+	assert.Equal(t, "z2", key)
+	assert.Equal(t, "m2", member)
+	assert.Equal(t, float64(2), score)
+
+	key, member, _, err = c.BZPOPMIN(context.Background(), policy, "z1", "z2")
+	assert.NoError(t, err)
+	assert.Equal(t, "z2", key)
+	assert.Equal(t, "m1", member)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	key, _, _, err = c.BZPOPMIN(ctx, policy, "z1", "z2")
+	assert.NoError(t, err)
+	assert.Equal(t, "", key)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		_, err := c.ZADD("z1", map[string]float64{"late": 42}, Flags{})
+		assert.NoError(t, err)
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key, member, score, err = c.BZPOPMIN(ctx, policy, "z1", "z2")
+	assert.NoError(t, err)
+	assert.Equal(t, "z1", key)
+	assert.Equal(t, "late", member)
+	assert.Equal(t, float64(42), score)
+}
+
+func TestZRandMember(t *testing.T) {
+	c := newClient(t)
+	fullSet := map[string]float64{"m1": 1, "m2": 2, "m3": 3, "m4": 4, "m5": 5}
+
+	members, err := c.ZRANDMEMBER("z1", 3)
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+
+	_, err = c.ZADD("z1", fullSet, Flags{})
+	assert.NoError(t, err)
+
+	members, err = c.ZRANDMEMBER("z1", 3)
+	assert.NoError(t, err)
+	assert.Len(t, members, 3)
+	assert.Subset(t, zReadKeys(fullSet), members)
+
+	seen := make(map[string]bool)
+	for _, member := range members {
+		assert.False(t, seen[member])
+		seen[member] = true
+	}
+
+	members, err = c.ZRANDMEMBER("z1", 10)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, zReadKeys(fullSet), members)
+
+	members, err = c.ZRANDMEMBER("z1", -8)
+	assert.NoError(t, err)
+	assert.Len(t, members, 8)
+	assert.Subset(t, zReadKeys(fullSet), members)
+
+	membersWithScores, err := c.ZRANDMEMBERWITHSCORES("z1", 2)
+	assert.NoError(t, err)
+	assert.Len(t, membersWithScores, 2)
+
+	for _, ms := range membersWithScores {
+		assert.Equal(t, fullSet[ms.Member], ms.Score)
+	}
+}
+
+func TestZMScore(t *testing.T) {
+	c := newClient(t)
+
+	set := make(map[string]float64)
+	members := make([]string, 0, 150)
+
+	for i := 0; i < 150; i++ {
+		member := fmt.Sprintf("m%v", i)
+		set[member] = float64(i) / 2
+		members = append(members, member)
+	}
+
+	err := c.ZADDBULK("z1", set, 2)
+	assert.NoError(t, err)
+
+	scores, found, err := c.ZMSCORE("z1", "m3", "nosuchmember", "m149", "m3")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, 0, 74.5, 1.5}, scores)
+	assert.Equal(t, []bool{true, false, true, true}, found)
+
+	scores, found, err = c.ZMSCORE("z1", members...)
+	assert.NoError(t, err)
+	assert.Len(t, scores, 150)
+
+	for i := range members {
+		assert.True(t, found[i])
+		assert.Equal(t, set[members[i]], scores[i])
+	}
+
+	scores, found, err = c.ZMSCORE("z1")
+	assert.NoError(t, err)
+	assert.Empty(t, scores)
+	assert.Empty(t, found)
+}
+
+func TestZScan(t *testing.T) {
+	c := newClient(t)
+
+	set := make(map[string]float64)
+	for i := 0; i < 25; i++ {
+		set[fmt.Sprintf("m%02d", i)] = float64(i)
+	}
+
+	_, err := c.ZADD("z1", set, Flags{})
+	assert.NoError(t, err)
+
+	scanned := make(map[string]float64)
+	cursor := ScanStart
+	calls := 0
+
+	for {
+		page, nextCursor, err := c.ZSCAN("z1", cursor, 10)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(page), 10)
+
+		for _, ms := range page {
+			scanned[ms.Member] = ms.Score
+		}
+
+		calls++
+		cursor = nextCursor
+
+		if cursor == ScanStart {
+			break
+		}
+	}
+
+	assert.Equal(t, set, scanned)
+	assert.GreaterOrEqual(t, calls, 3)
+
+	page, cursor, err := c.ZSCAN("nosuchkey", ScanStart, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, page)
+	assert.Equal(t, ScanStart, cursor)
+
+	_, _, err = c.ZSCAN("z1", "garbage", 10)
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	_, cursor, err = c.ZSCAN("z1", ScanStart, 10)
+	assert.NoError(t, err)
+
+	_, _, err = c.ZSCAN("z2", cursor, 10)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestZOrderedRanges(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{
+		"m1": 1, "m2": 2, "m3": 3, "m4": 4, "m5": 5, "m6": 6,
+	}, Flags{})
+	assert.NoError(t, err)
+
+	ordered, err := c.ZRANGEWITHSCORES("z1", 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m1", 1}, {"m2", 2}, {"m3", 3}}, ordered)
+
+	ordered, err = c.ZRANGEWITHSCORES("z1", -3, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m4", 4}, {"m5", 5}, {"m6", 6}}, ordered)
+
+	ordered, err = c.ZREVRANGEWITHSCORES("z1", 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m6", 6}, {"m5", 5}}, ordered)
+
+	ordered, err = c.ZRANGEBYSCOREWITHSCORES("z1", 2, 4, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m2", 2}, {"m3", 3}, {"m4", 4}}, ordered)
+
+	ordered, err = c.ZREVRANGEBYSCOREWITHSCORES("z1", 5, 2, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m4", 4}, {"m3", 3}}, ordered)
+
+	ordered, err = c.ZRANGEBYLEXWITHSCORES("z1", "m3", "m5", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m3", 3}, {"m4", 4}, {"m5", 5}}, ordered)
+
+	ordered, err = c.ZREVRANGEBYLEXWITHSCORES("z1", "m5", "m3", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m5", 5}, {"m4", 4}, {"m3", 3}}, ordered)
+}
+
+func TestZExclusiveScoreBounds(t *testing.T) {
+	assert.True(t, ExclusiveMin(1.5) > 1.5)
+	assert.True(t, ExclusiveMax(1.5) < 1.5)
+	assert.Equal(t, math.Inf(+1), ExclusiveMin(math.Inf(+1)))
+	assert.Equal(t, math.Inf(-1), ExclusiveMax(math.Inf(-1)))
+
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{
+		"m1": 1,
+		"m2": 1.5,
+		"m3": 2,
+		"m4": 3,
+		"m5": 4,
+	}, Flags{})
+	assert.NoError(t, err)
+
+	count, err := c.ZCOUNT("z1", ExclusiveMin(1.5), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	count, err = c.ZCOUNT("z1", 1.5, ExclusiveMax(3))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	membersWithScores, err := c.ZRANGEBYSCOREWITHSCORES("z1", ExclusiveMin(1), ExclusiveMax(4), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m2", 1.5}, {"m3", 2}, {"m4", 3}}, membersWithScores)
+
+	members, err := c.ZREVRANGEBYSCORE("z1", ExclusiveMax(4), ExclusiveMin(2), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m4": 3}, members)
+
+	removedMembers, err := c.ZREMRANGEBYSCORE("z1", ExclusiveMin(1), ExclusiveMax(2))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m2"}, removedMembers)
+
+	count, err = c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), count)
+}
+
+func TestZLexBounds(t *testing.T) {
+	assert.Equal(t, zLex{}, zLexBound("-"))
+	assert.Equal(t, zLex{}, zLexBound("+"))
+	assert.Equal(t, zLex{}, zLexBound(""))
+	assert.Equal(t, zLex{lex: "m1"}, zLexBound("[m1"))
+	assert.Equal(t, zLex{lex: "m1", excl: true}, zLexBound("(m1"))
+	assert.Equal(t, zLex{lex: "m1"}, zLexBound("m1"))
+
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{
+		"a": 0,
+		"b": 0,
+		"c": 0,
+		"d": 0,
+		"e": 0,
+	}, Flags{})
+	assert.NoError(t, err)
+
+	ordered, err := c.ZRANGEBYLEXWITHSCORES("z1", "-", "(c", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"a", 0}, {"b", 0}}, ordered)
+
+	ordered, err = c.ZRANGEBYLEXWITHSCORES("z1", "(b", "[d", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"c", 0}, {"d", 0}}, ordered)
+
+	ordered, err = c.ZRANGEBYLEXWITHSCORES("z1", "(a", "(e", 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"c", 0}, {"d", 0}}, ordered)
+
+	ordered, err = c.ZREVRANGEBYLEXWITHSCORES("z1", "(e", "(b", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"d", 0}, {"c", 0}}, ordered)
+
+	ordered, err = c.ZREVRANGEBYLEXWITHSCORES("z1", "+", "(c", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"e", 0}, {"d", 0}}, ordered)
+
+	count, err := c.ZLEXCOUNT("z1", "-", "+")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), count)
+
+	count, err = c.ZLEXCOUNT("z1", "(a", "(e")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	count, err = c.ZLEXCOUNT("z1", "(c", "(c")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	count, err = c.ZLEXCOUNT("z1", "(bb", "[d")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+}
+
+func TestZCountedCardinality(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{"m1": 1, "m2": 2, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+
+	c = c.CountedCardinality()
+
+	count, err := c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+
+	count, err = c.RepairCardinality("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	_, err = c.ZADD("z1", map[string]float64{"m3": 30, "m4": 4, "m5": 5}, Flags{})
+	assert.NoError(t, err)
+
+	_, err = c.ZINCRBY("z1", "m5", 1)
+	assert.NoError(t, err)
+
+	_, err = c.ZINCRBY("z1", "m6", 6)
+	assert.NoError(t, err)
+
+	count, err = c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), count)
+
+	_, err = c.ZREM("z1", "m1", "missing")
+	assert.NoError(t, err)
+
+	_, err = c.ZPOPMAX("z1", 1)
+	assert.NoError(t, err)
+
+	_, err = c.ZREMRANGEBYSCORE("z1", 4, 5)
+	assert.NoError(t, err)
+
+	count, err = c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	_, err = c.ZUNIONSTORE("z2", []string{"z1"}, ZAggregationSum, nil)
+	assert.NoError(t, err)
+
+	count, err = c.ZCARD("z2")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	err = c.ZADDBULK("z3", map[string]float64{"m1": 1, "m2": 2}, 1)
+	assert.NoError(t, err)
+
+	count, err = c.ZCARD("z3")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+}
+
+func TestZRangePages(t *testing.T) {
+	c := newClient(t)
+
+	membersWithScores := make(map[string]float64)
+	for i := 0; i < 25; i++ {
+		membersWithScores[fmt.Sprintf("m%02d", i)] = float64(i)
+	}
+
+	_, err := c.ZADD("z1", membersWithScores, Flags{})
+	assert.NoError(t, err)
+
+	var walked []MemberScore
+
+	pages := 0
+	err = c.ZRANGEBYSCOREPAGES(context.Background(), "z1", math.Inf(-1), math.Inf(+1), 10, func(page []MemberScore) bool {
+		assert.True(t, len(page) <= 10)
+
+		pages++
+		walked = append(walked, page...)
+
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, 25, len(walked))
+	assert.Equal(t, MemberScore{"m00", 0}, walked[0])
+	assert.Equal(t, MemberScore{"m24", 24}, walked[24])
+
+	walked = nil
+	err = c.ZREVRANGEBYSCOREPAGES(context.Background(), "z1", 20, ExclusiveMin(10), 4, func(page []MemberScore) bool {
+		walked = append(walked, page...)
+		return len(walked) < 8
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(walked))
+	assert.Equal(t, MemberScore{"m20", 20}, walked[0])
+	assert.Equal(t, MemberScore{"m13", 13}, walked[7])
+
+	walked = nil
+	err = c.ZRANGEBYLEXPAGES(context.Background(), "z1", "(m05", "[m08", 2, func(page []MemberScore) bool {
+		walked = append(walked, page...)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"m06", 6}, {"m07", 7}, {"m08", 8}}, walked)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err = c.ZREVRANGEBYLEXPAGES(ctx, "z1", "+", "-", 5, func(page []MemberScore) bool {
+		cancel()
+		return true
+	})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestZAddCounts(t *testing.T) {
+	c := newClient(t)
+
+	added, updated, err := c.ZADDCOUNTS("z1", map[string]float64{"m1": 1, "m2": 2}, Flags{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), added)
+	assert.Equal(t, int32(0), updated)
+
+	added, updated, err = c.ZADDCOUNTS("z1", map[string]float64{"m1": 10, "m2": 20, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), added)
+	assert.Equal(t, int32(2), updated)
+
+	added, updated, err = c.ZADDCOUNTS("z1", map[string]float64{"m1": 100, "m4": 4}, Flags{IfAlreadyExists})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), added)
+	assert.Equal(t, int32(1), updated)
+
+	added, updated, err = c.ZADDCOUNTS("z1", map[string]float64{"m1": 100, "m2": 21, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), added)
+	assert.Equal(t, int32(1), updated)
+
+	added, updated, err = c.ZADDCOUNTS("z1", map[string]float64{"m1": 100, "m2": 22}, Flags{IfAlreadyExists})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), added)
+	assert.Equal(t, int32(1), updated)
+
+	count, err := c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+}
+
+func TestZMemberResults(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{"m1": 1, "m2": 2}, Flags{})
+	assert.NoError(t, err)
+
+	results, err := c.ZADDRESULTS("z1", map[string]float64{"m1": 10, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]MemberResult{
+		"m1": {Status: MemberUpdated},
+		"m3": {Status: MemberAdded},
+	}, results)
+
+	results, err = c.ZADDRESULTS("z1", map[string]float64{"m2": 20, "m4": 4}, Flags{IfNotExists})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]MemberResult{
+		"m2": {Status: MemberSkipped},
+		"m4": {Status: MemberAdded},
+	}, results)
+
+	results, err = c.ZREMRESULTS("z1", "m1", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]MemberResult{
+		"m1":      {Status: MemberRemoved},
+		"missing": {Status: MemberSkipped},
+	}, results)
+
+	members, err := c.ZRANGEBYSCORE("z1", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m2": 2, "m3": 3, "m4": 4}, members)
+}
+
+func TestZMemberTTL(t *testing.T) {
+	ttl, ok := Flags{IfNotExists, WithMemberTTL(90 * time.Second)}.ttl()
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, ttl)
+
+	_, ok = Flags{IfNotExists}.ttl()
+	assert.False(t, ok)
+
+	unfiltered := newClient(t)
+	c := unfiltered.FilterExpired()
+
+	_, err := c.ZADD("z1", map[string]float64{"m1": 1, "m2": 2}, Flags{WithMemberTTL(-time.Minute)})
+	assert.NoError(t, err)
+
+	_, err = c.ZADD("z1", map[string]float64{"m3": 3}, Flags{WithMemberTTL(time.Hour)})
+	assert.NoError(t, err)
+
+	_, err = c.ZADD("z1", map[string]float64{"m2": 2, "m4": 4}, Flags{})
+	assert.NoError(t, err)
+
+	_, ok, err = c.ZSCORE("z1", "m1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = c.ZSCORE("z1", "m3")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, found, err := c.ZMSCORE("z1", "m1", "m2")
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, found)
+
+	members, err := c.ZRANGEBYSCORE("z1", math.Inf(-1), math.Inf(+1), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m2": 2, "m3": 3, "m4": 4}, members)
+
+	count, err := c.ZCOUNT("z1", math.Inf(-1), math.Inf(+1))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	count, err = c.ZLEXCOUNT("z1", "-", "+")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	_, ok, err = unfiltered.ZSCORE("z1", "m1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestZMPop(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z2", map[string]float64{"m1": 1, "m2": 2, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+
+	_, err = c.ZADD("z3", map[string]float64{"m4": 4}, Flags{})
+	assert.NoError(t, err)
+
+	key, membersWithScores, err := c.ZMPOP([]string{"z1", "z2", "z3"}, ZPopMin, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "z2", key)
+	assert.Equal(t, map[string]float64{"m1": 1, "m2": 2}, membersWithScores)
+
+	key, membersWithScores, err = c.ZMPOP([]string{"z1", "z2", "z3"}, ZPopMax, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "z2", key)
+	assert.Equal(t, map[string]float64{"m3": 3}, membersWithScores)
+
+	key, membersWithScores, err = c.ZMPOP([]string{"z1", "z2", "z3"}, ZPopMax, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "z3", key)
+	assert.Equal(t, map[string]float64{"m4": 4}, membersWithScores)
+
+	key, membersWithScores, err = c.ZMPOP([]string{"z1", "z2", "z3"}, ZPopMin, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", key)
+	assert.Empty(t, membersWithScores)
+}
+
+func TestZScoreSegments(t *testing.T) {
+	assert.Equal(t, [][2]float64{{1, 1}}, zScoreSegments(1, 1, 4))
+	assert.Equal(t, [][2]float64{{1, 5}}, zScoreSegments(1, 5, 1))
+	assert.Equal(t, [][2]float64{
+		{0, ExclusiveMax(25)},
+		{25, ExclusiveMax(50)},
+		{50, ExclusiveMax(75)},
+		{75, 100},
+	}, zScoreSegments(0, 100, 4))
+
+	segments := zScoreSegments(-math.MaxFloat64, math.MaxFloat64, 8)
+	assert.Equal(t, 8, len(segments))
+	assert.Equal(t, -math.MaxFloat64, segments[0][0])
+	assert.Equal(t, math.MaxFloat64, segments[7][1])
+
+	for i := 1; i < len(segments); i++ {
+		assert.Equal(t, segments[i-1][1], ExclusiveMax(segments[i][0]))
+	}
+
+	segments = zScoreSegments(1, math.Nextafter(1, 2), 4)
+	assert.Equal(t, [][2]float64{{1, 1}, {math.Nextafter(1, 2), math.Nextafter(1, 2)}}, segments)
+}
+
+func TestZParallelRank(t *testing.T) {
+	c := newClient(t).ParallelCounting(4)
+
+	membersWithScores := make(map[string]float64)
+	for i := 0; i < 50; i++ {
+		membersWithScores[fmt.Sprintf("m%02d", i)] = float64(i * i)
+	}
+
+	_, err := c.ZADD("z1", membersWithScores, Flags{})
+	assert.NoError(t, err)
+
+	for _, i := range []int{0, 1, 17, 42, 49} {
+		rank, found, err := c.ZRANK("z1", fmt.Sprintf("m%02d", i))
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, int32(i), rank)
+
+		rank, found, err = c.ZREVRANK("z1", fmt.Sprintf("m%02d", i))
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, int32(49-i), rank)
+	}
+}
+
+func TestZParallelCount(t *testing.T) {
+	c := newClient(t).ParallelCounting(8).CountingConcurrency(2)
+
+	membersWithScores := make(map[string]float64)
+	for i := 0; i < 50; i++ {
+		membersWithScores[fmt.Sprintf("m%02d", i)] = float64(i * i)
+	}
+
+	_, err := c.ZADD("z1", membersWithScores, Flags{})
+	assert.NoError(t, err)
+
+	count, err := c.ZCOUNT("z1", math.Inf(-1), math.Inf(+1))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(50), count)
+
+	count, err = c.ZCOUNT("z1", 100, 400)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(11), count)
+
+	count, err = c.ZCOUNT("z1", 5000, 6000)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+}
+
+func TestZRangeIndexes(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{
+		"m0": 0, "m1": 1, "m2": 2, "m3": 3, "m4": 4, "m5": 5, "m6": 6, "m7": 7, "m8": 8, "m9": 9,
+	}, Flags{})
+	assert.NoError(t, err)
+
+	members := func(ordered []MemberScore) (members []string) {
+		for _, ms := range ordered {
+			members = append(members, ms.Member)
+		}
+
+		return
+	}
+
+	cases := []struct {
+		start, stop int32
+		forward     []string
+		reverse     []string
+	}{
+		{0, 2, []string{"m0", "m1", "m2"}, []string{"m9", "m8", "m7"}},
+		{-3, -1, []string{"m7", "m8", "m9"}, []string{"m2", "m1", "m0"}},
+		{1, -8, []string{"m1", "m2"}, []string{"m8", "m7"}},
+		{-2, 9, []string{"m8", "m9"}, []string{"m1", "m0"}},
+		{7, -1, []string{"m7", "m8", "m9"}, []string{"m2", "m1", "m0"}},
+		{-100, 1, []string{"m0", "m1"}, []string{"m9", "m8"}},
+		{-100, -9, []string{"m0", "m1"}, []string{"m9", "m8"}},
+		{8, 100, []string{"m8", "m9"}, []string{"m1", "m0"}},
+		{-1, 100, []string{"m9"}, []string{"m0"}},
+		{3, 1, nil, nil},
+		{-1, -3, nil, nil},
+		{5, -6, nil, nil},
+		{10, 20, nil, nil},
+		{-20, -11, nil, nil},
+		{10, -1, nil, nil},
+		{8, math.MaxInt32, []string{"m8", "m9"}, []string{"m1", "m0"}},
+		{math.MinInt32, -9, []string{"m0", "m1"}, []string{"m9", "m8"}},
+		{math.MinInt32, math.MinInt32, nil, nil},
+		{5, math.MaxInt32, []string{"m5", "m6", "m7", "m8", "m9"}, []string{"m4", "m3", "m2", "m1", "m0"}},
+	}
+
+	for _, tc := range cases {
+		ordered, err := c.ZRANGEWITHSCORES("z1", tc.start, tc.stop)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.forward, members(ordered), "ZRANGE %v %v", tc.start, tc.stop)
+
+		ordered, err = c.ZREVRANGEWITHSCORES("z1", tc.start, tc.stop)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.reverse, members(ordered), "ZREVRANGE %v %v", tc.start, tc.stop)
+	}
+
+	ordered, err := c.ZRANGEBYSCOREWITHSCORES("z1", math.Inf(-1), math.Inf(+1), 8, math.MaxInt32-1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m8", "m9"}, members(ordered))
+
+	ordered, err = c.ZRANGEWITHSCORES("empty", 0, -1)
+	assert.NoError(t, err)
+	assert.Empty(t, ordered)
+}
+
+func TestZRemIfScore(t *testing.T) {
+	c := newClient(t).CountedCardinality()
+
+	_, err := c.ZADD("z1", map[string]float64{"m1": 10, "m2": 2, "m3": 3}, Flags{})
+	assert.NoError(t, err)
+
+	removedMembers, err := c.zRemIfScore("z1", []MemberScore{{"m1", 1}, {"m2", 2}, {"missing", 4}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m2"}, removedMembers)
+
+	removedMembers, err = c.zRemIfScore("z1", []MemberScore{{"m1", 10}, {"m3", 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m3"}, removedMembers)
+
+	count, err := c.ZCARD("z1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), count)
+}
+
+func TestZRangeLimits(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.ZADD("z1", map[string]float64{"a": 1, "b": 2, "c": 3, "d": 4}, Flags{})
+	assert.NoError(t, err)
+
+	members, err := c.ZRANGEBYSCOREWITHSCORES("z1", math.Inf(-1), math.Inf(+1), 1, AllResults)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"b", 2}, {"c", 3}, {"d", 4}}, members)
+
+	members, err = c.ZREVRANGEBYSCOREWITHSCORES("z1", math.Inf(+1), math.Inf(-1), 2, -5)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"b", 2}, {"a", 1}}, members)
+
+	members, err = c.ZRANGEBYLEXWITHSCORES("z1", "(a", "+", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"b", 2}, {"c", 3}, {"d", 4}}, members)
+
+	members, err = c.ZRANGEBYSCOREWITHSCORES("z1", math.Inf(-1), math.Inf(+1), -1, AllResults)
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestZStrictLex(t *testing.T) {
+	unchecked := newClient(t)
+	c := unchecked.StrictLex()
+
+	_, err := c.ZADD("z1", map[string]float64{"a": 0, "b": 0, "c": 0}, Flags{})
+	assert.NoError(t, err)
+
+	count, err := c.ZLEXCOUNT("z1", "-", "+")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), count)
+
+	_, err = c.ZADD("z1", map[string]float64{"d": 1}, Flags{})
+	assert.NoError(t, err)
+
+	_, err = c.ZLEXCOUNT("z1", "-", "+")
+	assert.Equal(t, ErrMixedScores, err)
+
+	_, err = c.ZRANGEBYLEX("z1", "-", "+", 0, AllResults)
+	assert.Equal(t, ErrMixedScores, err)
+
+	_, err = c.ZREMRANGEBYLEX("z1", "[a", "[b")
+	assert.Equal(t, ErrMixedScores, err)
+
+	members, err := unchecked.ZRANGEBYLEXWITHSCORES("z1", "(b", "+", 0, AllResults)
+	assert.NoError(t, err)
+	assert.Equal(t, []MemberScore{{"c", 0}, {"d", 1}}, members)
+
+	members, err = c.ZRANGEBYLEXWITHSCORES("empty", "-", "+", 0, AllResults)
+	assert.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestClampInt32(t *testing.T) {
+	assert.Equal(t, int32(5), clampInt32(5))
+	assert.Equal(t, int32(math.MaxInt32), clampInt32(math.MaxInt32+1))
+	assert.Equal(t, int32(math.MinInt32), clampInt32(math.MinInt32-1))
+}
@@ -700,18 +700,15 @@ func (c Client) XREADGROUP(key string, group string, consumer string, option XRe
 		return c.xGroupReadPending(key, group, consumer, maxCount)
 	}
 
-	retryCount := 0
-
-	for retryCount < 5 {
+	err = c.retryPolicy.retry(func() (done bool, err error) {
 		currentCursor, err := c.xGroupCursorGet(key, group)
 		if err != nil {
-			return items, err
+			return true, err
 		}
 
-		items, err := c.XRANGE(key, currentCursor.Next(), XEnd, 1)
-
+		items, err = c.XRANGE(key, currentCursor.Next(), XEnd, 1)
 		if err != nil || len(items) == 0 {
-			return items, err
+			return true, err
 		}
 
 		item := items[0]
@@ -730,17 +727,18 @@ func (c Client) XREADGROUP(key string, group string, consumer string, option XRe
 		_, err = c.ddbClient.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
 			TransactItems: actions,
 		})
-		if err == nil {
-			return items, nil
+		if conditionFailureError(err) {
+			return false, nil
 		}
 
-		if !conditionFailureError(err) {
-			return items, err
-		}
-		retryCount++
+		return true, err
+	})
+
+	if err == ErrTooMuchContention {
+		items = nil
 	}
 
-	return items, errors.New("too much contention")
+	return
 }
 
 // XREVRANGE is similar to XRANGE, but in reverse order. The stream items in descending chronological order. Using the
@@ -2,14 +2,25 @@ package redimo
 
 import (
 	"context"
-	"strings"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
-// GET fetches the value at the given key. If the key does not exist, the ReturnValue will be Empty().
+// ErrOffsetOutOfRange is returned by SETRANGE and the bitmap commands, like SETBIT, for offsets that are negative or
+// too large.
+var ErrOffsetOutOfRange = errors.New("offset is out of range")
+
+// ErrTooManyKeys is returned by MSETNX, ZINTERSTORE, HSET with AtomicHashes, and hash reads with SnapshotHashes, when
+// the keys, members or fields don't fit into a single transaction.
+var ErrTooManyKeys = errors.New("too many keys for a single transaction")
+
+// GET fetches the value at the given key. If the key does not exist, or has expired (see WithKeyTTL), the
+// ReturnValue will be Empty().
 //
 // Works similar to https://redis.io/commands/get
 func (c Client) GET(key string) (val ReturnValue, err error) {
@@ -18,20 +29,26 @@ func (c Client) GET(key string) (val ReturnValue, err error) {
 		Key:            keyDef{pk: key, sk: ""}.toAV(c),
 		TableName:      aws.String(c.tableName),
 	})
-	if err != nil || len(resp.Item) == 0 {
+	if err != nil || len(resp.Item) == 0 || itemExpired(resp.Item) {
 		return
 	}
 
-	val = ReturnValue{resp.Item[vk]}
-
-	return
+	return c.decodeValue(resp.Item)
 }
 
 // SET stores the given Value at the given key. If called as SET("key", "value", None), SET is
 // unconditional and is not expected to fail.
 //
 // The condition flags IfNotExists and IfAlreadyExists can be specified, and if they are
-// the SET becomes conditional and will return false if the condition fails.
+// the SET becomes conditional and will return false if the condition fails. A key that has expired but not been
+// deleted by DynamoDB yet counts as not existing.
+//
+// With WithKeyTTL the key expires after the given duration, with KeepTTL the key keeps the expiry it had, and
+// otherwise any expiry the key had is removed. Expired keys are hidden from GET and count as not existing for
+// IfNotExists and IfAlreadyExists right away, but are only deleted once DynamoDB's Time to Live process gets to
+// them – enable Time to Live on the "ttl" attribute of the table to have them deleted.
+//
+// Use SETGET to also get the value that was replaced.
 //
 // Works similar to https://redis.io/commands/set
 func (c Client) SET(key string, vValue interface{}, flags ...Flag) (ok bool, err error) {
@@ -39,21 +56,68 @@ func (c Client) SET(key string, vValue interface{}, flags ...Flag) (ok bool, err
 	if err != nil {
 		return
 	}
+
+	_, ok, err = c.set(key, value, flags, false)
+
+	return
+}
+
+// SETGET works like SET with the GET option in Redis: it takes the same flags as SET, and also returns the value
+// that was stored at the key before, or an Empty() value if the key didn't exist or had expired. The old value is
+// returned by the same write that replaces it, so compare-and-swap flows need a single round trip.
+//
+// If the condition of IfNotExists or IfAlreadyExists fails, ok is false and the current value is returned
+// instead. DynamoDB doesn't return the item when a condition fails, so it is read with a separate GET, which can
+// see a newer value than the one that made the condition fail.
+//
+// Works similar to https://redis.io/commands/set
+func (c Client) SETGET(key string, vValue interface{}, flags ...Flag) (oldValue ReturnValue, ok bool, err error) {
+	value, err := ToValueE(vValue)
+	if err != nil {
+		return
+	}
+
+	oldValue, ok, err = c.set(key, value, flags, true)
+	if err == nil && !ok {
+		oldValue, err = c.GET(key)
+	}
+
+	return
+}
+
+func (c Client) set(key string, value Value, flags Flags, returnOld bool) (oldValue ReturnValue, ok bool, err error) {
+	return c.setWhere(key, value, flags, returnOld, nil)
+}
+
+// setWhere works like set, with the extra conditions added by condition, if it isn't nil.
+func (c Client) setWhere(key string, value Value, flags Flags, returnOld bool,
+	condition func(b *expressionBuilder)) (oldValue ReturnValue, ok bool, err error) {
 	builder := newExpresionBuilder()
 
-	builder.updateSET(vk, value)
+	if err = c.updateValue(&builder, value); err != nil {
+		return
+	}
 
-	for _, flag := range flags {
-		if flag == IfNotExists {
-			builder.addConditionNotExists(c.partitionKey)
-		}
+	builder.updateTTL(flags)
 
-		if flag == IfAlreadyExists {
-			builder.addConditionExists(c.partitionKey)
-		}
+	if flags.has(IfNotExists) {
+		builder.addConditionNotExistsOrExpired(c.partitionKey)
+	}
+
+	if flags.has(IfAlreadyExists) {
+		builder.addConditionExistsUnexpired(c.partitionKey)
+	}
+
+	if condition != nil {
+		condition(&builder)
+	}
+
+	returnValues := types.ReturnValueNone
+	if returnOld {
+		returnValues = types.ReturnValueAllOld
 	}
 
-	_, err = c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
 		ConditionExpression:       builder.conditionExpression(),
 		ExpressionAttributeNames:  builder.expressionAttributeNames(),
 		ExpressionAttributeValues: builder.expressionAttributeValues(),
@@ -62,17 +126,84 @@ func (c Client) SET(key string, vValue interface{}, flags ...Flag) (ok bool, err
 			pk: key,
 			sk: "",
 		}.toAV(c),
-		TableName: aws.String(c.tableName),
+		ReturnValues: returnValues,
+		TableName:    aws.String(c.tableName),
 	})
 	if conditionFailureError(err) {
-		return false, nil
+		return oldValue, false, nil
 	}
 
 	if err != nil {
 		return
 	}
 
-	return true, nil
+	if len(resp.Attributes) > 0 && !itemExpired(resp.Attributes) {
+		oldValue, err = c.decodeValue(resp.Attributes)
+	}
+
+	return oldValue, true, err
+}
+
+// CAS sets the key to newValue only if it currently holds expectedOld, and returns false without changing anything
+// if it doesn't. A nil expectedOld means that the key must not exist, and a key that has expired counts as not
+// existing. The flags work like in SET, so the new value can be given an expiry with WithKeyTTL or keep the
+// current one with KeepTTL.
+//
+// The comparison is done by DynamoDB as a condition on the write, so CAS is a single round trip. Values compressed
+// by a client with Compression can't be compared that way, so a client with a codec reads the value first and
+// writes on the condition that it hasn't changed, which costs an extra read.
+//
+// Cost is O(1) / 1 WCU, plus 1 RCU with Compression.
+func (c Client) CAS(key string, expectedOld interface{}, newValue interface{}, flags ...Flag) (ok bool, err error) {
+	value, err := ToValueE(newValue)
+	if err != nil {
+		return
+	}
+
+	if expectedOld == nil {
+		_, ok, err = c.setWhere(key, value, append(Flags{IfNotExists}, flags...), false, nil)
+		return
+	}
+
+	expected, err := ToValueE(expectedOld)
+	if err != nil {
+		return
+	}
+
+	if c.codec == nil {
+		_, ok, err = c.setWhere(key, value, append(Flags{IfAlreadyExists}, flags...), false, func(b *expressionBuilder) {
+			b.addConditionEquality(vk, expected)
+			b.addConditionNotExists(codecKey)
+		})
+
+		return
+	}
+
+	resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		ConsistentRead: aws.Bool(true),
+		Key:            keyDef{pk: key, sk: ""}.toAV(c),
+		TableName:      aws.String(c.tableName),
+	})
+	if err != nil || len(resp.Item) == 0 || itemExpired(resp.Item) {
+		return
+	}
+
+	current, err := c.decodeValue(resp.Item)
+	if err != nil || !current.Equals(ReturnValue{expected.ToAV()}) {
+		return
+	}
+
+	_, ok, err = c.setWhere(key, value, append(Flags{IfAlreadyExists}, flags...), false, func(b *expressionBuilder) {
+		b.addConditionEquality(vk, ReturnValue{resp.Item[vk]})
+
+		if marker, found := resp.Item[codecKey]; found {
+			b.addConditionEquality(codecKey, ReturnValue{marker})
+		} else {
+			b.addConditionNotExists(codecKey)
+		}
+	})
+
+	return
 }
 
 // SETNX is equivalent to SET(key, value, Flags{IfNotExists})
@@ -82,42 +213,249 @@ func (c Client) SETNX(key string, value Value) (ok bool, err error) {
 	return c.SET(key, value, IfNotExists)
 }
 
-// GETSET gets the value at the key and atomically sets it to a new value.
+// GETSET gets the value at the key and atomically sets it to a new value, removing any expiry the key had. An
+// expired key returns an Empty() old value. It is equivalent to SETGET without flags.
 //
 // Works similar to https://redis.io/commands/getset
 func (c Client) GETSET(key string, value Value) (oldValue ReturnValue, err error) {
-	builder := newExpresionBuilder()
-	builder.updateSET(vk, value)
+	oldValue, _, err = c.set(key, value, Flags{}, true)
 
-	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-		ConditionExpression:       builder.conditionExpression(),
-		ExpressionAttributeNames:  builder.expressionAttributeNames(),
-		ExpressionAttributeValues: builder.expressionAttributeValues(),
-		UpdateExpression:          builder.updateExpression(),
-		Key: keyDef{
-			pk: key,
-			sk: "",
-		}.toAV(c),
-		ReturnValues: types.ReturnValueAllOld,
-		TableName:    aws.String(c.tableName),
+	return
+}
+
+// SETJSON works like SET, but marshals v to JSON first, so structs and other values without a direct DynamoDB
+// representation can be stored without reimplementing serialization. See ToJSONValue for storing JSON in other
+// commands.
+func (c Client) SETJSON(key string, v interface{}, flags ...Flag) (ok bool, err error) {
+	value, err := ToJSONValue(v)
+	if err != nil {
+		return
+	}
+
+	return c.SET(key, value, flags...)
+}
+
+// GETJSON works like GET, but unmarshals the JSON stored with SETJSON into v. If the key doesn't exist or has
+// expired, found is false and v is left unchanged.
+func (c Client) GETJSON(key string, v interface{}) (found bool, err error) {
+	val, err := c.GET(key)
+	if err != nil || val.Empty() {
+		return
+	}
+
+	return true, val.JSON(v)
+}
+
+// APPEND appends value to the string at key and returns the length of the new string in bytes. If the key doesn't
+// exist or has expired, it is created with value, like SET. Byte values are appended to as bytes, and numbers are
+// turned into a string of their digits first. The key keeps its expiry, if it has one.
+//
+// DynamoDB can't append to a string in place, so the value is read and written back with a condition that it
+// hasn't changed in between. If other clients keep changing the key, the write is retried according to the
+// RetryPolicy of the client, and ErrTooMuchContention is returned if the attempts run out.
+//
+// Cost is O(1) / 1 RCU + 1 WCU per attempt, where the sizes depend on the length of the value.
+//
+// Works similar to https://redis.io/commands/append
+func (c Client) APPEND(key string, value string) (length int64, err error) {
+	return c.rewriteString(key, false, func(current []byte) ([]byte, error) {
+		return append(current, value...), nil
+	})
+}
+
+// SETRANGE overwrites part of the string at key, starting at the given byte offset, and returns the length of the
+// new string in bytes. If the string is shorter than offset, it is padded with zero bytes first, and a key that
+// doesn't exist or has expired is treated as an empty string. An empty value leaves the string unchanged. A negative
+// offset, or one that would make the string larger than MaxItemSize, returns ErrOffsetOutOfRange before anything is
+// read. Values are treated like in APPEND – use bytes values for binary data, since a string cut in the middle of a
+// multi-byte character is no longer valid UTF-8.
+//
+// Cost is O(1) / 1 RCU + 1 WCU per attempt, like APPEND.
+//
+// Works similar to https://redis.io/commands/setrange
+func (c Client) SETRANGE(key string, offset int64, value string) (length int64, err error) {
+	if offset < 0 || offset > MaxItemSize-int64(len(value)) {
+		return 0, ErrOffsetOutOfRange
+	}
+
+	if value == "" {
+		return c.STRLEN(key)
+	}
+
+	return c.rewriteString(key, false, func(current []byte) ([]byte, error) {
+		end := offset + int64(len(value))
+		if int64(len(current)) < end {
+			current = append(current, make([]byte, end-int64(len(current)))...)
+		}
+
+		copy(current[offset:], value)
+
+		return current, nil
 	})
+}
 
-	if err != nil || len(resp.Attributes) == 0 {
+// GETRANGE returns the substring of the string at key between the byte offsets start and end, both inclusive.
+// Negative offsets count from the end of the string, so -1 is the last byte. Offsets outside the string are
+// limited to it, and an empty string is returned if the key doesn't exist or the range is empty.
+//
+// The whole value is read, so the cost doesn't depend on the length of the range.
+//
+// Cost is O(1) / 1 RCU per 4 KB of the value.
+//
+// Works similar to https://redis.io/commands/getrange
+func (c Client) GETRANGE(key string, start, end int64) (substring string, err error) {
+	val, err := c.GET(key)
+	if err != nil {
 		return
 	}
 
-	oldValue = parseItem(resp.Attributes, c).val
+	current := stringBytes(val.ToAV())
+	length := int64(len(current))
+
+	if start < 0 {
+		start += length
+	}
+
+	if end < 0 {
+		end += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if end >= length {
+		end = length - 1
+	}
+
+	if start > end {
+		return "", nil
+	}
+
+	return string(current[start : end+1]), nil
+}
+
+// stringBytes returns the bytes of a string value: the value itself for strings and bytes, and the digits of
+// numbers. The result is a copy that can be modified.
+func stringBytes(av types.AttributeValue) []byte {
+	switch av := av.(type) {
+	case *types.AttributeValueMemberS:
+		return []byte(av.Value)
+	case *types.AttributeValueMemberB:
+		return append([]byte(nil), av.Value...)
+	case *types.AttributeValueMemberN:
+		return []byte(av.Value)
+	}
+
+	return nil
+}
+
+// rewriteString reads the string at key, passes its bytes to fn and writes back the result, on the condition that
+// the value hasn't changed in between, retrying according to the RetryPolicy. Bytes values stay bytes, everything
+// else is written as a string unless binary is set. Expired keys are passed as empty and lose their expiry, other
+// keys keep it. If fn returns an error, nothing is written and the error is returned.
+func (c Client) rewriteString(key string, binary bool, fn func(current []byte) ([]byte, error)) (length int64, err error) {
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		resp, err := c.ddbClient.GetItem(context.TODO(), &dynamodb.GetItemInput{
+			ConsistentRead: aws.Bool(true),
+			Key:            keyDef{pk: key, sk: ""}.toAV(c),
+			TableName:      aws.String(c.tableName),
+		})
+		if err != nil {
+			return
+		}
+
+		builder := newExpresionBuilder()
+		current := resp.Item[vk]
+
+		decoded, err := c.decodeValue(resp.Item)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case len(resp.Item) == 0:
+			builder.addConditionNotExists(c.partitionKey)
+		case itemExpired(resp.Item):
+			builder.addConditionEquality(ttlKey, ReturnValue{resp.Item[ttlKey]})
+			builder.updateTTL(Flags{})
+
+			decoded = ReturnValue{}
+		case current == nil:
+			builder.addConditionNotExists(vk)
+		default:
+			builder.addConditionEquality(vk, ReturnValue{current})
+		}
+
+		rewritten, err := fn(stringBytes(decoded.ToAV()))
+		if err != nil {
+			return true, err
+		}
+
+		if _, isBytes := decoded.ToAV().(*types.AttributeValueMemberB); isBytes || binary {
+			err = c.updateValue(&builder, BytesValue{rewritten})
+		} else {
+			err = c.updateValue(&builder, StringValue{string(rewritten)})
+		}
+
+		if err != nil {
+			return
+		}
+
+		_, err = c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+			ConditionExpression:       builder.conditionExpression(),
+			ExpressionAttributeNames:  builder.expressionAttributeNames(),
+			ExpressionAttributeValues: builder.expressionAttributeValues(),
+			Key:                       keyDef{pk: key, sk: ""}.toAV(c),
+			TableName:                 aws.String(c.tableName),
+			UpdateExpression:          builder.updateExpression(),
+		})
+		if conditionFailureError(err) {
+			return false, nil
+		}
+
+		if err != nil {
+			return
+		}
+
+		length = int64(len(rewritten))
+
+		return true, nil
+	})
+
+	return
+}
+
+// STRLEN returns the length in bytes of the value stored at key, or 0 if the key doesn't exist or has expired.
+// Numbers count the digits DynamoDB stores for them.
+//
+// Cost is O(1) / 1 RCU per 4 KB of the value.
+//
+// Works similar to https://redis.io/commands/strlen
+func (c Client) STRLEN(key string) (length int64, err error) {
+	val, err := c.GET(key)
+
+	switch av := val.ToAV().(type) {
+	case *types.AttributeValueMemberS:
+		length = int64(len(av.Value))
+	case *types.AttributeValueMemberB:
+		length = int64(len(av.Value))
+	case *types.AttributeValueMemberN:
+		length = int64(len(av.Value))
+	}
 
 	return
 }
 
-// MGET fetches the given keys atomically in a transaction. The call is limited to 25 keys and 4MB.
+// MGET fetches the given keys atomically in a transaction. The call is limited to 25 keys and 4MB. Keys that
+// don't exist or have expired map to an Empty() ReturnValue.
 // See https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactGetItems.html
 //
 // Works similar to https://redis.io/commands/mget
 func (c Client) MGET(keys ...string) (values map[string]ReturnValue, err error) {
 	values = make(map[string]ReturnValue)
 	inputRequests := make([]types.TransactGetItem, len(keys))
+	projection, names := valueProjection(c.partitionKey, ttlKey)
 
 	for i, key := range keys {
 		inputRequests[i] = types.TransactGetItem{
@@ -126,8 +464,9 @@ func (c Client) MGET(keys ...string) (values map[string]ReturnValue, err error)
 					pk: key,
 					sk: "",
 				}.toAV(c),
-				ProjectionExpression: aws.String(strings.Join([]string{vk, c.partitionKey}, ", ")),
-				TableName:            aws.String(c.tableName),
+				ExpressionAttributeNames: names,
+				ProjectionExpression:     projection,
+				TableName:                aws.String(c.tableName),
 			},
 		}
 	}
@@ -140,29 +479,61 @@ func (c Client) MGET(keys ...string) (values map[string]ReturnValue, err error)
 		return
 	}
 
-	for _, item := range resp.Responses {
-		pi := parseItem(item.Item, c)
-		values[pi.pk] = pi.val
+	for i, item := range resp.Responses {
+		if len(item.Item) == 0 || itemExpired(item.Item) {
+			values[keys[i]] = ReturnValue{}
+			continue
+		}
+
+		if values[keys[i]], err = c.decodeValue(item.Item); err != nil {
+			return
+		}
 	}
 
 	return
 }
 
-// MSET sets the given keys and values atomically in a transaction. The call is limited to 25 keys and 4MB.
+// MSET sets the given keys and values, removing any expiry the keys had. If there are no more keys than fit into a
+// single transaction – see TransactionActions – they are set atomically in a transaction, which is limited to 4MB.
+// More keys are written with batched writes of 25 keys each, which are not atomic: if an error is returned, some of
+// the keys may have been set. Unprocessed items in a batch are retried with backoff.
 // See https://docs.aws.amazon.com/amazondynamodb/latest/APIReference/API_TransactWriteItems.html
 //
+// Cost is O(N) / 1 WCU per key, or 2 WCUs per key in a transaction.
+//
 // Works similar to https://redis.io/commands/mset
 func (c Client) MSET(vFieldMap interface{}) (err error) {
 	fieldMap, err := ToValueMapE(vFieldMap)
 	if err != nil {
 		return err
 	}
-	_, err = c.mset(fieldMap, Flags{})
-	return err
+
+	if len(fieldMap) <= c.transactionActions {
+		_, err = c.mset(fieldMap, Flags{})
+		return err
+	}
+
+	requests := make([]types.WriteRequest, 0, len(fieldMap))
+	for key, value := range fieldMap {
+		item, err := c.valueItem(key, "", value)
+		if err != nil {
+			return err
+		}
+
+		requests = append(requests, putRequest(item))
+	}
+
+	return c.batchWrite(requests)
 }
 
 // MSETNX sets the given keys and values atomically in a transaction, but only if none of the given
 // keys exist. If one or more of the keys already exist, nothing will be changed and MSETNX will return false.
+// Keys that have expired but not been deleted yet count as not existing.
+//
+// Either every key is created or none is, so all the keys have to fit into a single transaction: with more keys
+// than TransactionActions, ErrTooManyKeys is returned without writing anything.
+//
+// Cost is O(N) / 2 WCUs per key.
 //
 // Works similar to https://redis.io/commands/msetnx
 func (c Client) MSETNX(vFieldMap interface{}) (ok bool, err error) {
@@ -171,6 +542,10 @@ func (c Client) MSETNX(vFieldMap interface{}) (ok bool, err error) {
 		return ok, err
 	}
 
+	if len(fieldMap) > c.transactionActions {
+		return false, ErrTooManyKeys
+	}
+
 	ok, err = c.mset(fieldMap, Flags{IfNotExists})
 	return
 }
@@ -182,10 +557,14 @@ func (c Client) mset(data map[string]Value, flags Flags) (ok bool, err error) {
 		builder := newExpresionBuilder()
 
 		if flags.has(IfNotExists) {
-			builder.addConditionNotExists(c.partitionKey)
+			builder.addConditionNotExistsOrExpired(c.partitionKey)
+		}
+
+		if err = c.updateValue(&builder, v); err != nil {
+			return
 		}
 
-		builder.updateSET(vk, v)
+		builder.updateTTL(flags)
 
 		inputs = append(inputs, types.TransactWriteItem{
 			Update: &types.Update{
@@ -224,6 +603,11 @@ func (c Client) mset(data map[string]Value, flags Flags) (ok bool, err error) {
 //
 // The delta can be positive or negative, and a zero delta is effectively a no-op.
 //
+// The delta is sent as the shortest decimal that represents it (see FloatValue) and DynamoDB adds it to the stored
+// number in decimal arithmetic with up to 38 significant digits, so adding 0.1 ten times stores exactly 1. The
+// stored number is the exact result, and the returned value is the float64 closest to it. Expired counters are
+// handled like in INCRBY.
+//
 // If there is an existing value at the key with a non-numeric type (string, bytes, etc.)
 // the operation will throw an error. If the existing value is numeric, the operation
 // can continue irrespective of how it was initially set.
@@ -240,25 +624,88 @@ func (c Client) INCRBYFLOAT(key string, delta float64) (after float64, err error
 	return
 }
 
+// incr adds value to the number at key. Without FilterExpired it is a single unconditional ADD, so that concurrent
+// increments never conflict or retry; with FilterExpired it works like incrItem.
 func (c Client) incr(key string, value Value) (newValue ReturnValue, err error) {
+	if !c.filterExpired {
+		return c.addNumber(keyDef{pk: key, sk: ""}, value)
+	}
+
+	return c.incrItem(keyDef{pk: key, sk: ""}, value)
+}
+
+// incrItem adds value to the number of any item, like the fields of a hash. The ADD is conditional on the item not
+// having expired, and an item that has expired (see WithKeyTTL) is started over from value instead, which costs
+// an extra write the first time it is incremented after expiring and can retry under contention.
+func (c Client) incrItem(k keyDef, value Value) (newValue ReturnValue, err error) {
+	err = c.retryPolicy.retry(func() (done bool, err error) {
+		newValue, done, err = c.incrOnce(k, value, false)
+		if err != nil || done {
+			return true, err
+		}
+
+		newValue, done, err = c.incrOnce(k, value, true)
+
+		return err != nil || done, err
+	})
+
+	return
+}
+
+// addNumber adds value to the number of an item with a plain ADD.
+func (c Client) addNumber(k keyDef, value Value) (newValue ReturnValue, err error) {
 	builder := newExpresionBuilder()
 	builder.keys[vk] = struct{}{}
+	builder.values["delta"] = value.ToAV()
+
 	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: builder.expressionAttributeNames(),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":delta": value.ToAV(),
-		},
-		Key:              keyDef{pk: key, sk: ""}.toAV(c),
-		ReturnValues:     types.ReturnValueAllNew,
-		TableName:        aws.String(c.tableName),
-		UpdateExpression: aws.String("ADD #val :delta"),
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       k.toAV(c),
+		ReturnValues:              types.ReturnValueUpdatedNew,
+		TableName:                 aws.String(c.tableName),
+		UpdateExpression:          aws.String("ADD #val :delta"),
 	})
+	if err != nil {
+		return
+	}
 
-	if err == nil {
-		newValue = ReturnValue{resp.Attributes[vk]}
+	return ReturnValue{resp.Attributes[vk]}, nil
+}
+
+func (c Client) incrOnce(k keyDef, value Value, expired bool) (newValue ReturnValue, ok bool, err error) {
+	builder := newExpresionBuilder()
+	builder.keys[vk] = struct{}{}
+	builder.keys[ttlKey] = struct{}{}
+	builder.values["delta"] = value.ToAV()
+	builder.values["now"] = IntValue{time.Now().Unix()}.ToAV()
+
+	update := "ADD #val :delta"
+	condition := fmt.Sprintf("attribute_not_exists(#%v) OR #%v > :now", ttlKey, ttlKey)
+
+	if expired {
+		update = fmt.Sprintf("SET #val = :delta REMOVE #%v", ttlKey)
+		condition = fmt.Sprintf("#%v <= :now", ttlKey)
 	}
 
-	return
+	resp, err := c.ddbClient.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  builder.expressionAttributeNames(),
+		ExpressionAttributeValues: builder.expressionAttributeValues(),
+		Key:                       k.toAV(c),
+		ReturnValues:              types.ReturnValueUpdatedNew,
+		TableName:                 aws.String(c.tableName),
+		UpdateExpression:          aws.String(update),
+	})
+	if conditionFailureError(err) {
+		return newValue, false, nil
+	}
+
+	if err != nil {
+		return newValue, false, err
+	}
+
+	return ReturnValue{resp.Attributes[vk]}, true, nil
 }
 
 // INCR increments the number stored at the key by 1 (n = n + 1) and returns the new value. If the
@@ -296,7 +743,13 @@ func (c Client) DECR(key string) (after int64, err error) {
 // the operation will throw an error. If the existing value is numeric, the operation
 // can continue irrespective of how it was initially set.
 //
-// Cost is O(1) or 1 WCU.
+// The increment is a single unconditional DynamoDB ADD, so concurrent increments never conflict or retry. A counter
+// keeps the expiry it was SET with, and is incremented like any other until DynamoDB deletes it, even though GET
+// already hides it. With FilterExpired the next increment after the expiry starts the counter over from zero without
+// an expiry instead, so SET with WithKeyTTL followed by increments works as a fixed window counter – at the price of
+// making the ADD conditional.
+//
+// Cost is O(1) or 1 WCU, plus 1 WCU to start over an expired counter with FilterExpired.
 //
 // Works similar to https://redis.io/commands/incrby
 func (c Client) INCRBY(key string, delta int64) (after int64, err error) {
@@ -1,8 +1,13 @@
 package redimo
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -141,3 +146,317 @@ func TestAtomicOps(t *testing.T) {
 	assert.Equal(t, "v5", values["k5"].String())
 	assert.Equal(t, "v6", values["k6"].String())
 }
+
+func TestStringTTL(t *testing.T) {
+	c := newClient(t)
+
+	ok, err := c.SET("session", StringValue{"alive"}, WithKeyTTL(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	val, err := c.GET("session")
+	assert.NoError(t, err)
+	assert.Equal(t, "alive", val.String())
+
+	ok, err = c.SET("session", StringValue{"gone"}, WithKeyTTL(-time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	val, err = c.GET("session")
+	assert.NoError(t, err)
+	assert.True(t, val.Empty())
+
+	values, err := c.MGET("session")
+	assert.NoError(t, err)
+	assert.False(t, values["session"].Present())
+
+	ok, err = c.SET("session", StringValue{"again"}, IfAlreadyExists)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.SETNX("session", StringValue{"new"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	val, err = c.GET("session")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", val.String())
+
+	ok, err = c.SET("session", StringValue{"expiring"}, WithKeyTTL(-time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	oldValue, err := c.GETSET("session", StringValue{"forever"})
+	assert.NoError(t, err)
+	assert.True(t, oldValue.Empty())
+
+	val, err = c.GET("session")
+	assert.NoError(t, err)
+	assert.Equal(t, "forever", val.String())
+}
+
+func TestSetGet(t *testing.T) {
+	c := newClient(t)
+
+	oldValue, ok, err := c.SETGET("lock", "alice", IfNotExists, WithKeyTTL(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, oldValue.Empty())
+
+	oldValue, ok, err = c.SETGET("lock", "bob", IfNotExists)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "alice", oldValue.String())
+
+	oldValue, ok, err = c.SETGET("lock", "carol", IfAlreadyExists, KeepTTL)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", oldValue.String())
+
+	ok, err = c.SET("lock", "dave", IfNotExists)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = c.SET("lock", "erin", KeepTTL)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	val, err := c.GET("lock")
+	assert.NoError(t, err)
+	assert.Equal(t, "erin", val.String())
+
+	oldValue, ok, err = c.SETGET("vacant", "x", IfAlreadyExists)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, oldValue.Empty())
+}
+
+func TestAppend(t *testing.T) {
+	c := newClient(t)
+
+	length, err := c.APPEND("greeting", "Hello")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), length)
+
+	length, err = c.APPEND("greeting", " World")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), length)
+
+	val, err := c.GET("greeting")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello World", val.String())
+
+	_, err = c.SET("blob", []byte{1, 2})
+	assert.NoError(t, err)
+
+	length, err = c.APPEND("blob", "\x03")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), length)
+
+	val, err = c.GET("blob")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, val.Bytes())
+
+	_, err = c.SET("stale", "old", WithKeyTTL(-time.Hour))
+	assert.NoError(t, err)
+
+	length, err = c.APPEND("stale", "new")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), length)
+
+	val, err = c.GET("stale")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", val.String())
+}
+
+func TestStringRanges(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SET("k", "Hello World")
+	assert.NoError(t, err)
+
+	length, err := c.SETRANGE("k", 6, "Redis")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), length)
+
+	val, err := c.GET("k")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Redis", val.String())
+
+	substring, err := c.GETRANGE("k", 0, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello", substring)
+
+	substring, err = c.GETRANGE("k", -5, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Redis", substring)
+
+	substring, err = c.GETRANGE("k", 6, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, "Redis", substring)
+
+	substring, err = c.GETRANGE("k", 5, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "", substring)
+
+	length, err = c.SETRANGE("padded", 3, "x")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), length)
+
+	val, err = c.GET("padded")
+	assert.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x00x", val.String())
+
+	_, err = c.SETRANGE("padded", -1, "x")
+	assert.Equal(t, ErrOffsetOutOfRange, err)
+
+	_, err = c.SETRANGE("padded", 1<<40, "x")
+	assert.Equal(t, ErrOffsetOutOfRange, err)
+
+	_, err = c.SETRANGE("padded", math.MaxInt64, "x")
+	assert.Equal(t, ErrOffsetOutOfRange, err)
+
+	substring, err = c.GETRANGE("nothing", 0, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", substring)
+}
+
+func TestExpiringCounters(t *testing.T) {
+	c := newClient(t)
+
+	_, err := c.SET("window", IntValue{10}, WithKeyTTL(time.Hour))
+	assert.NoError(t, err)
+
+	count, err := c.INCRBY("window", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), count)
+
+	_, err = c.SET("window", IntValue{10}, WithKeyTTL(-time.Hour))
+	assert.NoError(t, err)
+
+	count, err = c.INCR("window")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), count)
+
+	filtered := c.FilterExpired()
+
+	count, err = filtered.INCR("window")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = filtered.INCR("window")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestIncrByFloatPrecision(t *testing.T) {
+	c := newClient(t)
+
+	for i := 0; i < 10; i++ {
+		_, err := c.INCRBYFLOAT("balance", 0.1)
+		assert.NoError(t, err)
+	}
+
+	val, err := c.GET("balance")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", val.ToAV().(*types.AttributeValueMemberN).Value)
+
+	after, err := c.INCRBYFLOAT("balance", 0.2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.2, after)
+}
+
+func TestMSetBatched(t *testing.T) {
+	unlimited := newClient(t)
+	c := unlimited.TransactionActions(2)
+
+	err := c.MSET(map[string]string{"b1": "v1", "b2": "v2", "b3": "v3", "b4": "v4", "b5": "v5"})
+	assert.NoError(t, err)
+
+	values, err := c.MGET("b1", "b2", "b3", "b4", "b5")
+	assert.NoError(t, err)
+	assert.Len(t, values, 5)
+	assert.Equal(t, "v1", values["b1"].String())
+	assert.Equal(t, "v5", values["b5"].String())
+
+	ok, err := c.MSETNX(map[string]string{"n1": "v1", "n2": "v2", "n3": "v3"})
+	assert.Equal(t, ErrTooManyKeys, err)
+	assert.False(t, ok)
+
+	exists, err := c.EXISTS("n1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	ok, err = c.MSETNX(map[string]string{"n1": "v1", "b1": "v2"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	exists, err = c.EXISTS("n1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestJSONStrings(t *testing.T) {
+	c := newClient(t)
+
+	type profile struct {
+		Name    string
+		Age     int
+		Created time.Time
+	}
+
+	created := time.Date(2020, 5, 17, 10, 30, 0, 0, time.UTC)
+
+	ok, err := c.SETJSON("profile", profile{"alice", 30, created})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	var p profile
+	found, err := c.GETJSON("profile", &p)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "alice", p.Name)
+	assert.Equal(t, 30, p.Age)
+	assert.True(t, created.Equal(p.Created))
+
+	found, err = c.GETJSON("nobody", &p)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestCAS(t *testing.T) {
+	plain := newClient(t)
+
+	for _, c := range []Client{plain, plain.Compression(GzipCodec, 10)} {
+		key := fmt.Sprintf("leader-%v", c.codec != nil)
+		long := strings.Repeat("node-", 10)
+
+		ok, err := c.CAS(key, nil, long)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = c.CAS(key, nil, "node-b")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = c.CAS(key, "node-c", "node-b")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = c.CAS(key, long, "node-b", WithKeyTTL(time.Hour))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		val, err := c.GET(key)
+		assert.NoError(t, err)
+		assert.Equal(t, "node-b", val.String())
+
+		ok, err = c.CAS(key, "node-b", long, KeepTTL)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		val, err = c.GET(key)
+		assert.NoError(t, err)
+		assert.Equal(t, long, val.String())
+	}
+}
@@ -0,0 +1,130 @@
+package redimo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ttlKey is the attribute that holds the expiry time of an item, in seconds since the Unix epoch. Enable
+// DynamoDB's Time to Live on this attribute to have expired items deleted automatically – DynamoDB usually
+// deletes them within a few days of expiry, so reads filter them out in the meantime, see FilterExpired.
+const ttlKey = "ttl"
+
+const ttlFlagPrefix = "TTL="
+
+// NoExpiry is the TTL reported for items that exist but don't expire.
+const NoExpiry time.Duration = -1
+
+// WithMemberTTL returns a flag that makes the members written by ZADD expire after ttl. Members written without
+// it don't expire, even if they had a TTL before.
+func WithMemberTTL(ttl time.Duration) Flag {
+	return Flag(ttlFlagPrefix + strconv.FormatInt(int64(ttl), 10))
+}
+
+// WithKeyTTL returns a flag that makes the key written by SET expire after ttl, like the EX and PX options in Redis.
+// The expiry is stored with a precision of one second, rounded up. Keys written without it don't expire, even if
+// they had a TTL before.
+func WithKeyTTL(ttl time.Duration) Flag {
+	return WithMemberTTL(ttl)
+}
+
+// KeepTTL makes a write keep the expiry the item already had, instead of removing it, like the KEEPTTL option in
+// Redis. It takes precedence over WithKeyTTL and WithMemberTTL.
+const KeepTTL Flag = "KEEPTTL"
+
+func (flags Flags) ttl() (ttl time.Duration, ok bool) {
+	for _, f := range flags {
+		if strings.HasPrefix(string(f), ttlFlagPrefix) {
+			nanos, err := strconv.ParseInt(strings.TrimPrefix(string(f), ttlFlagPrefix), 10, 64)
+			if err == nil {
+				return time.Duration(nanos), true
+			}
+		}
+	}
+
+	return
+}
+
+// expiryAV converts a TTL into the expiry timestamp stored in ttlKey, rounded up to the next second.
+func expiryAV(ttl time.Duration) types.AttributeValue {
+	expiry := time.Now().Add(ttl + time.Second - 1).Unix()
+	return IntValue{expiry}.ToAV()
+}
+
+// updateTTL sets the expiry attribute if flags carry a TTL, leaves it alone with KeepTTL, and removes it otherwise.
+func (b *expressionBuilder) updateTTL(flags Flags) {
+	if flags.has(KeepTTL) {
+		return
+	}
+
+	if ttl, ok := flags.ttl(); ok {
+		b.updateSetAV(ttlKey, expiryAV(ttl))
+		return
+	}
+
+	b.clauses["REMOVE"] = append(b.clauses["REMOVE"], "#"+ttlKey)
+	b.keys[ttlKey] = struct{}{}
+}
+
+// expired reports whether a fetched item has expired but not been deleted by DynamoDB yet. Items are only
+// ever reported as expired if the client filters expired items.
+func (c Client) expired(item map[string]types.AttributeValue) bool {
+	return c.filterExpired && itemExpired(item)
+}
+
+// itemExpired reports whether a fetched item has an expiry time that has passed.
+func itemExpired(item map[string]types.AttributeValue) bool {
+	expiry, ok := item[ttlKey].(*types.AttributeValueMemberN)
+	if !ok {
+		return false
+	}
+
+	return ReturnValue{expiry}.Int() <= time.Now().Unix()
+}
+
+// addConditionNotExistsOrExpired makes a write conditional on the item not existing, treating an item that has
+// expired but not been deleted yet as not existing.
+func (b *expressionBuilder) addConditionNotExistsOrExpired(attributeName string) {
+	b.values["now"] = IntValue{time.Now().Unix()}.ToAV()
+	b.condition(fmt.Sprintf("(attribute_not_exists(#%v) OR #%v <= :now)", attributeName, ttlKey), attributeName, ttlKey)
+}
+
+// addConditionExistsUnexpired makes a write conditional on the item existing and not having expired.
+func (b *expressionBuilder) addConditionExistsUnexpired(attributeName string) {
+	b.values["now"] = IntValue{time.Now().Unix()}.ToAV()
+	b.condition(fmt.Sprintf("attribute_exists(#%v) AND (attribute_not_exists(#%v) OR #%v > :now)",
+		attributeName, ttlKey, ttlKey), attributeName, ttlKey)
+}
+
+// filterExpiredItems adds a filter for expired items to a query if the client filters expired items. Filtering
+// is done by DynamoDB after reading, so expired items still consume read capacity.
+func (c Client) filterExpiredItems(input *dynamodb.QueryInput) *dynamodb.QueryInput {
+	if !c.filterExpired {
+		return input
+	}
+
+	return excludeExpiredItems(input)
+}
+
+// excludeExpiredItems adds a filter for expired items to a query, whether or not the client filters expired items.
+func excludeExpiredItems(input *dynamodb.QueryInput) *dynamodb.QueryInput {
+	if input.ExpressionAttributeNames == nil {
+		input.ExpressionAttributeNames = make(map[string]string)
+	}
+
+	if input.ExpressionAttributeValues == nil {
+		input.ExpressionAttributeValues = make(map[string]types.AttributeValue)
+	}
+
+	input.ExpressionAttributeNames["#"+ttlKey] = ttlKey
+	input.ExpressionAttributeValues[":now"] = IntValue{time.Now().Unix()}.ToAV()
+	input.FilterExpression = aws.String(fmt.Sprintf("attribute_not_exists(#%v) OR #%v > :now", ttlKey, ttlKey))
+
+	return input
+}
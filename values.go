@@ -1,10 +1,14 @@
 package redimo
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -53,7 +57,7 @@ func ToValueE(data interface{}) (value Value, err error) {
 	case int64:
 		value = IntValue{int64(data)}
 	case uint:
-		value = IntValue{int64(data)}
+		value = uintValue(uint64(data))
 	case uint8:
 		value = IntValue{int64(data)}
 	case uint16:
@@ -61,17 +65,68 @@ func ToValueE(data interface{}) (value Value, err error) {
 	case uint32:
 		value = IntValue{int64(data)}
 	case uint64:
-		value = IntValue{int64(data)}
+		value = uintValue(data)
 	case float32:
 		value = FloatValue{float64(data)}
 	case float64:
 		value = FloatValue{float64(data)}
+	case bool:
+		value = BoolValue{data}
+	case time.Time:
+		value = TimeValue{data}
+	case Value:
+		value = data
 	default:
-		err = fmt.Errorf("ToValue: unsupported type: %T", data)
+		value, err = reflectValue(data)
 	}
 	return value, err
 }
 
+// reflectValue converts named types based on the basic types, like time.Duration or a string enum, by their kind.
+func reflectValue(data interface{}) (value Value, err error) {
+	rv := reflect.ValueOf(data)
+
+	switch rv.Kind() {
+	case reflect.String:
+		return StringValue{rv.String()}, nil
+	case reflect.Bool:
+		return BoolValue{rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return IntValue{rv.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uintValue(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return FloatValue{rv.Float()}, nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return BytesValue{rv.Bytes()}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("ToValue: unsupported type: %T", data)
+}
+
+// uintValue converts an unsigned integer to a number, writing values that don't fit into an int64 as their decimal
+// string instead of letting them wrap around.
+func uintValue(u uint64) Value {
+	if u > math.MaxInt64 {
+		return ReturnValue{&types.AttributeValueMemberN{Value: strconv.FormatUint(u, 10)}}
+	}
+
+	return IntValue{int64(u)}
+}
+
+// ToJSONValue marshals v to JSON and wraps it in a StringValue, for storing structs and other values that have no
+// direct DynamoDB representation. Read it back with ReturnValue.JSON.
+func ToJSONValue(v interface{}) (value Value, err error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	return StringValue{string(data)}, nil
+}
+
 func ToValue(data interface{}) Value {
 	value, err := ToValueE(data)
 	if err != nil {
@@ -168,12 +223,16 @@ func (sv StringValue) ToAV() types.AttributeValue {
 // FloatValue is a convenience value wrapper for a float64, usable as
 //
 //	FloatValue{3.14}
+//
+// The float is stored as the shortest decimal that parses back to the same float64, so 0.1 is stored as 0.1
+// rather than as 0.10000000000000001. DynamoDB does arithmetic on numbers in decimal, so increments by such values
+// don't accumulate binary rounding errors.
 type FloatValue struct {
 	F float64
 }
 
 func (fv FloatValue) ToAV() types.AttributeValue {
-	return &types.AttributeValueMemberN{Value: strconv.FormatFloat(fv.F, 'G', 17, 64)}
+	return &types.AttributeValueMemberN{Value: strconv.FormatFloat(fv.F, 'G', -1, 64)}
 }
 
 // IntValue is a convenience value wrapper for an int64, usable as
@@ -198,6 +257,33 @@ func (bv BytesValue) ToAV() types.AttributeValue {
 	return &types.AttributeValueMemberB{Value: bv.B}
 }
 
+// BoolValue is a convenience value wrapper for a bool, usable as
+//
+//	BoolValue{true}
+type BoolValue struct {
+	B bool
+}
+
+func (bv BoolValue) ToAV() types.AttributeValue {
+	return &types.AttributeValueMemberBOOL{Value: bv.B}
+}
+
+// TimeValue is a convenience value wrapper for a time.Time, usable as
+//
+//	TimeValue{time.Now()}
+//
+// The time is stored as an RFC 3339 string in UTC with all nine digits of the nanoseconds, so that stored times sort
+// in time order.
+type TimeValue struct {
+	T time.Time
+}
+
+const timeValueLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+func (tv TimeValue) ToAV() types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: tv.T.UTC().Format(timeValueLayout)}
+}
+
 // ReturnValue holds a value returned by DynamoDB. There are convenience methods used to coerce the held value into common types,
 // but you can also retrieve the raw types.AttributeValue by calling ToAV if you would like to do custom decoding.
 type ReturnValue struct {
@@ -260,6 +346,115 @@ func (rv ReturnValue) Bytes() []byte {
 	return nil
 }
 
+// ErrWrongType is returned by IntE, FloatE and BytesE, and the typed getters built on them like HGetInt, when a
+// value can't be converted to the requested type. The returned errors wrap it, so check for it with errors.Is.
+var ErrWrongType = errors.New("value can't be converted to the requested type")
+
+// IntE returns the value as int64 like Int, but returns an error wrapping ErrWrongType instead of zero if the value
+// isn't a whole number. Strings holding whole numbers are converted, like Redis does.
+func (rv ReturnValue) IntE() (int64, error) {
+	var text string
+
+	switch av := rv.av.(type) {
+	case *types.AttributeValueMemberN:
+		text = av.Value
+	case *types.AttributeValueMemberS:
+		text = av.Value
+	default:
+		return 0, fmt.Errorf("%w: %T is not an integer", ErrWrongType, rv.av)
+	}
+
+	f, _, err := new(big.Float).Parse(text, 10)
+	if err != nil || !f.IsInt() {
+		return 0, fmt.Errorf("%w: %q is not an integer", ErrWrongType, text)
+	}
+
+	i, accuracy := f.Int64()
+	if accuracy != big.Exact {
+		return 0, fmt.Errorf("%w: %q overflows int64", ErrWrongType, text)
+	}
+
+	return i, nil
+}
+
+// FloatE returns the value as float64 like Float, but returns an error wrapping ErrWrongType instead of zero if the
+// value isn't a number. Strings holding numbers are converted, like Redis does.
+func (rv ReturnValue) FloatE() (float64, error) {
+	var text string
+
+	switch av := rv.av.(type) {
+	case *types.AttributeValueMemberN:
+		text = av.Value
+	case *types.AttributeValueMemberS:
+		text = av.Value
+	default:
+		return 0, fmt.Errorf("%w: %T is not a number", ErrWrongType, rv.av)
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a number", ErrWrongType, text)
+	}
+
+	return f, nil
+}
+
+// BytesE returns the value as a byte slice like Bytes, but also converts strings, and returns an error wrapping
+// ErrWrongType for any other type.
+func (rv ReturnValue) BytesE() ([]byte, error) {
+	switch av := rv.av.(type) {
+	case *types.AttributeValueMemberB:
+		return av.Value, nil
+	case *types.AttributeValueMemberS:
+		return []byte(av.Value), nil
+	}
+
+	return nil, fmt.Errorf("%w: %T is not binary", ErrWrongType, rv.av)
+}
+
+// Bool returns the value as a bool. Will be false if the value is not actually a bool.
+func (rv ReturnValue) Bool() bool {
+	if av, ok := rv.av.(*types.AttributeValueMemberBOOL); ok {
+		return av.Value
+	}
+
+	return false
+}
+
+// Time returns the value as a time.Time. Strings are parsed as RFC 3339 timestamps, like the ones stored by
+// TimeValue, and numbers as seconds since the Unix epoch. Will be the zero time if the value is neither.
+func (rv ReturnValue) Time() time.Time {
+	switch av := rv.av.(type) {
+	case *types.AttributeValueMemberS:
+		t, _ := time.Parse(time.RFC3339Nano, av.Value)
+		return t
+	case *types.AttributeValueMemberN:
+		seconds, err := strconv.ParseFloat(av.Value, 64)
+		if err != nil {
+			return time.Time{}
+		}
+
+		whole := math.Floor(seconds)
+
+		return time.Unix(int64(whole), int64((seconds-whole)*1e9))
+	}
+
+	return time.Time{}
+}
+
+// JSON unmarshals a value stored with ToJSONValue into v. An Empty() value leaves v unchanged.
+func (rv ReturnValue) JSON(v interface{}) error {
+	if rv.Empty() {
+		return nil
+	}
+
+	if b := rv.Bytes(); b != nil {
+		return json.Unmarshal(b, v)
+	}
+
+	return json.Unmarshal([]byte(rv.String()), v)
+}
+
 // Empty returns true if the value is empty or uninitialized. This
 // indicates that the underlying DynamoDB operation did not return a value.
 func (rv ReturnValue) Empty() bool {
@@ -1,8 +1,13 @@
 package redimo
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,6 +22,8 @@ func TestValues(t *testing.T) {
 
 	fv := FloatValue{3.14}
 	assert.InDelta(t, 3.14, ReturnValue{fv.ToAV()}.Float(), 0.001)
+	assert.Equal(t, "0.1", FloatValue{0.1}.ToAV().(*types.AttributeValueMemberN).Value)
+	assert.Equal(t, "1E+21", FloatValue{1e21}.ToAV().(*types.AttributeValueMemberN).Value)
 
 	bv := BytesValue{[]byte{1, 2, 3, 4}}
 	assert.Equal(t, []byte{1, 2, 3, 4}, ReturnValue{bv.ToAV()}.Bytes())
@@ -24,8 +31,101 @@ func TestValues(t *testing.T) {
 	assert.True(t, ReturnValue{}.Empty())
 	assert.False(t, ReturnValue{}.Present())
 
+	type blob []byte
+	value, err := ToValueE(blob{5, 6})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5, 6}, ReturnValue{value.ToAV()}.Bytes())
+
+	value, err = ToValueE(json.RawMessage(`{"a":1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{"a":1}`), ReturnValue{value.ToAV()}.Bytes())
+
+	value, err = ToValueE(uint64(math.MaxUint64))
+	assert.NoError(t, err)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "18446744073709551615"}, value.ToAV())
+
+	type counter uint64
+	value, err = ToValueE(counter(math.MaxUint64))
+	assert.NoError(t, err)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "18446744073709551615"}, value.ToAV())
+
+	value, err = ToValueE(zScore{1.5})
+	assert.NoError(t, err)
+	assert.Equal(t, zScore{1.5}, value)
+
+	_, err = ToValueE(struct{}{})
+	assert.Error(t, err)
+
+	value, err = ToValueE(90 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, IntValue{int64(90 * time.Second)}, value)
+
+	type color string
+	value, err = ToValueE(color("red"))
+	assert.NoError(t, err)
+	assert.Equal(t, StringValue{"red"}, value)
+
+	value, err = ToValueE(true)
+	assert.NoError(t, err)
+	assert.True(t, ReturnValue{value.ToAV()}.Bool())
+
+	moment := time.Date(2020, 5, 17, 10, 30, 0, 500, time.FixedZone("IST", 19800))
+	value, err = ToValueE(moment)
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-05-17T05:00:00.000000500Z", ReturnValue{value.ToAV()}.String())
+	assert.True(t, moment.Equal(ReturnValue{value.ToAV()}.Time()))
+	assert.True(t, time.Unix(1589709600, 0).Equal(ReturnValue{IntValue{1589709600}.ToAV()}.Time()))
+	assert.True(t, ReturnValue{}.Time().IsZero())
+
+	type point struct {
+		X, Y int
+	}
+	value, err = ToJSONValue(point{1, 2})
+	assert.NoError(t, err)
+
+	var p point
+	assert.NoError(t, ReturnValue{value.ToAV()}.JSON(&p))
+	assert.Equal(t, point{1, 2}, p)
+
 	// Ensure that return value indicates presence even with empty and zero values
 	assert.True(t, ReturnValue{BytesValue{[]byte{}}.ToAV()}.Present())
 	assert.True(t, ReturnValue{IntValue{0}.ToAV()}.Present())
 	assert.True(t, ReturnValue{StringValue{""}.ToAV()}.Present())
 }
+
+func TestTypedValues(t *testing.T) {
+	i, err := ReturnValue{IntValue{42}.ToAV()}.IntE()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), i)
+
+	i, err = ReturnValue{StringValue{"-7"}.ToAV()}.IntE()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-7), i)
+
+	for _, v := range []Value{FloatValue{1.5}, StringValue{"abc"}, BoolValue{true}, StringValue{"1e30"}} {
+		_, err = ReturnValue{v.ToAV()}.IntE()
+		assert.True(t, errors.Is(err, ErrWrongType), "%v", v)
+	}
+
+	f, err := ReturnValue{FloatValue{1.5}.ToAV()}.FloatE()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, f)
+
+	f, err = ReturnValue{StringValue{"2.25"}.ToAV()}.FloatE()
+	assert.NoError(t, err)
+	assert.Equal(t, 2.25, f)
+
+	_, err = ReturnValue{BytesValue{[]byte{1}}.ToAV()}.FloatE()
+	assert.True(t, errors.Is(err, ErrWrongType))
+
+	b, err := ReturnValue{BytesValue{[]byte{1, 2}}.ToAV()}.BytesE()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, b)
+
+	b, err = ReturnValue{StringValue{"hi"}.ToAV()}.BytesE()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hi"), b)
+
+	_, err = ReturnValue{IntValue{1}.ToAV()}.BytesE()
+	assert.True(t, errors.Is(err, ErrWrongType))
+}